@@ -0,0 +1,128 @@
+package memdb
+
+import (
+	"container/list"
+	"sort"
+	"sync"
+)
+
+type (
+	capped struct {
+		maxDocuments int
+		maxBytes     int
+		order        *list.List
+		elements     map[any]*list.Element
+		size         int
+		seq          uint64
+		lock         sync.Mutex
+	}
+
+	cappedEntry struct {
+		id   any
+		seq  uint64
+		size int
+	}
+)
+
+func newCapped(maxDocuments, maxBytes int) *capped {
+	return &capped{
+		maxDocuments: maxDocuments,
+		maxBytes:     maxBytes,
+		order:        list.New(),
+		elements:     map[any]*list.Element{},
+	}
+}
+
+func (c *capped) push(id any, document map[string]any) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	size := sizeOf(document)
+	if elem, ok := c.elements[id]; ok {
+		entry := elem.Value.(*cappedEntry)
+		c.size += size - entry.size
+		entry.size = size
+		return
+	}
+
+	c.seq++
+	c.elements[id] = c.order.PushBack(&cappedEntry{id: id, seq: c.seq, size: size})
+	c.size += size
+}
+
+func (c *capped) remove(id any) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if elem, ok := c.elements[id]; ok {
+		c.order.Remove(elem)
+		delete(c.elements, id)
+		c.size -= elem.Value.(*cappedEntry).size
+	}
+}
+
+func (c *capped) overflow() []any {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var ids []any
+	for c.order.Len() > 1 && c.exceeded() {
+		elem := c.order.Front()
+		entry := elem.Value.(*cappedEntry)
+
+		c.order.Remove(elem)
+		delete(c.elements, entry.id)
+		c.size -= entry.size
+
+		ids = append(ids, entry.id)
+	}
+	return ids
+}
+
+func (c *capped) sort(docs []map[string]any, pk primaryKey) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	seqs := make(map[int]uint64, len(docs))
+	for i, doc := range docs {
+		seqs[i] = ^uint64(0)
+		if id, ok := pk.id(doc); ok {
+			if elem, ok := c.elements[id]; ok {
+				seqs[i] = elem.Value.(*cappedEntry).seq
+			}
+		}
+	}
+
+	indices := make([]int, len(docs))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		return seqs[indices[i]] < seqs[indices[j]]
+	})
+
+	sorted := make([]map[string]any, len(docs))
+	for i, j := range indices {
+		sorted[i] = docs[j]
+	}
+	copy(docs, sorted)
+}
+
+func (c *capped) reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.order.Init()
+	c.elements = map[any]*list.Element{}
+	c.size = 0
+}
+
+func (c *capped) exceeded() bool {
+	if c.maxDocuments > 0 && c.order.Len() > c.maxDocuments {
+		return true
+	}
+	if c.maxBytes > 0 && c.size > c.maxBytes {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,656 @@
+package memdb
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/siyul-park/memdb/internal/raft"
+	"github.com/siyul-park/memdb/internal/util"
+	"time"
+)
+
+type (
+	Node struct {
+		db      *Database
+		raft    *raft.Node
+		timeout time.Duration
+	}
+
+	NodeOptions struct {
+		Peers             []string
+		TickInterval      *time.Duration
+		ElectionTick      *int
+		HeartbeatTick     *int
+		SnapshotThreshold *int
+		Timeout           *time.Duration
+	}
+
+	ReplicatedCollection struct {
+		name string
+		node *Node
+	}
+
+	ReplicatedIndexView struct {
+		coll *ReplicatedCollection
+	}
+
+	Transport       = raft.Transport
+	MemoryTransport = raft.MemoryTransport
+	Message         = raft.Message
+
+	command struct {
+		collection string
+		op         operation
+		filter     *Filter
+		documents  []map[string]any
+		update     map[string]any
+		options    *UpdateOptions
+		settings   *CollectionOptions
+		index      *IndexModel
+		name       string
+		generated  any
+	}
+
+	commandResult struct {
+		value any
+		err   error
+	}
+
+	operation int
+
+	stateMachine struct {
+		db *Database
+	}
+
+	databaseSnapshot map[string]collectionSnapshot
+
+	collectionSnapshot struct {
+		options   *CollectionOptions
+		indexes   []IndexModel
+		documents []map[string]any
+	}
+)
+
+const (
+	opInsertOne operation = iota
+	opInsertMany
+	opUpdateOne
+	opUpdateMany
+	opDeleteOne
+	opDeleteMany
+	opCreateIndex
+	opDropIndex
+	opCreateCollection
+	opDropCollection
+	opRenameCollection
+)
+
+var (
+	ErrCodeNotLeader         = raft.ErrCodeNotLeader
+	ErrCodeProposalDropped   = raft.ErrCodeProposalDropped
+	ErrCodeConfChangePending = raft.ErrCodeConfChangePending
+	ErrCodeNodeStopped       = raft.ErrCodeStopped
+
+	ErrNotLeader         = raft.ErrNotLeader
+	ErrProposalDropped   = raft.ErrProposalDropped
+	ErrConfChangePending = raft.ErrConfChangePending
+	ErrNodeStopped       = raft.ErrStopped
+)
+
+var _ raft.StateMachine = (*stateMachine)(nil)
+
+func NewMemoryTransport() *MemoryTransport {
+	return raft.NewMemoryTransport()
+}
+
+func NewNode(id string, db *Database, transport Transport, opts ...*NodeOptions) *Node {
+	opt := mergeNodeOptions(opts)
+
+	config := raft.Config{
+		ID:           id,
+		Transport:    transport,
+		StateMachine: &stateMachine{db: db},
+	}
+	timeout := 5 * time.Second
+	if !util.IsNil(opt) {
+		config.Peers = opt.Peers
+		config.TickInterval = util.UnPtr(opt.TickInterval)
+		config.ElectionTick = util.UnPtr(opt.ElectionTick)
+		config.HeartbeatTick = util.UnPtr(opt.HeartbeatTick)
+		config.SnapshotThreshold = util.UnPtr(opt.SnapshotThreshold)
+		if !util.IsNil(opt.Timeout) {
+			timeout = util.UnPtr(opt.Timeout)
+		}
+	}
+
+	return &Node{
+		db:      db,
+		raft:    raft.NewNode(config),
+		timeout: timeout,
+	}
+}
+
+func (n *Node) ID() string {
+	return n.raft.ID()
+}
+
+func (n *Node) Database() *Database {
+	return n.db
+}
+
+func (n *Node) Collection(name string) *ReplicatedCollection {
+	return &ReplicatedCollection{
+		name: name,
+		node: n,
+	}
+}
+
+func (n *Node) CreateCollection(name string, opts ...*CollectionOptions) (*ReplicatedCollection, error) {
+	return n.CreateCollectionContext(context.Background(), name, opts...)
+}
+
+func (n *Node) CreateCollectionContext(ctx context.Context, name string, opts ...*CollectionOptions) (*ReplicatedCollection, error) {
+	if _, err := n.propose(ctx, &command{
+		collection: name,
+		op:         opCreateCollection,
+		settings:   mergeCollectionOptions(opts),
+	}); err != nil {
+		return nil, err
+	}
+	return n.Collection(name), nil
+}
+
+func (n *Node) DropCollection(name string) error {
+	return n.DropCollectionContext(context.Background(), name)
+}
+
+func (n *Node) DropCollectionContext(ctx context.Context, name string) error {
+	_, err := n.propose(ctx, &command{
+		collection: name,
+		op:         opDropCollection,
+	})
+	return err
+}
+
+func (n *Node) RenameCollection(from, to string) error {
+	return n.RenameCollectionContext(context.Background(), from, to)
+}
+
+func (n *Node) RenameCollectionContext(ctx context.Context, from, to string) error {
+	_, err := n.propose(ctx, &command{
+		collection: from,
+		op:         opRenameCollection,
+		name:       to,
+	})
+	return err
+}
+
+func (n *Node) IsLeader() bool {
+	return n.raft.Status().State == raft.StateLeader
+}
+
+func (n *Node) Leader() string {
+	return n.raft.Status().Leader
+}
+
+func (n *Node) Members() []string {
+	return n.raft.Status().Members
+}
+
+func (n *Node) AddMember(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), n.timeout)
+	defer cancel()
+
+	return n.raft.ProposeConfChange(ctx, raft.ConfChange{Type: raft.ConfChangeAddNode, ID: id})
+}
+
+func (n *Node) RemoveMember(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), n.timeout)
+	defer cancel()
+
+	return n.raft.ProposeConfChange(ctx, raft.ConfChange{Type: raft.ConfChangeRemoveNode, ID: id})
+}
+
+func (n *Node) Close() {
+	n.raft.Stop()
+}
+
+func (n *Node) propose(ctx context.Context, cmd *command) (any, error) {
+	ctx, cancel := context.WithTimeout(ctx, n.timeout)
+	defer cancel()
+
+	if r, err := n.raft.Propose(ctx, cmd); err != nil {
+		return nil, err
+	} else if r, ok := r.(*commandResult); !ok {
+		return nil, nil
+	} else {
+		return r.value, r.err
+	}
+}
+
+func (coll *ReplicatedCollection) Name() string {
+	return coll.name
+}
+
+func (coll *ReplicatedCollection) Indexes() *ReplicatedIndexView {
+	return &ReplicatedIndexView{coll: coll}
+}
+
+func (coll *ReplicatedCollection) CreateIndex(index IndexModel) error {
+	return coll.CreateIndexContext(context.Background(), index)
+}
+
+func (coll *ReplicatedCollection) CreateIndexContext(ctx context.Context, index IndexModel) error {
+	_, err := coll.node.propose(ctx, &command{
+		collection: coll.name,
+		op:         opCreateIndex,
+		index:      &index,
+	})
+	return err
+}
+
+func (coll *ReplicatedCollection) DropIndex(name string) error {
+	return coll.DropIndexContext(context.Background(), name)
+}
+
+func (coll *ReplicatedCollection) DropIndexContext(ctx context.Context, name string) error {
+	_, err := coll.node.propose(ctx, &command{
+		collection: coll.name,
+		op:         opDropIndex,
+		name:       name,
+	})
+	return err
+}
+
+func (coll *ReplicatedCollection) Watch(listener func(event Event, val any)) int {
+	local, err := coll.local()
+	if err != nil {
+		return 0
+	}
+	return local.Watch(listener)
+}
+
+func (coll *ReplicatedCollection) Unwatch(listenerID int) {
+	if local, err := coll.local(); err == nil {
+		local.Unwatch(listenerID)
+	}
+}
+
+func (coll *ReplicatedCollection) InsertOne(document map[string]any) (any, error) {
+	return coll.InsertOneContext(context.Background(), document)
+}
+
+func (coll *ReplicatedCollection) InsertOneContext(ctx context.Context, document map[string]any) (any, error) {
+	return coll.node.propose(ctx, &command{
+		collection: coll.name,
+		op:         opInsertOne,
+		documents:  coll.prepare(util.Copy(document)),
+	})
+}
+
+func (coll *ReplicatedCollection) InsertMany(documents []map[string]any) ([]any, error) {
+	return coll.InsertManyContext(context.Background(), documents)
+}
+
+func (coll *ReplicatedCollection) InsertManyContext(ctx context.Context, documents []map[string]any) ([]any, error) {
+	if r, err := coll.node.propose(ctx, &command{
+		collection: coll.name,
+		op:         opInsertMany,
+		documents:  coll.prepare(util.Copy(documents)...),
+	}); err != nil {
+		return nil, err
+	} else {
+		ids, _ := r.([]any)
+		return ids, nil
+	}
+}
+
+func (coll *ReplicatedCollection) UpdateOne(filter *Filter, update map[string]any, opts ...*UpdateOptions) (bool, error) {
+	return coll.UpdateOneContext(context.Background(), filter, update, opts...)
+}
+
+func (coll *ReplicatedCollection) UpdateOneContext(ctx context.Context, filter *Filter, update map[string]any, opts ...*UpdateOptions) (bool, error) {
+	if r, err := coll.node.propose(ctx, &command{
+		collection: coll.name,
+		op:         opUpdateOne,
+		filter:     filter,
+		update:     util.Copy(update),
+		options:    mergeUpdateOptions(opts),
+		generated:  coll.generate(),
+	}); err != nil {
+		return false, err
+	} else {
+		ok, _ := r.(bool)
+		return ok, nil
+	}
+}
+
+func (coll *ReplicatedCollection) UpdateMany(filter *Filter, update map[string]any, opts ...*UpdateOptions) (int, error) {
+	return coll.UpdateManyContext(context.Background(), filter, update, opts...)
+}
+
+func (coll *ReplicatedCollection) UpdateManyContext(ctx context.Context, filter *Filter, update map[string]any, opts ...*UpdateOptions) (int, error) {
+	if r, err := coll.node.propose(ctx, &command{
+		collection: coll.name,
+		op:         opUpdateMany,
+		filter:     filter,
+		update:     util.Copy(update),
+		options:    mergeUpdateOptions(opts),
+		generated:  coll.generate(),
+	}); err != nil {
+		return 0, err
+	} else {
+		count, _ := r.(int)
+		return count, nil
+	}
+}
+
+func (coll *ReplicatedCollection) DeleteOne(filter *Filter) (bool, error) {
+	return coll.DeleteOneContext(context.Background(), filter)
+}
+
+func (coll *ReplicatedCollection) DeleteOneContext(ctx context.Context, filter *Filter) (bool, error) {
+	if r, err := coll.node.propose(ctx, &command{
+		collection: coll.name,
+		op:         opDeleteOne,
+		filter:     filter,
+	}); err != nil {
+		return false, err
+	} else {
+		ok, _ := r.(bool)
+		return ok, nil
+	}
+}
+
+func (coll *ReplicatedCollection) DeleteMany(filter *Filter) (int, error) {
+	return coll.DeleteManyContext(context.Background(), filter)
+}
+
+func (coll *ReplicatedCollection) DeleteManyContext(ctx context.Context, filter *Filter) (int, error) {
+	if r, err := coll.node.propose(ctx, &command{
+		collection: coll.name,
+		op:         opDeleteMany,
+		filter:     filter,
+	}); err != nil {
+		return 0, err
+	} else {
+		count, _ := r.(int)
+		return count, nil
+	}
+}
+
+func (coll *ReplicatedCollection) FindOne(filter *Filter, opts ...*FindOptions) (map[string]any, error) {
+	return coll.FindOneContext(context.Background(), filter, opts...)
+}
+
+func (coll *ReplicatedCollection) FindOneContext(ctx context.Context, filter *Filter, opts ...*FindOptions) (map[string]any, error) {
+	local, err := coll.local()
+	if err != nil {
+		return nil, err
+	}
+	return local.FindOneContext(ctx, filter, opts...)
+}
+
+func (coll *ReplicatedCollection) FindMany(filter *Filter, opts ...*FindOptions) ([]map[string]any, error) {
+	return coll.FindManyContext(context.Background(), filter, opts...)
+}
+
+func (coll *ReplicatedCollection) FindManyContext(ctx context.Context, filter *Filter, opts ...*FindOptions) ([]map[string]any, error) {
+	local, err := coll.local()
+	if err != nil {
+		return nil, err
+	}
+	return local.FindManyContext(ctx, filter, opts...)
+}
+
+func (coll *ReplicatedCollection) local() (*Collection, error) {
+	if local, ok := coll.node.db.get(coll.name); ok {
+		return local, nil
+	}
+	return nil, errors.Wrap(ErrCollectionNotFound, coll.name)
+}
+
+func (coll *ReplicatedCollection) prepare(documents ...map[string]any) []map[string]any {
+	local, err := coll.local()
+	if err != nil {
+		return documents
+	}
+	for _, doc := range documents {
+		local.generate(doc)
+	}
+	return documents
+}
+
+func (coll *ReplicatedCollection) generate() any {
+	local, err := coll.local()
+	if err != nil || util.IsNil(local.generator) || len(local.pk) != 1 {
+		return nil
+	}
+	return local.generator.Generate()
+}
+
+func (iv *ReplicatedIndexView) List() []IndexModel {
+	local, err := iv.coll.local()
+	if err != nil {
+		return nil
+	}
+	return local.Indexes().List()
+}
+
+func (iv *ReplicatedIndexView) Stats() []IndexStats {
+	local, err := iv.coll.local()
+	if err != nil {
+		return nil
+	}
+	return local.Indexes().Stats()
+}
+
+func (iv *ReplicatedIndexView) Create(index IndexModel) error {
+	return iv.coll.CreateIndex(index)
+}
+
+func (iv *ReplicatedIndexView) CreateContext(ctx context.Context, index IndexModel) error {
+	return iv.coll.CreateIndexContext(ctx, index)
+}
+
+func (iv *ReplicatedIndexView) Drop(name string) {
+	_ = iv.coll.DropIndex(name)
+}
+
+func (iv *ReplicatedIndexView) DropContext(ctx context.Context, name string) error {
+	return iv.coll.DropIndexContext(ctx, name)
+}
+
+func (sm *stateMachine) Apply(data any) any {
+	cmd, ok := data.(*command)
+	if !ok {
+		return nil
+	}
+
+	switch cmd.op {
+	case opCreateCollection:
+		_, err := sm.db.CreateCollection(cmd.collection, cmd.settings)
+		return &commandResult{err: err}
+	case opDropCollection:
+		err := sm.db.DropCollection(cmd.collection)
+		return &commandResult{err: err}
+	case opRenameCollection:
+		err := sm.db.RenameCollection(cmd.collection, cmd.name)
+		return &commandResult{err: err}
+	}
+
+	ctx := withoutHooks(context.Background())
+	coll, err := sm.collection(cmd)
+	if err != nil {
+		return &commandResult{err: err}
+	}
+
+	switch cmd.op {
+	case opInsertOne:
+		id, err := coll.insertOneContext(ctx, util.Copy(cmd.documents[0]))
+		return &commandResult{value: id, err: err}
+	case opInsertMany:
+		ids, err := coll.insertManyContext(ctx, util.Copy(cmd.documents))
+		return &commandResult{value: ids, err: err}
+	case opUpdateOne:
+		filter, matched, err := sm.pin(ctx, coll, cmd.filter)
+		if err != nil {
+			return &commandResult{err: err}
+		}
+		ok, err := coll.updateOneContext(ctx, filter, sm.update(coll, cmd, matched), cmd.options)
+		return &commandResult{value: ok, err: err}
+	case opUpdateMany:
+		_, matched, err := sm.pin(ctx, coll, cmd.filter)
+		if err != nil {
+			return &commandResult{err: err}
+		}
+		count, err := coll.updateManyContext(ctx, cmd.filter, sm.update(coll, cmd, matched), cmd.options)
+		return &commandResult{value: count, err: err}
+	case opDeleteOne:
+		filter, _, err := sm.pin(ctx, coll, cmd.filter)
+		if err != nil {
+			return &commandResult{err: err}
+		}
+		ok, err := coll.deleteOneContext(ctx, filter)
+		return &commandResult{value: ok, err: err}
+	case opDeleteMany:
+		count, err := coll.deleteManyContext(ctx, cmd.filter)
+		return &commandResult{value: count, err: err}
+	case opCreateIndex:
+		err := coll.Indexes().Create(*cmd.index)
+		return &commandResult{err: err}
+	case opDropIndex:
+		coll.Indexes().Drop(cmd.name)
+		return &commandResult{}
+	}
+
+	return nil
+}
+
+func (sm *stateMachine) Snapshot() (any, error) {
+	colls := sm.db.list()
+
+	ctx := withQuery(context.Background(), nil)
+	snapshot := databaseSnapshot{}
+	for _, coll := range colls {
+		docs, err := coll.findMany(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		snapshot[coll.Name()] = collectionSnapshot{
+			options:   coll.options,
+			indexes:   append([]IndexModel(nil), coll.Indexes().List()...),
+			documents: util.Copy(docs),
+		}
+	}
+	return snapshot, nil
+}
+
+func (sm *stateMachine) Restore(data any) error {
+	snapshot, ok := data.(databaseSnapshot)
+	if !ok {
+		return nil
+	}
+
+	colls := map[string]*Collection{}
+	for name, s := range snapshot {
+		coll, err := sm.db.newCollection(name, s.options)
+		if err != nil {
+			return err
+		}
+		for _, index := range s.indexes {
+			if index.Name == indexID {
+				continue
+			}
+			if err := coll.Indexes().Create(index); err != nil {
+				return err
+			}
+		}
+
+		if _, _, err := coll.insertMany(util.Copy(s.documents)); err != nil {
+			return err
+		}
+
+		colls[name] = coll
+	}
+
+	sm.db.restore(colls)
+	return nil
+}
+
+func (sm *stateMachine) collection(cmd *command) (*Collection, error) {
+	create := false
+	switch cmd.op {
+	case opInsertOne, opInsertMany, opCreateIndex:
+		create = true
+	case opUpdateOne, opUpdateMany:
+		create = !util.IsNil(cmd.options) && util.UnPtr(cmd.options.Upsert)
+	}
+
+	if create {
+		coll := sm.db.Collection(cmd.collection)
+		if err := coll.failure(); err != nil {
+			return nil, err
+		}
+		return coll, nil
+	}
+	if coll, ok := sm.db.get(cmd.collection); ok {
+		return coll, nil
+	}
+	return nil, errors.Wrap(ErrCollectionNotFound, cmd.collection)
+}
+
+func (sm *stateMachine) pin(ctx context.Context, coll *Collection, filter *Filter) (*Filter, bool, error) {
+	doc, err := coll.findOneContext(ctx, filter, &FindOptions{Sorts: coll.pk.sorts()})
+	if err != nil {
+		return nil, false, err
+	}
+	if util.IsNil(doc) {
+		return filter, false, nil
+	}
+	return coll.pk.filter(doc), true, nil
+}
+
+func (sm *stateMachine) update(coll *Collection, cmd *command, matched bool) map[string]any {
+	update := util.Copy(cmd.update)
+	if matched || util.IsNil(cmd.generated) || len(coll.pk) != 1 {
+		return update
+	}
+	if update == nil {
+		update = map[string]any{}
+	}
+	if v, ok := update[coll.pk[0]]; !ok || util.IsNil(v) {
+		update[coll.pk[0]] = cmd.generated
+	}
+	return update
+}
+
+func mergeNodeOptions(options []*NodeOptions) *NodeOptions {
+	if len(options) == 0 {
+		return nil
+	}
+	opt := &NodeOptions{}
+	for _, curr := range options {
+		if util.IsNil(curr) {
+			continue
+		}
+		if !util.IsNil(curr.Peers) {
+			opt.Peers = curr.Peers
+		}
+		if !util.IsNil(curr.TickInterval) {
+			opt.TickInterval = curr.TickInterval
+		}
+		if !util.IsNil(curr.ElectionTick) {
+			opt.ElectionTick = curr.ElectionTick
+		}
+		if !util.IsNil(curr.HeartbeatTick) {
+			opt.HeartbeatTick = curr.HeartbeatTick
+		}
+		if !util.IsNil(curr.SnapshotThreshold) {
+			opt.SnapshotThreshold = curr.SnapshotThreshold
+		}
+		if !util.IsNil(curr.Timeout) {
+			opt.Timeout = curr.Timeout
+		}
+	}
+	return opt
+}
@@ -0,0 +1,391 @@
+package memdb
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-faker/faker/v4"
+	"github.com/siyul-park/memdb/internal/util"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func newTestNodes(t *testing.T, tr Transport, size int, opts ...*NodeOptions) []*Node {
+	var peers []string
+	for i := 0; i < size; i++ {
+		peers = append(peers, fmt.Sprintf("node-%d", i))
+	}
+
+	var nodes []*Node
+	for _, id := range peers {
+		nodes = append(nodes, NewNode(id, New(faker.Word()), tr, append([]*NodeOptions{{
+			Peers:        peers,
+			TickInterval: util.Ptr(time.Millisecond),
+			ElectionTick: util.Ptr(10),
+		}}, opts...)...))
+	}
+
+	t.Cleanup(func() {
+		for _, n := range nodes {
+			n.Close()
+		}
+	})
+
+	return nodes
+}
+
+func waitLeaderNode(t *testing.T, nodes []*Node) *Node {
+	var leader *Node
+	assert.Eventually(t, func() bool {
+		for _, n := range nodes {
+			if n.IsLeader() {
+				leader = n
+				return true
+			}
+		}
+		return false
+	}, time.Second, time.Millisecond)
+	return leader
+}
+
+func TestNode_Collection(t *testing.T) {
+	tr := NewMemoryTransport()
+	nodes := newTestNodes(t, tr, 3)
+
+	leader := waitLeaderNode(t, nodes)
+	coll := leader.Collection(faker.UUIDHyphenated())
+
+	doc := map[string]any{
+		"id":      faker.UUIDHyphenated(),
+		"version": 0,
+	}
+
+	id, err := coll.InsertOne(doc)
+	assert.NoError(t, err)
+	assert.Equal(t, doc["id"], id)
+
+	_, err = coll.InsertOne(doc)
+	assert.ErrorIs(t, err, ErrPKDuplicated)
+
+	ok, err := coll.UpdateOne(Where("id").EQ(doc["id"]), map[string]any{"version": 1})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	for _, n := range nodes {
+		n := n
+		assert.Eventually(t, func() bool {
+			r, _ := n.Collection(coll.Name()).FindOne(Where("id").EQ(doc["id"]))
+			return r != nil && r["version"] == 1
+		}, time.Second, time.Millisecond)
+	}
+
+	for _, n := range nodes {
+		if n != leader {
+			_, err := n.Collection(coll.Name()).InsertOne(map[string]any{"id": faker.UUIDHyphenated()})
+			assert.ErrorIs(t, err, ErrNotLeader)
+		}
+	}
+
+	count, err := coll.DeleteMany(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestNode_CreateCollection(t *testing.T) {
+	tr := NewMemoryTransport()
+	nodes := newTestNodes(t, tr, 3)
+
+	leader := waitLeaderNode(t, nodes)
+	name := faker.UUIDHyphenated()
+
+	coll, err := leader.CreateCollection(name, &CollectionOptions{
+		PrimaryKey: []string{"email"},
+		Shards:     util.Ptr(4),
+	})
+	assert.NoError(t, err)
+
+	_, err = leader.CreateCollection(name)
+	assert.ErrorIs(t, err, ErrCollectionExists)
+
+	err = coll.Indexes().Create(IndexModel{Keys: []string{"name"}, Name: "name"})
+	assert.NoError(t, err)
+
+	_, err = coll.InsertOne(map[string]any{"email": "a@example.com", "name": "a"})
+	assert.NoError(t, err)
+
+	for _, n := range nodes {
+		n := n
+		assert.Eventually(t, func() bool {
+			docs, _ := n.Collection(name).FindMany(Where("name").EQ("a"))
+			return len(docs) == 1 && len(n.Collection(name).Indexes().List()) == 2
+		}, time.Second, time.Millisecond)
+
+		local, ok := n.Database().get(name)
+		assert.True(t, ok)
+		assert.Equal(t, primaryKey{"email"}, local.pk)
+		assert.Len(t, local.shards, 4)
+	}
+
+	missing := faker.UUIDHyphenated()
+	for _, n := range nodes {
+		_, err := n.Collection(missing).FindMany(nil)
+		assert.ErrorIs(t, err, ErrCollectionNotFound)
+		assert.Nil(t, n.Collection(missing).Indexes().List())
+		assert.False(t, n.Database().HasCollection(missing))
+	}
+
+	_, err = leader.Collection(missing).DeleteMany(nil)
+	assert.ErrorIs(t, err, ErrCollectionNotFound)
+	assert.False(t, leader.Database().HasCollection(missing))
+}
+
+func TestNode_Failover(t *testing.T) {
+	tr := NewMemoryTransport()
+	nodes := newTestNodes(t, tr, 3)
+
+	leader := waitLeaderNode(t, nodes)
+	name := faker.UUIDHyphenated()
+
+	_, err := leader.Collection(name).InsertOne(map[string]any{"id": faker.UUIDHyphenated()})
+	assert.NoError(t, err)
+
+	tr.Disconnect(leader.ID())
+	leader.Close()
+
+	var remains []*Node
+	for _, n := range nodes {
+		if n != leader {
+			remains = append(remains, n)
+		}
+	}
+
+	next := waitLeaderNode(t, remains)
+
+	_, err = next.Collection(name).InsertOne(map[string]any{"id": faker.UUIDHyphenated()})
+	assert.NoError(t, err)
+
+	for _, n := range remains {
+		n := n
+		assert.Eventually(t, func() bool {
+			docs, _ := n.Collection(name).FindMany(nil)
+			return len(docs) == 2
+		}, time.Second, time.Millisecond)
+	}
+}
+
+func TestNode_AddMember(t *testing.T) {
+	tr := NewMemoryTransport()
+	nodes := newTestNodes(t, tr, 3, &NodeOptions{SnapshotThreshold: util.Ptr(4)})
+
+	leader := waitLeaderNode(t, nodes)
+	name := faker.UUIDHyphenated()
+
+	err := leader.Collection(name).CreateIndex(IndexModel{
+		Keys:   []string{"name"},
+		Name:   "name",
+		Unique: true,
+	})
+	assert.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		_, err := leader.Collection(name).InsertOne(map[string]any{
+			"id":   faker.UUIDHyphenated(),
+			"name": faker.UUIDHyphenated(),
+		})
+		assert.NoError(t, err)
+	}
+
+	joiner := NewNode("node-3", New(faker.Word()), tr, &NodeOptions{
+		TickInterval: util.Ptr(time.Millisecond),
+		ElectionTick: util.Ptr(10),
+	})
+	defer joiner.Close()
+
+	err = leader.AddMember(joiner.ID())
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		docs, _ := joiner.Collection(name).FindMany(nil)
+		return len(docs) == 10 && len(joiner.Members()) == 4
+	}, time.Second, time.Millisecond)
+	assert.Len(t, joiner.Collection(name).Indexes().List(), 2)
+
+	err = leader.RemoveMember(joiner.ID())
+	assert.NoError(t, err)
+	assert.Len(t, leader.Members(), 3)
+}
+
+func TestNode_IDGenerator(t *testing.T) {
+	tr := NewMemoryTransport()
+	nodes := newTestNodes(t, tr, 3)
+
+	name := faker.UUIDHyphenated()
+	for _, n := range nodes {
+		_, err := n.Database().CreateCollection(name, &CollectionOptions{IDGenerator: NewUUIDv4Generator()})
+		assert.NoError(t, err)
+	}
+
+	leader := waitLeaderNode(t, nodes)
+	coll := leader.Collection(name)
+
+	id, err := coll.InsertOne(map[string]any{"name": faker.Name()})
+	assert.NoError(t, err)
+	assert.NotNil(t, id)
+
+	ok, err := coll.UpdateOne(Where("name").EQ("upsert"), map[string]any{"name": "upsert"}, &UpdateOptions{Upsert: util.Ptr(true)})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	doc, err := coll.FindOne(Where("name").EQ("upsert"))
+	assert.NoError(t, err)
+	assert.NotNil(t, doc)
+
+	for _, n := range nodes {
+		n := n
+		assert.Eventually(t, func() bool {
+			r1, _ := n.Collection(name).FindOne(Where("id").EQ(id))
+			r2, _ := n.Collection(name).FindOne(Where("id").EQ(doc["id"]))
+			return r1 != nil && r2 != nil
+		}, time.Second, time.Millisecond)
+	}
+}
+
+func TestNode_RenameCollection(t *testing.T) {
+	tr := NewMemoryTransport()
+	nodes := newTestNodes(t, tr, 3)
+
+	leader := waitLeaderNode(t, nodes)
+	from := faker.UUIDHyphenated()
+	to := faker.UUIDHyphenated()
+
+	_, err := leader.Collection(from).InsertOne(map[string]any{"id": faker.UUIDHyphenated()})
+	assert.NoError(t, err)
+
+	err = leader.RenameCollection(from, to)
+	assert.NoError(t, err)
+
+	err = leader.RenameCollection(from, to)
+	assert.ErrorIs(t, err, ErrCollectionNotFound)
+
+	for _, n := range nodes {
+		n := n
+		assert.Eventually(t, func() bool {
+			return !n.Database().HasCollection(from) && n.Database().HasCollection(to)
+		}, time.Second, time.Millisecond)
+	}
+
+	err = leader.DropCollection(to)
+	assert.NoError(t, err)
+
+	for _, n := range nodes {
+		n := n
+		assert.Eventually(t, func() bool {
+			return !n.Database().HasCollection(to)
+		}, time.Second, time.Millisecond)
+	}
+}
+
+func TestStateMachine_Snapshot(t *testing.T) {
+	src := New(faker.Word())
+	src.SetProfile(&ProfileOptions{Threshold: util.Ptr(time.Duration(0))})
+
+	users, err := src.CreateCollection("users", &CollectionOptions{PrimaryKey: []string{"email"}})
+	assert.NoError(t, err)
+	err = users.Indexes().Create(IndexModel{Keys: []string{"name"}, Name: "name"})
+	assert.NoError(t, err)
+	_, err = users.InsertMany([]map[string]any{
+		{"email": "a@example.com", "name": "a"},
+		{"email": "b@example.com", "name": "b"},
+	})
+	assert.NoError(t, err)
+
+	snapshot, err := (&stateMachine{db: src}).Snapshot()
+	assert.NoError(t, err)
+
+	dst := New(faker.Word())
+	_, err = dst.Collection("stale").InsertOne(map[string]any{"id": 1})
+	assert.NoError(t, err)
+
+	prev := dst.Collection("users")
+	hooks := 0
+	prev.BeforeInsert(func(_ context.Context, _ map[string]any) error {
+		hooks++
+		return nil
+	})
+	events := 0
+	prev.Watch(func(_ Event, _ any) {
+		events++
+	})
+
+	err = (&stateMachine{db: dst}).Restore(snapshot)
+	assert.NoError(t, err)
+
+	assert.False(t, dst.HasCollection("stale"))
+	assert.Zero(t, hooks)
+	assert.Zero(t, events)
+
+	next := dst.Collection("users")
+	assert.Equal(t, primaryKey{"email"}, next.pk)
+	assert.Len(t, next.Indexes().List(), 2)
+
+	docs, err := next.FindMany(nil, &FindOptions{Sorts: []Sort{{Key: "email", Order: OrderASC}}})
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]any{
+		{"email": "a@example.com", "name": "a"},
+		{"email": "b@example.com", "name": "b"},
+	}, docs)
+
+	_, err = next.InsertOne(map[string]any{"email": "c@example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, hooks)
+	assert.Equal(t, 1, events)
+}
+
+func TestStateMachine_Snapshot_Encryption(t *testing.T) {
+	opts := &CollectionOptions{
+		Encryption: &EncryptionOptions{
+			KeyProvider: NewStaticKeyProvider([]byte(faker.Password())),
+			Fields:      []EncryptedField{{Path: "email", Deterministic: true}},
+		},
+	}
+
+	src := New(faker.Word())
+	users, err := src.CreateCollection("users", opts)
+	assert.NoError(t, err)
+	_, err = users.InsertOne(map[string]any{"id": 1, "email": "a@example.com"})
+	assert.NoError(t, err)
+
+	snapshot, err := (&stateMachine{db: src}).Snapshot()
+	assert.NoError(t, err)
+
+	docs := snapshot.(databaseSnapshot)["users"].documents
+	assert.Len(t, docs, 1)
+	assert.IsType(t, ciphertext{}, docs[0]["email"])
+
+	dst := New(faker.Word())
+	err = (&stateMachine{db: dst}).Restore(snapshot)
+	assert.NoError(t, err)
+
+	stored, err := dst.Collection("users").findOne(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, docs[0], stored)
+
+	doc, err := dst.Collection("users").FindOne(Where("email").EQ("a@example.com"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"id": 1, "email": "a@example.com"}, doc)
+}
+
+func TestStateMachine_Restore(t *testing.T) {
+	db := New(faker.Word())
+
+	err := (&stateMachine{db: db}).Restore(databaseSnapshot{
+		"users": {
+			indexes: []IndexModel{{Keys: []string{"name"}, Name: "name", Unique: true}},
+			documents: []map[string]any{
+				{"id": 1, "name": "a"},
+				{"id": 2, "name": "a"},
+			},
+		},
+	})
+	assert.ErrorIs(t, err, ErrIndexConflict)
+}
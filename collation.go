@@ -0,0 +1,147 @@
+package memdb
+
+import (
+	"github.com/siyul-park/memdb/internal/util/reflectutil"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+	"strings"
+	"unicode"
+)
+
+type (
+	Collation struct {
+		CaseInsensitive   bool
+		AccentInsensitive bool
+		NumericOrdering   bool
+	}
+)
+
+func (c *Collation) equal(x, y any) bool {
+	return reflectutil.Equal(c.normalize(x), c.normalize(y))
+}
+
+func (c *Collation) compare(x, y any) int {
+	if c != nil && c.NumericOrdering {
+		if a, ok := x.(string); ok {
+			if b, ok := y.(string); ok {
+				return compareNumeric(c.fold(a), c.fold(b))
+			}
+		}
+	}
+	return reflectutil.Compare(c.normalize(x), c.normalize(y))
+}
+
+func (c *Collation) covers(other *Collation) bool {
+	if other == nil {
+		return true
+	}
+	if c == nil {
+		return !other.CaseInsensitive && !other.AccentInsensitive && !other.NumericOrdering
+	}
+	return (c.CaseInsensitive || !other.CaseInsensitive) &&
+		(c.AccentInsensitive || !other.AccentInsensitive) &&
+		(c.NumericOrdering || !other.NumericOrdering)
+}
+
+func (c *Collation) normalize(value any) any {
+	if c == nil || (!c.CaseInsensitive && !c.AccentInsensitive && !c.NumericOrdering) {
+		return value
+	}
+
+	switch v := value.(type) {
+	case string:
+		return c.fold(v)
+	case []any:
+		next := make([]any, len(v))
+		for i, e := range v {
+			next[i] = c.normalize(e)
+		}
+		return next
+	case map[string]any:
+		next := make(map[string]any, len(v))
+		for k, e := range v {
+			next[k] = c.normalize(e)
+		}
+		return next
+	}
+	return value
+}
+
+func (c *Collation) fold(s string) string {
+	if c.AccentInsensitive {
+		s, _, _ = transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), s)
+	}
+	if c.CaseInsensitive {
+		s = cases.Fold().String(s)
+	}
+	if c.NumericOrdering {
+		s = trimZeros(s)
+	}
+	return s
+}
+
+func trimZeros(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); {
+		if !isDigit(s[i]) {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(s) && isDigit(s[j]) {
+			j++
+		}
+		k := i
+		for k < j-1 && s[k] == '0' {
+			k++
+		}
+		b.WriteString(s[k:j])
+		i = j
+	}
+	return b.String()
+}
+
+func compareNumeric(x, y string) int {
+	for len(x) > 0 && len(y) > 0 {
+		if isDigit(x[0]) && isDigit(y[0]) {
+			i, j := 0, 0
+			for i < len(x) && isDigit(x[i]) {
+				i++
+			}
+			for j < len(y) && isDigit(y[j]) {
+				j++
+			}
+			if i != j {
+				return compareInt(i, j)
+			}
+			if c := strings.Compare(x[:i], y[:j]); c != 0 {
+				return c
+			}
+			x, y = x[i:], y[j:]
+			continue
+		}
+		if x[0] != y[0] {
+			return compareInt(int(x[0]), int(y[0]))
+		}
+		x, y = x[1:], y[1:]
+	}
+	return compareInt(len(x), len(y))
+}
+
+func compareInt(x, y int) int {
+	if x < y {
+		return -1
+	}
+	if x > y {
+		return 1
+	}
+	return 0
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
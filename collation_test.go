@@ -0,0 +1,173 @@
+package memdb
+
+import (
+	"github.com/go-faker/faker/v4"
+	"github.com/siyul-park/memdb/internal/util"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCollation_Compare(t *testing.T) {
+	var testCases = []struct {
+		collation *Collation
+		x         any
+		y         any
+		expect    int
+	}{
+		{
+			collation: nil,
+			x:         "a",
+			y:         "B",
+			expect:    1,
+		},
+		{
+			collation: &Collation{CaseInsensitive: true},
+			x:         "a",
+			y:         "B",
+			expect:    -1,
+		},
+		{
+			collation: &Collation{CaseInsensitive: true},
+			x:         "Straße",
+			y:         "STRASSE",
+			expect:    0,
+		},
+		{
+			collation: &Collation{AccentInsensitive: true},
+			x:         "café",
+			y:         "cafe",
+			expect:    0,
+		},
+		{
+			collation: nil,
+			x:         "item10",
+			y:         "item9",
+			expect:    -1,
+		},
+		{
+			collation: &Collation{NumericOrdering: true},
+			x:         "item10",
+			y:         "item9",
+			expect:    1,
+		},
+		{
+			collation: &Collation{NumericOrdering: true},
+			x:         "item007",
+			y:         "item7",
+			expect:    0,
+		},
+		{
+			collation: &Collation{CaseInsensitive: true},
+			x:         1,
+			y:         2,
+			expect:    -1,
+		},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expect, tc.collation.compare(tc.x, tc.y), "%v %v", tc.x, tc.y)
+	}
+}
+
+func TestCollation_Equal(t *testing.T) {
+	c := &Collation{CaseInsensitive: true, AccentInsensitive: true}
+
+	assert.True(t, c.equal("Émile", "emile"))
+	assert.True(t, c.equal([]any{"A", "b"}, []any{"a", "B"}))
+	assert.False(t, c.equal("a", "b"))
+
+	var binary *Collation
+	assert.False(t, binary.equal("A", "a"))
+}
+
+func TestCollation_Covers(t *testing.T) {
+	var binary *Collation
+	c := &Collation{CaseInsensitive: true}
+
+	assert.True(t, binary.covers(nil))
+	assert.False(t, binary.covers(c))
+	assert.True(t, c.covers(nil))
+	assert.True(t, c.covers(&Collation{CaseInsensitive: true}))
+	assert.False(t, c.covers(&Collation{AccentInsensitive: true}))
+}
+
+func TestCollection_Collation(t *testing.T) {
+	coll := newCollection(faker.Name())
+
+	err := coll.Indexes().Create(IndexModel{
+		Name:      "email",
+		Keys:      []string{"email"},
+		Unique:    true,
+		Collation: &Collation{CaseInsensitive: true},
+	})
+	assert.NoError(t, err)
+
+	_, err = coll.InsertMany([]map[string]any{
+		{"id": 1, "email": "Alice@example.com", "name": "émile"},
+		{"id": 2, "email": "bob@example.com", "name": "Bob"},
+		{"id": 3, "email": "carol@example.com", "name": "alice"},
+	})
+	assert.NoError(t, err)
+
+	_, err = coll.InsertOne(map[string]any{"id": 4, "email": "ALICE@example.com"})
+	assert.ErrorIs(t, err, ErrIndexConflict)
+
+	docs, err := coll.FindMany(Where("email").EQ("alice@example.com"))
+	assert.NoError(t, err)
+	assert.Len(t, docs, 0)
+
+	docs, err = coll.FindMany(Where("email").EQ("alice@example.com"), &FindOptions{
+		Collation: &Collation{CaseInsensitive: true},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []any{1}, ids(docs))
+
+	docs, err = coll.FindMany(Where("name").EQ("EMILE"), &FindOptions{
+		Collation: &Collation{CaseInsensitive: true, AccentInsensitive: true},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []any{1}, ids(docs))
+
+	docs, err = coll.FindMany(nil, &FindOptions{
+		Sorts:     []Sort{{Key: "name", Order: OrderASC}},
+		Collation: &Collation{CaseInsensitive: true, AccentInsensitive: true},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []any{3, 2, 1}, ids(docs))
+
+	docs, err = coll.FindMany(nil, &FindOptions{
+		Sorts: []Sort{{Key: "name", Order: OrderASC, Collation: &Collation{CaseInsensitive: true}}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []any{3, 2, 1}, ids(docs))
+}
+
+func TestCollection_NumericOrdering(t *testing.T) {
+	coll := newCollection(faker.Name())
+
+	_, err := coll.InsertMany([]map[string]any{
+		{"id": 1, "version": "v10"},
+		{"id": 2, "version": "v2"},
+		{"id": 3, "version": "v1"},
+	})
+	assert.NoError(t, err)
+
+	docs, err := coll.FindMany(Where("version").GT("v9"), &FindOptions{
+		Sorts:     []Sort{{Key: "version", Order: OrderASC}},
+		Collation: &Collation{NumericOrdering: true},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []any{1}, ids(docs))
+
+	docs, err = coll.FindMany(nil, &FindOptions{
+		Sorts:     []Sort{{Key: "version", Order: OrderDESC}},
+		Collation: util.Ptr(Collation{NumericOrdering: true}),
+	})
+	assert.NoError(t, err)
+
+	versions := make([]string, len(docs))
+	for i, doc := range docs {
+		versions[i] = doc["version"].(string)
+	}
+	assert.Equal(t, []string{"v10", "v2", "v1"}, versions)
+}
@@ -1,21 +1,77 @@
 package memdb
 
 import (
+	"context"
+	"fmt"
 	"github.com/pkg/errors"
 	"github.com/siyul-park/memdb/internal/pool"
 	"github.com/siyul-park/memdb/internal/util"
+	"hash/fnv"
 	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type (
 	Collection struct {
-		name          string
-		data          *sync.Map
-		indexView     *IndexView
-		listeners     map[int]func(Event, any)
-		dataLock      sync.RWMutex
-		listenersLock sync.RWMutex
+		name            string
+		shards          []*shard
+		db              *Database
+		indexView       *IndexView
+		validator       *Schema
+		validationLevel ValidationLevel
+		pk              primaryKey
+		options         *CollectionOptions
+		generator       IDGenerator
+		encryptor       *encryptor
+		capped          *capped
+		memory          *memory
+		budget          *budget
+		metrics         Metrics
+		profiler        *profiler
+		chain           *chain
+		hooks           *hooks
+		listeners       map[int]func(Event, any)
+		listenersLock   sync.RWMutex
+		nameLock        sync.RWMutex
+		err             error
+		errLock         sync.RWMutex
+	}
+
+	CollectionOptions struct {
+		Shards          *int
+		Validator       *Schema
+		ValidationLevel *ValidationLevel
+		PrimaryKey      []string
+		IDGenerator     IDGenerator
+		MaxDocuments    *int
+		MaxBytes        *int
+		MaxMemory       *int
+		EvictionPolicy  *EvictionPolicy
+		TTLKey          *string
+		Metrics         Metrics
+		Encryption      *EncryptionOptions
+	}
+
+	CollectionStats struct {
+		Name    string
+		Count   int
+		Size    int
+		Fields  map[string]int
+		Indexes []IndexStats
+	}
+
+	scanStat struct {
+		indexed bool
+		scanned int
+		wait    time.Duration
+	}
+
+	shard struct {
+		data      *sync.Map
+		indexView *IndexView
+		lock      sync.RWMutex
 	}
 
 	UpdateOptions struct {
@@ -23,14 +79,20 @@ type (
 	}
 
 	FindOptions struct {
-		Limit *int
-		Skip  *int
-		Sorts []Sort
+		Limit     *int
+		Skip      *int
+		Sorts     []Sort
+		Collation *Collation
+		Lookups   []Lookup
 	}
 
 	Event int
 )
 
+const (
+	checkInterval = 256
+)
+
 const (
 	EventInsert Event = iota
 	EventUpdate
@@ -45,31 +107,150 @@ var (
 	ErrPKDuplicated = errors.New(ErrCodePKDuplicated)
 )
 
-func newCollection(name string) *Collection {
-	return &Collection{
+func newCollection(name string, opts ...*CollectionOptions) *Collection {
+	opt := mergeCollectionOptions(opts)
+
+	size := 1
+	if !util.IsNil(opt) && !util.IsNil(opt.Shards) && util.UnPtr(opt.Shards) > 1 {
+		size = util.UnPtr(opt.Shards)
+	}
+	pk := primaryKey{keyID}
+	if !util.IsNil(opt) && len(opt.PrimaryKey) > 0 {
+		pk = opt.PrimaryKey
+	}
+
+	var indexView *IndexView
+	var shards []*shard
+	if size == 1 {
+		indexView = newShardedIndexView(0, pk)
+		shards = append(shards, newShard(indexView))
+	} else {
+		indexView = newShardedIndexView(size, pk)
+		for _, iv := range indexView.shards {
+			shards = append(shards, newShard(iv))
+		}
+	}
+
+	coll := &Collection{
 		name:          name,
-		data:          pool.GetMap(),
-		indexView:     newIndexView(),
+		shards:        shards,
+		indexView:     indexView,
+		pk:            pk,
+		options:       opt,
+		hooks:         &hooks{},
 		listeners:     map[int]func(Event, any){},
-		dataLock:      sync.RWMutex{},
 		listenersLock: sync.RWMutex{},
+		err:           opt.compile(),
+	}
+	if !util.IsNil(opt) {
+		coll.validator = opt.Validator
+		coll.validationLevel = util.UnPtr(opt.ValidationLevel)
+		coll.generator = opt.IDGenerator
+		coll.metrics = opt.Metrics
+		coll.encryptor = newEncryptor(opt.Encryption)
+
+		maxDocuments := util.UnPtr(opt.MaxDocuments)
+		maxBytes := util.UnPtr(opt.MaxBytes)
+		if maxDocuments > 0 || maxBytes > 0 {
+			coll.capped = newCapped(maxDocuments, maxBytes)
+		}
+		if maxMemory := util.UnPtr(opt.MaxMemory); maxMemory > 0 {
+			coll.memory = newMemory(maxMemory, util.UnPtr(opt.EvictionPolicy), util.UnPtr(opt.TTLKey))
+		}
+	}
+
+	return coll
+}
+
+func newShard(indexView *IndexView) *shard {
+	s := &shard{
+		data:      pool.GetMap(),
+		indexView: indexView,
+		lock:      sync.RWMutex{},
 	}
+	indexView.source = s
+	return s
 }
 
 func (coll *Collection) Name() string {
-	coll.dataLock.RLock()
-	defer coll.dataLock.RUnlock()
+	coll.nameLock.RLock()
+	defer coll.nameLock.RUnlock()
 
 	return coll.name
 }
 
 func (coll *Collection) Indexes() *IndexView {
-	coll.dataLock.RLock()
-	defer coll.dataLock.RUnlock()
-
 	return coll.indexView
 }
 
+func (coll *Collection) Stats() (CollectionStats, error) {
+	return coll.StatsContext(context.Background())
+}
+
+func (coll *Collection) StatsContext(ctx context.Context) (CollectionStats, error) {
+	stats := CollectionStats{
+		Name:   coll.Name(),
+		Fields: map[string]int{},
+	}
+
+	for _, s := range coll.shards {
+		if err := func() error {
+			s.lock.RLock()
+			defer s.lock.RUnlock()
+
+			var err error
+			i := 0
+			s.data.Range(func(_, value any) bool {
+				if i%checkInterval == 0 {
+					if err = ctx.Err(); err != nil {
+						return false
+					}
+				}
+				i++
+
+				doc := value.(map[string]any)
+				stats.Count++
+				stats.Size += sizeOf(doc)
+				countFields(doc, "", stats.Fields)
+				return true
+			})
+			return err
+		}(); err != nil {
+			return CollectionStats{}, err
+		}
+	}
+
+	stats.Indexes = coll.indexView.Stats()
+	return stats, nil
+}
+
+func (coll *Collection) Memory() MemoryStats {
+	if coll.memory == nil {
+		return MemoryStats{}
+	}
+	return coll.memory.stats()
+}
+
+func (coll *Collection) BeforeInsert(hook BeforeInsertHook) int {
+	return coll.hooks.add(hook)
+}
+
+func (coll *Collection) BeforeUpdate(hook BeforeUpdateHook) int {
+	return coll.hooks.add(hook)
+}
+
+func (coll *Collection) BeforeDelete(hook BeforeDeleteHook) int {
+	return coll.hooks.add(hook)
+}
+
+func (coll *Collection) AfterCommit(hook AfterCommitHook) int {
+	return coll.hooks.add(hook)
+}
+
+func (coll *Collection) RemoveHook(hookID int) {
+	coll.hooks.remove(hookID)
+}
+
 func (coll *Collection) Watch(listener func(event Event, val any)) int {
 	coll.listenersLock.Lock()
 	defer coll.listenersLock.Unlock()
@@ -83,6 +264,7 @@ func (coll *Collection) Watch(listener func(event Event, val any)) int {
 		}
 	}
 	coll.listeners[id] = listener
+	coll.observeListeners()
 
 	return id
 }
@@ -92,251 +274,592 @@ func (coll *Collection) Unwatch(listenerID int) {
 	defer coll.listenersLock.Unlock()
 
 	delete(coll.listeners, listenerID)
+	coll.observeListeners()
 }
 
 func (coll *Collection) InsertOne(document map[string]any) (any, error) {
-	if id, err := coll.insertOne(document); err != nil {
+	return coll.InsertOneContext(context.Background(), document)
+}
+
+func (coll *Collection) InsertOneContext(ctx context.Context, document map[string]any) (any, error) {
+	return coll.intercept(ctx, &Operation{
+		Type:      OperationInsertOne,
+		Documents: []map[string]any{document},
+	}, func(ctx context.Context, op *Operation) (any, error) {
+		if len(op.Documents) == 0 {
+			return nil, ErrPKNotFound
+		}
+		return coll.insertOneContext(ctx, op.Documents[0])
+	})
+}
+
+func (coll *Collection) InsertMany(documents []map[string]any) ([]any, error) {
+	return coll.InsertManyContext(context.Background(), documents)
+}
+
+func (coll *Collection) InsertManyContext(ctx context.Context, documents []map[string]any) ([]any, error) {
+	r, err := coll.intercept(ctx, &Operation{
+		Type:      OperationInsertMany,
+		Documents: documents,
+	}, func(ctx context.Context, op *Operation) (any, error) {
+		return coll.insertManyContext(ctx, op.Documents)
+	})
+	ids, _ := r.([]any)
+	return ids, err
+}
+
+func (coll *Collection) UpdateOne(filter *Filter, update map[string]any, opts ...*UpdateOptions) (bool, error) {
+	return coll.UpdateOneContext(context.Background(), filter, update, opts...)
+}
+
+func (coll *Collection) UpdateOneContext(ctx context.Context, filter *Filter, update map[string]any, opts ...*UpdateOptions) (bool, error) {
+	r, err := coll.intercept(ctx, &Operation{
+		Type:    OperationUpdateOne,
+		Filter:  filter,
+		Update:  update,
+		Options: mergeUpdateOptions(opts),
+	}, func(ctx context.Context, op *Operation) (any, error) {
+		opt, _ := op.Options.(*UpdateOptions)
+		return coll.updateOneContext(ctx, op.Filter, op.Update, opt)
+	})
+	ok, _ := r.(bool)
+	return ok, err
+}
+
+func (coll *Collection) UpdateMany(filter *Filter, update map[string]any, opts ...*UpdateOptions) (int, error) {
+	return coll.UpdateManyContext(context.Background(), filter, update, opts...)
+}
+
+func (coll *Collection) UpdateManyContext(ctx context.Context, filter *Filter, update map[string]any, opts ...*UpdateOptions) (int, error) {
+	r, err := coll.intercept(ctx, &Operation{
+		Type:    OperationUpdateMany,
+		Filter:  filter,
+		Update:  update,
+		Options: mergeUpdateOptions(opts),
+	}, func(ctx context.Context, op *Operation) (any, error) {
+		opt, _ := op.Options.(*UpdateOptions)
+		return coll.updateManyContext(ctx, op.Filter, op.Update, opt)
+	})
+	count, _ := r.(int)
+	return count, err
+}
+
+func (coll *Collection) DeleteOne(filter *Filter) (bool, error) {
+	return coll.DeleteOneContext(context.Background(), filter)
+}
+
+func (coll *Collection) DeleteOneContext(ctx context.Context, filter *Filter) (bool, error) {
+	r, err := coll.intercept(ctx, &Operation{
+		Type:   OperationDeleteOne,
+		Filter: filter,
+	}, func(ctx context.Context, op *Operation) (any, error) {
+		return coll.deleteOneContext(ctx, op.Filter)
+	})
+	ok, _ := r.(bool)
+	return ok, err
+}
+
+func (coll *Collection) DeleteMany(filter *Filter) (int, error) {
+	return coll.DeleteManyContext(context.Background(), filter)
+}
+
+func (coll *Collection) DeleteManyContext(ctx context.Context, filter *Filter) (int, error) {
+	r, err := coll.intercept(ctx, &Operation{
+		Type:   OperationDeleteMany,
+		Filter: filter,
+	}, func(ctx context.Context, op *Operation) (any, error) {
+		return coll.deleteManyContext(ctx, op.Filter)
+	})
+	count, _ := r.(int)
+	return count, err
+}
+
+func (coll *Collection) FindOne(filter *Filter, opts ...*FindOptions) (map[string]any, error) {
+	return coll.FindOneContext(context.Background(), filter, opts...)
+}
+
+func (coll *Collection) FindOneContext(ctx context.Context, filter *Filter, opts ...*FindOptions) (map[string]any, error) {
+	r, err := coll.intercept(ctx, &Operation{
+		Type:    OperationFindOne,
+		Filter:  filter,
+		Options: mergeFindOptions(opts),
+	}, func(ctx context.Context, op *Operation) (any, error) {
+		opt, _ := op.Options.(*FindOptions)
+		return coll.findOneContext(ctx, op.Filter, opt)
+	})
+	doc, _ := r.(map[string]any)
+	return doc, err
+}
+
+func (coll *Collection) FindMany(filter *Filter, opts ...*FindOptions) ([]map[string]any, error) {
+	return coll.FindManyContext(context.Background(), filter, opts...)
+}
+
+func (coll *Collection) FindManyContext(ctx context.Context, filter *Filter, opts ...*FindOptions) ([]map[string]any, error) {
+	r, err := coll.intercept(ctx, &Operation{
+		Type:    OperationFindMany,
+		Filter:  filter,
+		Options: mergeFindOptions(opts),
+	}, func(ctx context.Context, op *Operation) (any, error) {
+		opt, _ := op.Options.(*FindOptions)
+		return coll.findManyContext(ctx, op.Filter, opt)
+	})
+	docs, _ := r.([]map[string]any)
+	return docs, err
+}
+
+func (coll *Collection) Drop() {
+	var data []*sync.Map
+	for _, s := range coll.shards {
+		func() {
+			s.lock.Lock()
+			defer s.lock.Unlock()
+
+			data = append(data, s.data)
+			s.data = pool.GetMap()
+		}()
+	}
+	coll.indexView.deleteAll()
+	if coll.capped != nil {
+		coll.capped.reset()
+	}
+	if coll.memory != nil {
+		coll.memory.reset()
+	}
+
+	for _, d := range data {
+		d.Range(func(_, value any) bool {
+			coll.emit(EventDelete, coll.pk.value(value.(map[string]any)))
+			return true
+		})
+	}
+
+	coll.listenersLock.Lock()
+	defer coll.listenersLock.Unlock()
+
+	coll.listeners = map[int]func(Event, any){}
+	coll.observeListeners()
+}
+
+func (coll *Collection) inherit(prev *Collection) {
+	coll.hooks = prev.hooks
+
+	prev.listenersLock.Lock()
+	defer prev.listenersLock.Unlock()
+	coll.listenersLock.Lock()
+	defer coll.listenersLock.Unlock()
+
+	for id, listener := range prev.listeners {
+		coll.listeners[id] = listener
+	}
+	prev.listeners = map[int]func(Event, any){}
+	coll.observeListeners()
+}
+
+func (coll *Collection) fail(err error) {
+	coll.errLock.Lock()
+	defer coll.errLock.Unlock()
+
+	coll.err = err
+}
+
+func (coll *Collection) failure() error {
+	coll.errLock.RLock()
+	defer coll.errLock.RUnlock()
+
+	return coll.err
+}
+
+func (coll *Collection) rename(name string) {
+	coll.nameLock.Lock()
+	defer coll.nameLock.Unlock()
+
+	coll.name = name
+}
+
+func (coll *Collection) insertOneContext(ctx context.Context, document map[string]any) (id any, err error) {
+	ctx, q := coll.begin(ctx, OperationInsertOne, nil, nil)
+	defer coll.end(q, &err)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	coll.generate(document)
+	if err := coll.hooks.beforeInsert(ctx, document); err != nil {
+		return nil, err
+	}
+	if err := coll.check(ctx, document); err != nil {
+		return nil, err
+	}
+	if err := coll.validate(document, nil); err != nil {
+		return nil, err
+	}
+
+	stored, err := coll.encrypt(document)
+	if err != nil {
+		return nil, err
+	}
+
+	if id, evicted, err := coll.insertOne(stored[0]); err != nil {
 		return nil, err
 	} else {
+		coll.hooks.afterCommit(ctx, EventInsert, document)
 		coll.emit(EventInsert, document)
+		coll.expire(evicted...)
 		return id, nil
 	}
 }
 
-func (coll *Collection) InsertMany(documents []map[string]any) ([]any, error) {
-	if ids, err := coll.insertMany(documents); err != nil {
+func (coll *Collection) insertManyContext(ctx context.Context, documents []map[string]any) (ids []any, err error) {
+	ctx, q := coll.begin(ctx, OperationInsertMany, nil, nil)
+	defer coll.end(q, &err)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, doc := range documents {
+		coll.generate(doc)
+	}
+	if err := coll.hooks.beforeInsert(ctx, documents...); err != nil {
+		return nil, err
+	}
+	if err := coll.check(ctx, documents...); err != nil {
+		return nil, err
+	}
+	for _, doc := range documents {
+		if err := coll.validate(doc, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	stored, err := coll.encrypt(documents...)
+	if err != nil {
+		return nil, err
+	}
+
+	if ids, evicted, err := coll.insertMany(stored); err != nil {
 		return nil, err
 	} else {
+		coll.hooks.afterCommit(ctx, EventInsert, documents...)
 		for _, doc := range documents {
 			coll.emit(EventInsert, doc)
 		}
+		coll.expire(evicted...)
 		return ids, nil
 	}
 }
 
-func (coll *Collection) UpdateOne(filter *Filter, update map[string]any, opts ...*UpdateOptions) (bool, error) {
+func (coll *Collection) updateOneContext(ctx context.Context, filter *Filter, update map[string]any, opts ...*UpdateOptions) (ok bool, err error) {
+	ctx, q := coll.begin(ctx, OperationUpdateOne, filter, mergeUpdateOptions(opts))
+	defer coll.end(q, &err)
+
 	opt := mergeUpdateOptions(opts)
 	upsert := false
 	if !util.IsNil(opt) && !util.IsNil(opt.Upsert) {
 		upsert = util.UnPtr(opt.Upsert)
 	}
 
-	doc, err := coll.findOne(filter)
+	doc, err := coll.findOne(ctx, filter)
 	if err != nil {
 		return false, err
 	}
 	if util.IsNil(doc) && !upsert {
 		return false, nil
 	}
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
 
-	var id any
+	var prev map[string]any
+	var next map[string]any
 	if !util.IsNil(doc) {
-		id = doc[keyID]
-	}
-	if util.IsNil(id) {
-		id = update[keyID]
-	}
-	if util.IsNil(id) {
-		if examples, ok := filterToExample(filter); ok {
-			for _, example := range examples {
-				if v, ok := example[keyID]; ok {
-					if util.IsNil(id) {
-						id = v
-					} else {
-						return false, ErrPKDuplicated
-					}
-				}
-			}
+		if docs, err := coll.decrypt(doc); err != nil {
+			return false, err
+		} else {
+			prev = docs[0]
 		}
+		next = coll.replacement(prev, update)
+		err = coll.hooks.beforeUpdate(ctx, []map[string]any{prev}, []map[string]any{next})
+	} else if next, err = coll.upsertion(filter, update); err == nil {
+		err = coll.hooks.beforeInsert(ctx, next)
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := coll.check(ctx, next); err != nil {
+		return false, err
+	}
+	if err := coll.validate(next, prev); err != nil {
+		return false, err
 	}
-	if util.IsNil(id) {
-		return false, ErrPKNotFound
+
+	stored, err := coll.encrypt(next)
+	if err != nil {
+		return false, err
 	}
 
 	if !util.IsNil(doc) {
-		if _, err := coll.deleteOne(doc); err != nil {
+		if _, err := coll.deleteOne(doc, false); err != nil {
 			return false, err
 		}
 	}
 
 	old := doc
-	doc = map[string]any{keyID: id}
-	for k, v := range update {
-		doc[k] = v
-	}
-	if _, err := coll.insertOne(doc); err != nil {
-		_, _ = coll.InsertOne(old)
+	doc = stored[0]
+	_, evicted, err := coll.insertOne(doc)
+	if err != nil {
+		if !util.IsNil(old) {
+			_, _, _ = coll.insertOne(old)
+		}
 		return false, err
 	}
 
-	coll.emit(EventUpdate, doc)
+	if !util.IsNil(old) {
+		coll.hooks.afterCommit(ctx, EventUpdate, next)
+	} else {
+		coll.hooks.afterCommit(ctx, EventInsert, next)
+	}
+	coll.emit(EventUpdate, next)
+	coll.expire(evicted...)
 
 	return true, nil
 }
 
-func (coll *Collection) UpdateMany(filter *Filter, update map[string]any, opts ...*UpdateOptions) (int, error) {
+func (coll *Collection) updateManyContext(ctx context.Context, filter *Filter, update map[string]any, opts ...*UpdateOptions) (count int, err error) {
+	ctx, q := coll.begin(ctx, OperationUpdateMany, filter, mergeUpdateOptions(opts))
+	defer coll.end(q, &err)
+
 	opt := mergeUpdateOptions(opts)
 	upsert := false
 	if !util.IsNil(opt) && !util.IsNil(opt.Upsert) {
 		upsert = util.UnPtr(opt.Upsert)
 	}
 
-	docs, err := coll.findMany(filter)
+	docs, err := coll.findMany(ctx, filter)
 	if err != nil {
 		return 0, err
 	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
 	if len(docs) == 0 {
 		if !upsert {
 			return 0, nil
 		}
 
-		id := update[keyID]
-		if util.IsNil(id) {
-			if examples, ok := filterToExample(filter); ok {
-				for _, example := range examples {
-					if v, ok := example[keyID]; ok {
-						if util.IsNil(id) {
-							id = v
-						} else {
-							return 0, ErrPKDuplicated
-						}
-					}
-				}
-			}
+		doc, err := coll.upsertion(filter, update)
+		if err != nil {
+			return 0, err
 		}
-
-		doc := map[string]any{keyID: id}
-		for k, v := range update {
-			doc[k] = v
+		if err := coll.hooks.beforeInsert(ctx, doc); err != nil {
+			return 0, err
+		}
+		if err := coll.check(ctx, doc); err != nil {
+			return 0, err
 		}
-		if _, err := coll.insertOne(doc); err != nil {
+		if err := coll.validate(doc, nil); err != nil {
 			return 0, err
 		}
+		stored, err := coll.encrypt(doc)
+		if err != nil {
+			return 0, err
+		}
+		_, evicted, err := coll.insertOne(stored[0])
+		if err != nil {
+			return 0, err
+		}
+		coll.hooks.afterCommit(ctx, EventInsert, doc)
+		coll.expire(evicted...)
 		return 1, nil
 	}
 
-	if _, err := coll.deleteMany(docs); err != nil {
+	old := docs
+	prev, err := coll.decrypt(old...)
+	if err != nil {
+		return 0, err
+	}
+	docs = make([]map[string]any, len(prev))
+	for i, doc := range prev {
+		docs[i] = coll.replacement(doc, update)
+	}
+	if err := coll.hooks.beforeUpdate(ctx, prev, docs); err != nil {
+		return 0, err
+	}
+	if err := coll.check(ctx, docs...); err != nil {
 		return 0, err
 	}
-	old := docs
 	for i, doc := range docs {
-		doc := map[string]any{keyID: doc[keyID]}
-		for k, v := range update {
-			doc[k] = v
+		if err := coll.validate(doc, prev[i]); err != nil {
+			return 0, err
 		}
-		docs[i] = doc
 	}
-	if _, err := coll.insertMany(docs); err != nil {
-		_, _ = coll.insertMany(old)
+
+	stored, err := coll.encrypt(docs...)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := coll.deleteMany(old, false); err != nil {
+		return 0, err
+	}
+	_, evicted, err := coll.insertMany(stored)
+	if err != nil {
+		_, _, _ = coll.insertMany(old)
 		return 0, err
 	}
 
+	coll.hooks.afterCommit(ctx, EventUpdate, docs...)
 	for _, doc := range docs {
 		coll.emit(EventInsert, doc)
 	}
+	coll.expire(evicted...)
 
 	return len(docs), nil
 }
 
-func (coll *Collection) DeleteOne(filter *Filter) (bool, error) {
-	if doc, err := coll.findOne(filter); err != nil {
+func (coll *Collection) deleteOneContext(ctx context.Context, filter *Filter) (ok bool, err error) {
+	ctx, q := coll.begin(ctx, OperationDeleteOne, filter, nil)
+	defer coll.end(q, &err)
+
+	if doc, err := coll.findOne(ctx, filter); err != nil {
+		return false, err
+	} else if err := ctx.Err(); err != nil {
 		return false, err
-	} else if doc, err := coll.deleteOne(doc); err != nil {
+	} else if prev, err := coll.decrypt(doc); err != nil {
+		return false, err
+	} else if err := coll.hooks.beforeDelete(ctx, prev...); err != nil {
+		return false, err
+	} else if doc, err := coll.deleteOne(doc, true); err != nil {
 		return false, err
 	} else {
 		if !util.IsNil(doc) {
-			if id, ok := doc[keyID]; ok {
-				coll.emit(EventDelete, id)
-			}
+			coll.hooks.afterCommit(ctx, EventDelete, prev...)
+			coll.emit(EventDelete, coll.pk.value(doc))
 		}
 		return !util.IsNil(doc), nil
 	}
 }
 
-func (coll *Collection) DeleteMany(filter *Filter) (int, error) {
-	if docs, err := coll.findMany(filter); err != nil {
+func (coll *Collection) deleteManyContext(ctx context.Context, filter *Filter) (count int, err error) {
+	ctx, q := coll.begin(ctx, OperationDeleteMany, filter, nil)
+	defer coll.end(q, &err)
+
+	if docs, err := coll.findMany(ctx, filter); err != nil {
+		return 0, err
+	} else if err := ctx.Err(); err != nil {
+		return 0, err
+	} else if prev, err := coll.decrypt(docs...); err != nil {
+		return 0, err
+	} else if err := coll.hooks.beforeDelete(ctx, prev...); err != nil {
 		return 0, err
-	} else if docs, err := coll.deleteMany(docs); err != nil {
+	} else if docs, err := coll.deleteMany(docs, true); err != nil {
 		return 0, err
 	} else {
+		coll.hooks.afterCommit(ctx, EventDelete, prev...)
 		for _, doc := range docs {
-			if id, ok := doc[keyID]; ok {
-				coll.emit(EventDelete, id)
-			}
+			coll.emit(EventDelete, coll.pk.value(doc))
 		}
 		return len(docs), nil
 	}
 }
 
-func (coll *Collection) FindOne(filter *Filter, opts ...*FindOptions) (map[string]any, error) {
-	return coll.findOne(filter, opts...)
-}
-
-func (coll *Collection) FindMany(filter *Filter, opts ...*FindOptions) ([]map[string]any, error) {
-	return coll.findMany(filter, opts...)
-}
+func (coll *Collection) findOneContext(ctx context.Context, filter *Filter, opts ...*FindOptions) (doc map[string]any, err error) {
+	ctx, q := coll.begin(ctx, OperationFindOne, filter, mergeFindOptions(opts))
+	defer coll.end(q, &err)
 
-func (coll *Collection) Drop() {
-	data := func() *sync.Map {
-		coll.dataLock.Lock()
-		defer coll.dataLock.Unlock()
+	opt := mergeFindOptions(append(opts, util.Ptr(FindOptions{Limit: util.Ptr(1)})))
 
-		data := coll.data
-		coll.data = pool.GetMap()
+	docs, fields, err := coll.search(ctx, filter, opt.scan())
+	if err != nil || len(docs) == 0 {
+		return nil, err
+	}
+	coll.touch(docs...)
 
-		coll.indexView.deleteAll()
+	if docs, err = coll.decrypt(docs...); err != nil {
+		return nil, err
+	}
+	if docs, err = coll.join(ctx, annotate(docs, fields), opt); err != nil || len(docs) == 0 {
+		return nil, err
+	}
+	return docs[0], nil
+}
 
-		return data
-	}()
+func (coll *Collection) findManyContext(ctx context.Context, filter *Filter, opts ...*FindOptions) (docs []map[string]any, err error) {
+	ctx, q := coll.begin(ctx, OperationFindMany, filter, mergeFindOptions(opts))
+	defer coll.end(q, &err)
 
-	data.Range(func(key, _ any) bool {
-		coll.emit(EventDelete, key)
-		return true
-	})
+	opt := mergeFindOptions(opts)
 
-	coll.dataLock.Lock()
-	defer coll.dataLock.Unlock()
+	docs, fields, err := coll.search(ctx, filter, opt.scan())
+	if err != nil {
+		return nil, err
+	}
+	coll.touch(docs...)
 
-	coll.listeners = map[int]func(Event, any){}
+	if docs, err = coll.decrypt(docs...); err != nil {
+		return nil, err
+	}
+	return coll.join(ctx, annotate(docs, fields), opt)
 }
 
-func (coll *Collection) insertOne(document map[string]any) (any, error) {
-	if ids, err := coll.insertMany([]map[string]any{document}); err != nil {
-		return nil, err
+func (coll *Collection) insertOne(document map[string]any) (any, []map[string]any, error) {
+	if ids, evicted, err := coll.insertMany([]map[string]any{document}); err != nil {
+		return nil, nil, err
 	} else {
-		return ids[0], nil
+		return ids[0], evicted, nil
 	}
 }
 
-func (coll *Collection) insertMany(documents []map[string]any) ([]any, error) {
-	coll.dataLock.Lock()
-	defer coll.dataLock.Unlock()
-
+func (coll *Collection) insertMany(documents []map[string]any) ([]any, []map[string]any, error) {
 	var ids []any
+	groups := map[int][]map[string]any{}
 	for _, doc := range documents {
-		id, ok := doc[keyID]
+		id, ok := coll.pk.id(doc)
 		if !ok {
-			return nil, ErrPKNotFound
-		} else if _, ok := coll.data.Load(id); ok {
-			return nil, ErrPKDuplicated
+			return nil, nil, ErrPKNotFound
 		}
-		ids = append(ids, id)
+		ids = append(ids, coll.pk.value(doc))
+
+		i := coll.shardOf(id)
+		groups[i] = append(groups[i], doc)
 	}
 
-	if err := coll.indexView.insertMany(documents); err != nil {
-		return nil, err
+	shards := coll.lock(groups)
+	defer coll.unlock(shards)
+
+	for i, docs := range groups {
+		for _, doc := range docs {
+			id, _ := coll.pk.id(doc)
+			if _, ok := coll.shards[i].data.Load(id); ok {
+				return nil, nil, ErrPKDuplicated
+			}
+		}
 	}
-	for i, doc := range documents {
-		coll.data.Store(ids[i], doc)
+
+	var inserted []int
+	for _, i := range shards {
+		if err := coll.shards[i].indexView.insertMany(groups[i]); err != nil {
+			for _, j := range inserted {
+				_ = coll.shards[j].indexView.deleteMany(groups[j])
+			}
+			return nil, nil, err
+		}
+		inserted = append(inserted, i)
+	}
+	for _, i := range shards {
+		for _, doc := range groups[i] {
+			id, _ := coll.pk.id(doc)
+			coll.shards[i].data.Store(id, doc)
+		}
 	}
 
-	return ids, nil
+	return ids, coll.track(documents...), nil
 }
 
-func (coll *Collection) findOne(filter *Filter, opts ...*FindOptions) (map[string]any, error) {
+func (coll *Collection) findOne(ctx context.Context, filter *Filter, opts ...*FindOptions) (map[string]any, error) {
 	opt := mergeFindOptions(append(opts, util.Ptr(FindOptions{Limit: util.Ptr(1)})))
 
-	if docs, err := coll.findMany(filter, opt); err != nil {
+	if docs, err := coll.findMany(ctx, filter, opt); err != nil {
 		return nil, err
 	} else if len(docs) > 0 {
 		return docs[0], nil
@@ -345,10 +868,12 @@ func (coll *Collection) findOne(filter *Filter, opts ...*FindOptions) (map[strin
 	}
 }
 
-func (coll *Collection) findMany(filter *Filter, opts ...*FindOptions) ([]map[string]any, error) {
-	coll.dataLock.RLock()
-	defer coll.dataLock.RUnlock()
+func (coll *Collection) findMany(ctx context.Context, filter *Filter, opts ...*FindOptions) ([]map[string]any, error) {
+	docs, _, err := coll.search(ctx, filter, opts...)
+	return docs, err
+}
 
+func (coll *Collection) search(ctx context.Context, filter *Filter, opts ...*FindOptions) ([]map[string]any, []virtualField, error) {
 	opt := mergeFindOptions(opts)
 
 	limit := -1
@@ -359,50 +884,98 @@ func (coll *Collection) findMany(filter *Filter, opts ...*FindOptions) ([]map[st
 	if !util.IsNil(opt) && !util.IsNil(opt.Skip) {
 		skip = util.UnPtr(opt.Skip)
 	}
+	var collation *Collation
+	if !util.IsNil(opt) && !util.IsNil(opt.Collation) {
+		collation = opt.Collation
+	}
 	var sorts []Sort
 	if !util.IsNil(opt) && !util.IsNil(opt.Sorts) {
-		sorts = opt.Sorts
+		sorts = collateSorts(opt.Sorts, collation)
 	}
 
-	match := parseFilter(filter)
+	filter, err := coll.encryptor.filter(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := coll.encryptor.sort(sorts); err != nil {
+		return nil, nil, err
+	}
+	match := parseFilterWith(filter, collation)
+	fields := coll.virtualFields(filter)
+	if len(sorts) == 0 && findFilter(filter, NEAR) != nil {
+		sorts = []Sort{{Key: KeyGeoDistance, Order: OrderASC}}
+	}
+
+	natural := coll.capped != nil && len(sorts) == 0
 
 	scanSize := limit
-	if skip > 0 || len(sorts) > 0 {
+	if skip > 0 || len(sorts) > 0 || natural {
 		scanSize = -1
 	}
 
 	var docs []map[string]any
-
-	if ids, err := coll.indexView.findMany(filter); err == nil {
-		for _, id := range ids {
-			if scanSize == len(docs) {
-				break
-			}
-			if doc, ok := coll.data.Load(id); ok && match(doc.(map[string]any)) {
-				docs = append(docs, doc.(map[string]any))
+	if len(coll.shards) == 1 {
+		var err error
+		var stat scanStat
+		docs, err = coll.shards[0].findMany(ctx, filter, collation, match, scanSize, &stat)
+		coll.observeScan(ctx, stat)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(sorts) > 0 && skip < len(docs) {
+			if err := sortContext(ctx, docs, parseVirtualSorts(sorts, fields)); err != nil {
+				return nil, nil, err
 			}
 		}
 	} else {
-		coll.data.Range(func(_, value any) bool {
-			if scanSize == len(docs) {
-				return false
+		if limit >= 0 && !natural && len(sorts) == 0 {
+			scanSize = limit + skip
+		}
+
+		results := make([][]map[string]any, len(coll.shards))
+		errs := make([]error, len(coll.shards))
+
+		var wg sync.WaitGroup
+		for i, s := range coll.shards {
+			wg.Add(1)
+			go func(i int, s *shard) {
+				defer wg.Done()
+
+				var stat scanStat
+				docs, err := s.findMany(ctx, filter, collation, match, scanSize, &stat)
+				coll.observeScan(ctx, stat)
+				if err == nil && len(sorts) > 0 {
+					err = sortContext(ctx, docs, parseVirtualSorts(sorts, fields))
+					if limit >= 0 && len(docs) > limit+skip {
+						docs = docs[:limit+skip]
+					}
+				}
+				results[i], errs[i] = docs, err
+			}(i, s)
+		}
+		wg.Wait()
+
+		for _, err := range errs {
+			if err != nil {
+				return nil, nil, err
 			}
+		}
 
-			if match(value.(map[string]any)) {
-				docs = append(docs, value.(map[string]any))
+		if len(sorts) > 0 {
+			docs = mergeSorted(results, parseVirtualSorts(sorts, fields))
+		} else {
+			for _, r := range results {
+				docs = append(docs, r...)
 			}
-			return true
-		})
+		}
 	}
 
-	if skip >= len(docs) {
-		return nil, nil
+	if natural {
+		coll.capped.sort(docs, coll.pk)
 	}
-	if len(sorts) > 0 {
-		compare := parseSorts(sorts)
-		sort.Slice(docs, func(i, j int) bool {
-			return compare(docs[i], docs[j])
-		})
+
+	if skip >= len(docs) {
+		return nil, nil, nil
 	}
 	if limit >= 0 {
 		if len(docs) > limit+skip {
@@ -411,11 +984,12 @@ func (coll *Collection) findMany(filter *Filter, opts ...*FindOptions) ([]map[st
 			docs = docs[skip:]
 		}
 	}
-	return docs, nil
+	queryFrom(ctx).result(len(docs))
+	return docs, fields, nil
 }
 
-func (coll *Collection) deleteOne(document map[string]any) (map[string]any, error) {
-	if docs, err := coll.deleteMany([]map[string]any{document}); err != nil {
+func (coll *Collection) deleteOne(document map[string]any, untrack bool) (map[string]any, error) {
+	if docs, err := coll.deleteMany([]map[string]any{document}, untrack); err != nil {
 		return nil, err
 	} else if len(docs) > 0 {
 		return docs[0], nil
@@ -424,32 +998,364 @@ func (coll *Collection) deleteOne(document map[string]any) (map[string]any, erro
 	}
 }
 
-func (coll *Collection) deleteMany(documents []map[string]any) ([]map[string]any, error) {
-	coll.dataLock.Lock()
-	defer coll.dataLock.Unlock()
-
-	var ids []any
+func (coll *Collection) deleteMany(documents []map[string]any, untrack bool) ([]map[string]any, error) {
 	var docs []map[string]any
+	groups := map[int][]map[string]any{}
 	for _, doc := range documents {
-		if id, ok := doc[keyID]; !ok {
+		if id, ok := coll.pk.id(doc); !ok {
 			continue
 		} else {
-			ids = append(ids, id)
 			docs = append(docs, doc)
+
+			i := coll.shardOf(id)
+			groups[i] = append(groups[i], doc)
 		}
 	}
 
-	if err := coll.indexView.deleteMany(docs); err != nil {
-		return nil, err
+	shards := coll.lock(groups)
+	defer coll.unlock(shards)
+
+	var deleted []int
+	for _, i := range shards {
+		if err := coll.shards[i].indexView.deleteMany(groups[i]); err != nil {
+			for _, j := range deleted {
+				_ = coll.shards[j].indexView.insertMany(groups[j])
+			}
+			return nil, err
+		}
+		deleted = append(deleted, i)
+	}
+	for _, i := range shards {
+		for _, doc := range groups[i] {
+			id, _ := coll.pk.id(doc)
+			coll.shards[i].data.Delete(id)
+		}
+	}
+	if untrack {
+		coll.untrack(docs...)
 	}
 
-	for _, id := range ids {
-		coll.data.Delete(id)
+	return docs, nil
+}
+
+func (coll *Collection) shardOf(id any) int {
+	if len(coll.shards) == 1 {
+		return 0
 	}
 
+	h := fnv.New32a()
+	_, _ = fmt.Fprint(h, id)
+	return int(h.Sum32() % uint32(len(coll.shards)))
+}
+
+func (coll *Collection) lock(groups map[int][]map[string]any) []int {
+	var shards []int
+	if coll.capped != nil || coll.memory != nil {
+		for i := range coll.shards {
+			shards = append(shards, i)
+		}
+	} else {
+		for i := range groups {
+			shards = append(shards, i)
+		}
+	}
+	sort.Ints(shards)
+
+	start := time.Now()
+	for _, i := range shards {
+		coll.shards[i].lock.Lock()
+	}
+	if !util.IsNil(coll.metrics) {
+		coll.metrics.ObserveLockWait(coll.Name(), time.Since(start))
+	}
+	return shards
+}
+
+func (coll *Collection) unlock(shards []int) {
+	for i := len(shards) - 1; i >= 0; i-- {
+		coll.shards[shards[i]].lock.Unlock()
+	}
+}
+
+func (coll *Collection) generate(document map[string]any) {
+	if util.IsNil(coll.generator) || len(coll.pk) != 1 {
+		return
+	}
+	if v, ok := document[coll.pk[0]]; !ok || util.IsNil(v) {
+		document[coll.pk[0]] = coll.generator.Generate()
+	}
+}
+
+func (coll *Collection) replacement(document map[string]any, update map[string]any) map[string]any {
+	next := map[string]any{}
+	for _, k := range coll.pk {
+		if v, ok := document[k]; ok {
+			next[k] = v
+		}
+	}
+	for k, v := range update {
+		next[k] = v
+	}
+	return next
+}
+
+func (coll *Collection) upsertion(filter *Filter, update map[string]any) (map[string]any, error) {
+	next := map[string]any{}
+	examples, _ := filterToExample(filter)
+	for _, k := range coll.pk {
+		if v, ok := update[k]; ok && !util.IsNil(v) {
+			continue
+		}
+		for _, example := range examples {
+			if v, ok := example[k]; ok {
+				if _, ok := next[k]; ok {
+					return nil, ErrPKDuplicated
+				}
+				next[k] = v
+			}
+		}
+	}
+	for k, v := range update {
+		next[k] = v
+	}
+
+	coll.generate(next)
+	if _, ok := coll.pk.id(next); !ok {
+		return nil, ErrPKNotFound
+	}
+	return next, nil
+}
+
+func (coll *Collection) check(ctx context.Context, documents ...map[string]any) error {
+	filter := checkFrom(ctx)
+	if util.IsNil(filter) {
+		return nil
+	}
+
+	match := parseFilter(filter)
+	for _, doc := range documents {
+		if !match(doc) {
+			return ErrForbidden
+		}
+	}
+	return nil
+}
+
+func (coll *Collection) virtualFields(filter *Filter) []virtualField {
+	var fields []virtualField
+	if s := coll.scorer(filter); s != nil {
+		fields = append(fields, s)
+	}
+	if l := coll.locator(filter); l != nil {
+		fields = append(fields, l)
+	}
+	return fields
+}
+
+func (coll *Collection) encrypt(documents ...map[string]any) ([]map[string]any, error) {
+	if coll.encryptor == nil {
+		return documents, nil
+	}
+
+	docs := make([]map[string]any, len(documents))
+	for i, doc := range documents {
+		if doc, err := coll.encryptor.encrypt(doc); err != nil {
+			return nil, err
+		} else {
+			docs[i] = doc
+		}
+	}
+	return docs, nil
+}
+
+func (coll *Collection) decrypt(documents ...map[string]any) ([]map[string]any, error) {
+	if coll.encryptor == nil {
+		return documents, nil
+	}
+
+	docs := make([]map[string]any, len(documents))
+	for i, doc := range documents {
+		if doc, err := coll.encryptor.decrypt(doc); err != nil {
+			return nil, err
+		} else {
+			docs[i] = doc
+		}
+	}
 	return docs, nil
 }
 
+func (coll *Collection) validate(document map[string]any, old map[string]any) error {
+	if util.IsNil(coll.validator) || coll.validationLevel == ValidationOff {
+		return nil
+	}
+	if coll.validationLevel == ValidationModerate && !util.IsNil(old) && coll.validator.Validate(old) != nil {
+		return nil
+	}
+	return coll.validator.Validate(document)
+}
+
+func (coll *Collection) track(documents ...map[string]any) []map[string]any {
+	if coll.capped == nil && coll.memory == nil {
+		return nil
+	}
+
+	for _, doc := range documents {
+		if id, ok := coll.pk.id(doc); ok {
+			if coll.capped != nil {
+				coll.capped.push(id, doc)
+			}
+			if coll.memory != nil {
+				coll.memory.push(id, doc)
+			}
+		}
+	}
+
+	var evicted []map[string]any
+	if coll.capped != nil {
+		evicted = append(evicted, coll.remove(coll.capped.overflow()...)...)
+	}
+	if coll.memory != nil {
+		docs := coll.remove(coll.memory.overflow()...)
+		atomic.AddInt64(&coll.memory.evictions, int64(len(docs)))
+		evicted = append(evicted, docs...)
+	}
+	return evicted
+}
+
+func (coll *Collection) expire(documents ...map[string]any) {
+	for _, doc := range documents {
+		coll.emit(EventDelete, coll.pk.value(doc))
+	}
+	if coll.budget != nil {
+		coll.budget.evict()
+	}
+}
+
+func (coll *Collection) touch(documents ...map[string]any) {
+	if coll.memory == nil || len(documents) == 0 {
+		return
+	}
+
+	ids := make([]any, 0, len(documents))
+	for _, doc := range documents {
+		if id, ok := coll.pk.id(doc); ok {
+			ids = append(ids, id)
+		}
+	}
+	coll.memory.touch(ids...)
+}
+
+func (coll *Collection) untrack(documents ...map[string]any) {
+	if coll.capped == nil && coll.memory == nil {
+		return
+	}
+
+	for _, doc := range documents {
+		if id, ok := coll.pk.id(doc); ok {
+			if coll.capped != nil {
+				coll.capped.remove(id)
+			}
+			if coll.memory != nil {
+				coll.memory.remove(id)
+			}
+		}
+	}
+}
+
+func (coll *Collection) remove(ids ...any) []map[string]any {
+	groups := map[int][]map[string]any{}
+	for _, id := range ids {
+		i := coll.shardOf(id)
+		if doc, ok := coll.shards[i].data.Load(id); ok {
+			groups[i] = append(groups[i], doc.(map[string]any))
+		}
+	}
+
+	var docs []map[string]any
+	for i, group := range groups {
+		if err := coll.shards[i].indexView.deleteMany(group); err != nil {
+			continue
+		}
+		for _, doc := range group {
+			id, _ := coll.pk.id(doc)
+			coll.shards[i].data.Delete(id)
+		}
+		docs = append(docs, group...)
+	}
+	coll.untrack(docs...)
+	return docs
+}
+
+func (coll *Collection) evict(ids ...any) int {
+	var evicted []map[string]any
+	for _, id := range ids {
+		if doc, ok := coll.shards[coll.shardOf(id)].data.Load(id); ok {
+			evicted = append(evicted, doc.(map[string]any))
+		}
+	}
+	if len(evicted) == 0 {
+		return 0
+	}
+
+	docs, err := coll.deleteMany(evicted, true)
+	if err != nil {
+		return 0
+	}
+	for _, doc := range docs {
+		coll.emit(EventDelete, coll.pk.value(doc))
+	}
+	return len(docs)
+}
+
+func (coll *Collection) intercept(ctx context.Context, op *Operation, handler Handler) (any, error) {
+	if err := coll.failure(); err != nil {
+		return nil, err
+	}
+	if coll.chain == nil {
+		return handler(ctx, op)
+	}
+	op.Collection = coll.Name()
+	return coll.chain.intercept(ctx, op, handler)
+}
+
+func (coll *Collection) begin(ctx context.Context, operation OperationType, filter *Filter, options any) (context.Context, *query) {
+	q := &query{
+		operation: operation,
+		filter:    filter,
+		options:   options,
+		start:     time.Now(),
+	}
+	if coll.profiler.active() {
+		ctx = withQuery(ctx, q)
+	}
+	return ctx, q
+}
+
+func (coll *Collection) end(q *query, err *error) {
+	duration := time.Since(q.start)
+	if !util.IsNil(coll.metrics) {
+		coll.metrics.ObserveOperation(coll.Name(), string(q.operation), duration, *err)
+	}
+	if coll.profiler.active() {
+		coll.profiler.record(coll, q, duration, *err)
+	}
+}
+
+func (coll *Collection) observeListeners() {
+	if !util.IsNil(coll.metrics) {
+		coll.metrics.ObserveListenerCount(coll.Name(), len(coll.listeners))
+	}
+}
+
+func (coll *Collection) observeScan(ctx context.Context, stat scanStat) {
+	queryFrom(ctx).observe(stat)
+	if !util.IsNil(coll.metrics) {
+		name := coll.Name()
+		coll.metrics.ObserveScan(name, stat.indexed)
+		coll.metrics.ObserveLockWait(name, stat.wait)
+	}
+}
+
 func (coll *Collection) emit(event Event, val any) {
 	coll.listenersLock.RLock()
 	defer coll.listenersLock.RUnlock()
@@ -459,6 +1365,164 @@ func (coll *Collection) emit(event Event, val any) {
 	}
 }
 
+func (s *shard) findMany(ctx context.Context, filter *Filter, collation *Collation, match func(map[string]any) bool, scanSize int, stat *scanStat) ([]map[string]any, error) {
+	start := time.Now()
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	stat.wait = time.Since(start)
+
+	var docs []map[string]any
+	var err error
+
+	if ids, e := s.indexView.findManyWith(filter, collation); e == nil {
+		stat.indexed = true
+		for i, id := range ids {
+			if scanSize == len(docs) {
+				break
+			}
+			if i%checkInterval == 0 {
+				if err = ctx.Err(); err != nil {
+					break
+				}
+			}
+			stat.scanned++
+			if doc, ok := s.data.Load(id); ok && match(doc.(map[string]any)) {
+				docs = append(docs, doc.(map[string]any))
+			}
+		}
+	} else {
+		i := 0
+		s.data.Range(func(_, value any) bool {
+			if scanSize == len(docs) {
+				return false
+			}
+			if i%checkInterval == 0 {
+				if err = ctx.Err(); err != nil {
+					return false
+				}
+			}
+			i++
+			stat.scanned++
+
+			if match(value.(map[string]any)) {
+				docs = append(docs, value.(map[string]any))
+			}
+			return true
+		})
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+func countFields(document map[string]any, prefix string, fields map[string]int) {
+	for k, v := range document {
+		path := joinPath(prefix, k)
+		fields[path]++
+		if sub, ok := v.(map[string]any); ok {
+			countFields(sub, path, fields)
+		}
+	}
+}
+
+func sortContext(ctx context.Context, docs []map[string]any, compare func(i, j map[string]any) bool) error {
+	var err error
+	n := 0
+	sort.Slice(docs, func(i, j int) bool {
+		if err != nil {
+			return false
+		}
+		if n%checkInterval == 0 {
+			err = ctx.Err()
+		}
+		n++
+		return compare(docs[i], docs[j])
+	})
+	return err
+}
+
+func mergeSorted(lists [][]map[string]any, compare func(i, j map[string]any) bool) []map[string]any {
+	var docs []map[string]any
+	cursors := make([]int, len(lists))
+	for {
+		next := -1
+		for i, list := range lists {
+			if cursors[i] >= len(list) {
+				continue
+			}
+			if next < 0 || compare(list[cursors[i]], lists[next][cursors[next]]) {
+				next = i
+			}
+		}
+		if next < 0 {
+			return docs
+		}
+		docs = append(docs, lists[next][cursors[next]])
+		cursors[next]++
+	}
+}
+
+func (opt *CollectionOptions) compile() error {
+	if util.IsNil(opt) {
+		return nil
+	}
+	if err := opt.Validator.Compile(); err != nil {
+		return err
+	}
+	return opt.Encryption.compile(opt.PrimaryKey)
+}
+
+func mergeCollectionOptions(options []*CollectionOptions) *CollectionOptions {
+	if len(options) == 0 {
+		return nil
+	}
+	opt := &CollectionOptions{}
+	for _, curr := range options {
+		if util.IsNil(curr) {
+			continue
+		}
+		if !util.IsNil(curr.Shards) {
+			opt.Shards = curr.Shards
+		}
+		if !util.IsNil(curr.Validator) {
+			opt.Validator = curr.Validator
+		}
+		if !util.IsNil(curr.ValidationLevel) {
+			opt.ValidationLevel = curr.ValidationLevel
+		}
+		if !util.IsNil(curr.PrimaryKey) {
+			opt.PrimaryKey = curr.PrimaryKey
+		}
+		if !util.IsNil(curr.IDGenerator) {
+			opt.IDGenerator = curr.IDGenerator
+		}
+		if !util.IsNil(curr.MaxDocuments) {
+			opt.MaxDocuments = curr.MaxDocuments
+		}
+		if !util.IsNil(curr.MaxBytes) {
+			opt.MaxBytes = curr.MaxBytes
+		}
+		if !util.IsNil(curr.MaxMemory) {
+			opt.MaxMemory = curr.MaxMemory
+		}
+		if !util.IsNil(curr.EvictionPolicy) {
+			opt.EvictionPolicy = curr.EvictionPolicy
+		}
+		if !util.IsNil(curr.TTLKey) {
+			opt.TTLKey = curr.TTLKey
+		}
+		if !util.IsNil(curr.Metrics) {
+			opt.Metrics = curr.Metrics
+		}
+		if !util.IsNil(curr.Encryption) {
+			opt.Encryption = curr.Encryption
+		}
+	}
+	return opt
+}
+
 func mergeUpdateOptions(options []*UpdateOptions) *UpdateOptions {
 	if len(options) == 0 {
 		return nil
@@ -493,6 +1557,12 @@ func mergeFindOptions(options []*FindOptions) *FindOptions {
 		if !util.IsNil(curr.Sorts) {
 			opt.Sorts = curr.Sorts
 		}
+		if !util.IsNil(curr.Collation) {
+			opt.Collation = curr.Collation
+		}
+		if !util.IsNil(curr.Lookups) {
+			opt.Lookups = curr.Lookups
+		}
 	}
 	return opt
 }
@@ -1,10 +1,15 @@
 package memdb
 
 import (
+	"context"
+	"fmt"
 	"github.com/go-faker/faker/v4"
 	"github.com/siyul-park/memdb/internal/util"
 	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 const (
@@ -267,6 +272,221 @@ func TestCollection_Drop(t *testing.T) {
 	assert.Len(t, many, 0)
 }
 
+func TestCollection_Stats(t *testing.T) {
+	coll := newCollection(faker.Name())
+
+	err := coll.Indexes().Create(IndexModel{
+		Keys: []string{"name"},
+		Name: "name",
+	})
+	assert.NoError(t, err)
+
+	_, err = coll.InsertMany([]map[string]any{
+		{"id": 1, "name": "a", "profile": map[string]any{"age": 10}},
+		{"id": 2, "name": "a"},
+		{"id": 3},
+	})
+	assert.NoError(t, err)
+
+	stats, err := coll.Stats()
+	assert.NoError(t, err)
+	assert.Equal(t, coll.Name(), stats.Name)
+	assert.Equal(t, 3, stats.Count)
+	assert.Greater(t, stats.Size, 0)
+	assert.Equal(t, map[string]int{"id": 3, "name": 2, "profile": 1, "profile.age": 1}, stats.Fields)
+	assert.Equal(t, []IndexStats{
+		{Name: "_id", Entries: 3, Keys: 3, Depth: 1},
+		{Name: "name", Entries: 3, Keys: 2, Depth: 2},
+	}, stats.Indexes)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = coll.StatsContext(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestCollection_Context(t *testing.T) {
+	coll := newCollection(faker.Name())
+
+	for i := 0; i < 1000; i++ {
+		_, err := coll.InsertOne(map[string]any{
+			"id":   faker.UUIDHyphenated(),
+			"name": faker.Name(),
+		})
+		assert.NoError(t, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := coll.InsertOneContext(ctx, map[string]any{"id": faker.UUIDHyphenated()})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = coll.FindManyContext(ctx, Where("name").NE(""))
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = coll.UpdateManyContext(ctx, nil, map[string]any{"name": faker.Name()})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = coll.DeleteManyContext(ctx, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	res, err := coll.FindManyContext(context.Background(), nil, &FindOptions{
+		Sorts: []Sort{{Key: "name"}},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, res, 1000)
+}
+
+func TestCollection_Validator(t *testing.T) {
+	schema := &Schema{
+		Type:     SchemaType{"object"},
+		Required: []string{"id", "name"},
+		Properties: map[string]*Schema{
+			"name": {Type: SchemaType{"string"}},
+		},
+	}
+
+	t.Run("ValidationStrict", func(t *testing.T) {
+		coll := newCollection(faker.Name(), &CollectionOptions{Validator: schema})
+
+		_, err := coll.InsertOne(map[string]any{"id": faker.UUIDHyphenated()})
+		assert.ErrorIs(t, err, ErrValidation)
+
+		id, err := coll.InsertOne(map[string]any{"id": faker.UUIDHyphenated(), "name": faker.Name()})
+		assert.NoError(t, err)
+
+		_, err = coll.UpdateOne(Where("id").EQ(id), map[string]any{"name": 1})
+		assert.ErrorIs(t, err, ErrValidation)
+
+		_, err = coll.UpdateMany(Where("id").EQ(id), map[string]any{"name": 1})
+		assert.ErrorIs(t, err, ErrValidation)
+
+		doc, err := coll.FindOne(Where("id").EQ(id))
+		assert.NoError(t, err)
+		assert.IsType(t, "", doc["name"])
+	})
+
+	t.Run("ValidationModerate", func(t *testing.T) {
+		coll := newCollection(faker.Name(), &CollectionOptions{
+			Validator:       schema,
+			ValidationLevel: util.Ptr(ValidationModerate),
+		})
+
+		invalid := map[string]any{"id": faker.UUIDHyphenated()}
+		_, _, err := coll.insertOne(invalid)
+		assert.NoError(t, err)
+
+		ok, err := coll.UpdateOne(Where("id").EQ(invalid["id"]), map[string]any{"name": 1})
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		_, err = coll.InsertOne(map[string]any{"id": faker.UUIDHyphenated()})
+		assert.ErrorIs(t, err, ErrValidation)
+	})
+
+	t.Run("ValidationOff", func(t *testing.T) {
+		coll := newCollection(faker.Name(), &CollectionOptions{
+			Validator:       schema,
+			ValidationLevel: util.Ptr(ValidationOff),
+		})
+
+		_, err := coll.InsertOne(map[string]any{"id": faker.UUIDHyphenated()})
+		assert.NoError(t, err)
+	})
+}
+
+func TestCollection_Shards(t *testing.T) {
+	coll := newCollection(faker.Name(), &CollectionOptions{Shards: util.Ptr(4)})
+
+	coll.Indexes().Create(IndexModel{
+		Keys:   []string{"email"},
+		Name:   "email",
+		Unique: true,
+	})
+
+	var docs []map[string]any
+	for i := 0; i < 20; i++ {
+		docs = append(docs, map[string]any{
+			"id":    faker.UUIDHyphenated(),
+			"email": faker.Email(),
+			"order": i,
+		})
+	}
+
+	_, err := coll.InsertMany(docs)
+	assert.NoError(t, err)
+
+	t.Run("FindMany", func(t *testing.T) {
+		res, err := coll.FindMany(nil, &FindOptions{
+			Skip:  util.Ptr(5),
+			Limit: util.Ptr(10),
+			Sorts: []Sort{{Key: "order", Order: OrderDESC}},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, res, 10)
+		for i, doc := range res {
+			assert.Equal(t, 14-i, doc["order"])
+		}
+
+		res, err = coll.FindMany(Where("email").EQ(docs[3]["email"]))
+		assert.NoError(t, err)
+		assert.Len(t, res, 1)
+		assert.Equal(t, docs[3], res[0])
+	})
+
+	t.Run("TopK", func(t *testing.T) {
+		coll := newCollection(faker.Name(), &CollectionOptions{Shards: util.Ptr(4)})
+
+		var docs []map[string]any
+		for i := 0; i < 200; i++ {
+			docs = append(docs, map[string]any{"id": i, "v": 200 - i})
+		}
+		_, err := coll.InsertMany(docs)
+		assert.NoError(t, err)
+
+		res, err := coll.FindMany(nil, &FindOptions{
+			Limit: util.Ptr(3),
+			Sorts: []Sort{{Key: "v", Order: OrderASC}},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []map[string]any{{"id": 199, "v": 1}, {"id": 198, "v": 2}, {"id": 197, "v": 3}}, res)
+
+		res, err = coll.FindMany(Where("v").GT(100), &FindOptions{
+			Skip:  util.Ptr(2),
+			Limit: util.Ptr(2),
+			Sorts: []Sort{{Key: "v", Order: OrderDESC}},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []map[string]any{{"id": 2, "v": 198}, {"id": 3, "v": 197}}, res)
+	})
+
+	t.Run("Unique", func(t *testing.T) {
+		var ids []any
+		for len(ids) < 2 {
+			id := faker.UUIDHyphenated()
+			if len(ids) == 0 || coll.shardOf(id) != coll.shardOf(ids[0]) {
+				ids = append(ids, id)
+			}
+		}
+		email := faker.Email()
+
+		_, err := coll.InsertOne(map[string]any{"id": ids[0], "email": email})
+		assert.NoError(t, err)
+
+		_, err = coll.InsertOne(map[string]any{"id": ids[1], "email": email})
+		assert.ErrorIs(t, err, ErrIndexConflict)
+
+		ok, err := coll.DeleteOne(Where("id").EQ(ids[0]))
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		_, err = coll.InsertOne(map[string]any{"id": ids[1], "email": email})
+		assert.NoError(t, err)
+	})
+}
+
 func BenchmarkCollection_InsertOne(b *testing.B) {
 	coll := newCollection(faker.Name())
 
@@ -280,6 +500,27 @@ func BenchmarkCollection_InsertOne(b *testing.B) {
 	}
 }
 
+func BenchmarkCollection_InsertOne_Parallel(b *testing.B) {
+	for _, shards := range []int{1, 8} {
+		b.Run(fmt.Sprintf("Shards%d", shards), func(b *testing.B) {
+			coll := newCollection(faker.Name(), &CollectionOptions{Shards: util.Ptr(shards)})
+
+			var id atomic.Int64
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					_, err := coll.InsertOne(map[string]any{
+						"id":   id.Add(1),
+						"type": "a",
+					})
+					if err != nil {
+						b.Error(err)
+					}
+				}
+			})
+		})
+	}
+}
+
 func BenchmarkCollection_InsertMany(b *testing.B) {
 	coll := newCollection(faker.Name())
 
@@ -572,3 +813,300 @@ func BenchmarkCollection_FindMany(b *testing.B) {
 		}
 	})
 }
+
+func TestCollection_PrimaryKey(t *testing.T) {
+	t.Run("Compound", func(t *testing.T) {
+		coll := newCollection(faker.Name(), &CollectionOptions{PrimaryKey: []string{"tenant", "user"}})
+
+		doc := map[string]any{
+			"tenant": faker.UUIDHyphenated(),
+			"user":   faker.UUIDHyphenated(),
+		}
+
+		id, err := coll.InsertOne(doc)
+		assert.NoError(t, err)
+		assert.Equal(t, []any{doc["tenant"], doc["user"]}, id)
+
+		_, err = coll.InsertOne(doc)
+		assert.ErrorIs(t, err, ErrPKDuplicated)
+
+		_, err = coll.InsertOne(map[string]any{"tenant": doc["tenant"]})
+		assert.ErrorIs(t, err, ErrPKNotFound)
+
+		_, err = coll.InsertOne(map[string]any{"tenant": doc["tenant"], "user": faker.UUIDHyphenated()})
+		assert.NoError(t, err)
+
+		res, err := coll.FindMany(Where("tenant").EQ(doc["tenant"]))
+		assert.NoError(t, err)
+		assert.Len(t, res, 2)
+
+		var deleted any
+		coll.Watch(func(event Event, val any) {
+			if event == EventDelete {
+				deleted = val
+			}
+		})
+
+		ok, err := coll.DeleteOne(Where("tenant").EQ(doc["tenant"]).And(Where("user").EQ(doc["user"])))
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, id, deleted)
+	})
+
+	t.Run("Generator", func(t *testing.T) {
+		coll := newCollection(faker.Name(), &CollectionOptions{
+			PrimaryKey:  []string{"_key"},
+			IDGenerator: NewSequenceGenerator(1),
+		})
+
+		id, err := coll.InsertOne(map[string]any{"name": faker.Name()})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), id)
+
+		ids, err := coll.InsertMany([]map[string]any{{"name": faker.Name()}, {"_key": "custom"}})
+		assert.NoError(t, err)
+		assert.Equal(t, []any{int64(2), "custom"}, ids)
+
+		ok, err := coll.UpdateOne(Where("name").EQ("missing"), map[string]any{"name": "missing"}, &UpdateOptions{Upsert: util.Ptr(true)})
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		doc, err := coll.FindOne(Where("name").EQ("missing"))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), doc["_key"])
+	})
+}
+
+func TestCollection_Capped(t *testing.T) {
+	t.Run("MaxDocuments", func(t *testing.T) {
+		coll := newCollection(faker.Name(), &CollectionOptions{MaxDocuments: util.Ptr(3)})
+
+		var deleted []any
+		coll.Watch(func(event Event, val any) {
+			if event == EventDelete {
+				deleted = append(deleted, val)
+			}
+		})
+
+		for i := 0; i < 5; i++ {
+			_, err := coll.InsertOne(map[string]any{"id": i})
+			assert.NoError(t, err)
+		}
+
+		docs, err := coll.FindMany(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []map[string]any{{"id": 2}, {"id": 3}, {"id": 4}}, docs)
+		assert.Equal(t, []any{0, 1}, deleted)
+
+		ok, err := coll.UpdateOne(Where("id").EQ(2), map[string]any{"value": "updated"})
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		_, err = coll.InsertOne(map[string]any{"id": 5})
+		assert.NoError(t, err)
+
+		docs, err = coll.FindMany(nil, &FindOptions{Limit: util.Ptr(2)})
+		assert.NoError(t, err)
+		assert.Equal(t, []map[string]any{{"id": 3}, {"id": 4}}, docs)
+
+		ok, err = coll.DeleteOne(Where("id").EQ(4))
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		_, err = coll.InsertMany([]map[string]any{{"id": 6}, {"id": 7}})
+		assert.NoError(t, err)
+
+		docs, err = coll.FindMany(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []map[string]any{{"id": 5}, {"id": 6}, {"id": 7}}, docs)
+	})
+
+	t.Run("MaxBytes", func(t *testing.T) {
+		coll := newCollection(faker.Name(), &CollectionOptions{
+			Shards:   util.Ptr(4),
+			MaxBytes: util.Ptr(100),
+		})
+
+		for i := 0; i < 10; i++ {
+			_, err := coll.InsertOne(map[string]any{"id": i, "data": "0123456789"})
+			assert.NoError(t, err)
+		}
+
+		docs, err := coll.FindMany(nil)
+		assert.NoError(t, err)
+		assert.Len(t, docs, 4)
+		for i, doc := range docs {
+			assert.Equal(t, 6+i, doc["id"])
+		}
+	})
+}
+
+func TestCollection_Memory(t *testing.T) {
+	doc := func(id int) map[string]any {
+		return map[string]any{"id": id, "data": "0123456789"}
+	}
+
+	t.Run("LRU", func(t *testing.T) {
+		coll := newCollection(faker.Name(), &CollectionOptions{
+			MaxMemory:      util.Ptr(72),
+			EvictionPolicy: util.Ptr(EvictionLRU),
+		})
+
+		for i := 0; i < 3; i++ {
+			_, err := coll.InsertOne(doc(i))
+			assert.NoError(t, err)
+		}
+
+		_, err := coll.FindOne(Where("id").EQ(0))
+		assert.NoError(t, err)
+
+		_, err = coll.InsertOne(doc(3))
+		assert.NoError(t, err)
+
+		r, err := coll.FindOne(Where("id").EQ(1))
+		assert.NoError(t, err)
+		assert.Nil(t, r)
+
+		stats := coll.Memory()
+		assert.Equal(t, 72, stats.Size)
+		assert.Equal(t, 72, stats.Limit)
+		assert.Equal(t, 1, stats.Evictions)
+	})
+
+	t.Run("LFU", func(t *testing.T) {
+		coll := newCollection(faker.Name(), &CollectionOptions{
+			MaxMemory:      util.Ptr(72),
+			EvictionPolicy: util.Ptr(EvictionLFU),
+		})
+
+		for i := 0; i < 3; i++ {
+			_, err := coll.InsertOne(doc(i))
+			assert.NoError(t, err)
+		}
+		for i := 0; i < 3; i++ {
+			if i == 1 {
+				continue
+			}
+			for j := 0; j < 2; j++ {
+				_, err := coll.FindOne(Where("id").EQ(i))
+				assert.NoError(t, err)
+			}
+		}
+
+		_, err := coll.InsertOne(doc(3))
+		assert.NoError(t, err)
+		_, err = coll.InsertOne(doc(4))
+		assert.NoError(t, err)
+
+		docs, err := coll.FindMany(nil, &FindOptions{Sorts: []Sort{{Key: "id", Order: OrderASC}}})
+		assert.NoError(t, err)
+		assert.Len(t, docs, 3)
+		assert.Equal(t, 0, docs[0]["id"])
+		assert.Equal(t, 2, docs[1]["id"])
+		assert.Equal(t, 4, docs[2]["id"])
+	})
+
+	t.Run("TTL", func(t *testing.T) {
+		coll := newCollection(faker.Name(), &CollectionOptions{
+			MaxMemory:      util.Ptr(3),
+			EvictionPolicy: util.Ptr(EvictionTTL),
+			TTLKey:         util.Ptr("expire"),
+		})
+
+		now := time.Now()
+		_, err := coll.InsertMany([]map[string]any{
+			{"id": "a"},
+			{"id": "b", "expire": now.Add(time.Hour)},
+			{"id": "c", "expire": now.Add(time.Minute)},
+		})
+		assert.NoError(t, err)
+
+		docs, err := coll.FindMany(nil, &FindOptions{Sorts: []Sort{{Key: "id", Order: OrderASC}}})
+		assert.NoError(t, err)
+		assert.Len(t, docs, 1)
+		assert.Equal(t, "a", docs[0]["id"])
+	})
+
+	t.Run("Random", func(t *testing.T) {
+		coll := newCollection(faker.Name(), &CollectionOptions{
+			MaxMemory:      util.Ptr(240),
+			EvictionPolicy: util.Ptr(EvictionRandom),
+		})
+
+		for i := 0; i < 20; i++ {
+			_, err := coll.InsertOne(doc(i))
+			assert.NoError(t, err)
+		}
+
+		docs, err := coll.FindMany(nil)
+		assert.NoError(t, err)
+		assert.Len(t, docs, 10)
+		assert.Equal(t, 10, coll.Memory().Evictions)
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		coll := newCollection(faker.Name(), &CollectionOptions{
+			Shards:    util.Ptr(8),
+			MaxMemory: util.Ptr(240),
+		})
+
+		usage := func() int {
+			for _, s := range coll.shards {
+				s.lock.RLock()
+			}
+			defer func() {
+				for _, s := range coll.shards {
+					s.lock.RUnlock()
+				}
+			}()
+
+			size := 0
+			for _, s := range coll.shards {
+				s.data.Range(func(_, value any) bool {
+					size += sizeOf(value)
+					return true
+				})
+			}
+			return size
+		}
+
+		done := make(chan struct{})
+		peak := make(chan int)
+		go func() {
+			max := 0
+			for {
+				select {
+				case <-done:
+					peak <- max
+					return
+				default:
+					if size := usage(); size > max {
+						max = size
+					}
+				}
+			}
+		}()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 50; j++ {
+					_, err := coll.InsertOne(doc(i*50 + j))
+					assert.NoError(t, err)
+				}
+			}(i)
+		}
+		wg.Wait()
+		close(done)
+
+		docs, err := coll.FindMany(nil)
+		assert.NoError(t, err)
+
+		stats := coll.Memory()
+		assert.LessOrEqual(t, <-peak, stats.Limit)
+		assert.Equal(t, usage(), stats.Size)
+		assert.Equal(t, 400, len(docs)+stats.Evictions)
+	})
+}
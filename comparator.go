@@ -0,0 +1,35 @@
+package memdb
+
+import (
+	"github.com/pkg/errors"
+	"github.com/siyul-park/memdb/internal/util/reflectutil"
+	"reflect"
+)
+
+var (
+	ErrCodeInvalidComparator = "invalid_comparator"
+
+	ErrInvalidComparator = errors.New(ErrCodeInvalidComparator)
+)
+
+func RegisterComparator[T any](compare func(x, y T) int, key func(value T) any) error {
+	if compare == nil || key == nil {
+		return errors.Wrap(ErrInvalidComparator, typeOf[T]().String())
+	}
+	return reflectutil.Register(typeOf[T](), reflectutil.Codec{
+		Compare: func(x, y any) int {
+			return compare(x.(T), y.(T))
+		},
+		Key: func(value any) any {
+			return key(value.(T))
+		},
+	})
+}
+
+func UnregisterComparator[T any]() {
+	reflectutil.Unregister(typeOf[T]())
+}
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
@@ -0,0 +1,72 @@
+package memdb
+
+import (
+	"fmt"
+	"github.com/go-faker/faker/v4"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+type semver string
+
+func (v semver) parts() [3]int {
+	var parts [3]int
+	_, _ = fmt.Sscanf(strings.TrimPrefix(string(v), "v"), "%d.%d.%d", &parts[0], &parts[1], &parts[2])
+	return parts
+}
+
+func TestRegisterComparator(t *testing.T) {
+	err := RegisterComparator[semver](func(x, y semver) int {
+		return strings.Compare(string(x), string(y))
+	}, nil)
+	assert.ErrorIs(t, err, ErrInvalidComparator)
+
+	err = RegisterComparator(func(x, y semver) int {
+		a, b := x.parts(), y.parts()
+		for i := range a {
+			if a[i] != b[i] {
+				if a[i] < b[i] {
+					return -1
+				}
+				return 1
+			}
+		}
+		return 0
+	}, func(value semver) any {
+		return value.parts()
+	})
+	assert.NoError(t, err)
+	defer UnregisterComparator[semver]()
+
+	coll := newCollection(faker.Name())
+
+	err = coll.Indexes().Create(IndexModel{Name: "version", Keys: []string{"version"}, Unique: true})
+	assert.NoError(t, err)
+
+	_, err = coll.InsertMany([]map[string]any{
+		{"id": 1, "version": semver("1.10.0")},
+		{"id": 2, "version": semver("1.9.2")},
+		{"id": 3, "version": semver("v2.0.0")},
+	})
+	assert.NoError(t, err)
+
+	_, err = coll.InsertOne(map[string]any{"id": 4, "version": semver("v1.9.2")})
+	assert.ErrorIs(t, err, ErrIndexConflict)
+
+	docs, err := coll.FindMany(Where("version").EQ(semver("2.0.0")))
+	assert.NoError(t, err)
+	assert.Equal(t, []any{3}, ids(docs))
+
+	docs, err = coll.FindMany(Where("version").GT(semver("1.9.10")), &FindOptions{
+		Sorts: []Sort{{Key: "version", Order: OrderASC}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []any{1, 3}, ids(docs))
+
+	docs, err = coll.FindMany(nil, &FindOptions{
+		Sorts: []Sort{{Key: "version", Order: OrderDESC}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []any{3, 1, 2}, ids(docs))
+}
@@ -1,23 +1,97 @@
 package memdb
 
 import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/siyul-park/memdb/internal/util"
+	"sort"
 	"sync"
 )
 
 type (
 	Database struct {
-		name        string
-		collections map[string]*Collection
-		lock        sync.RWMutex
+		name          string
+		collections   map[string]*Collection
+		views         map[string]*View
+		materialized  map[string]*materializer
+		policy        EvictionPolicy
+		budget        *budget
+		metrics       Metrics
+		profiler      *profiler
+		chain         *chain
+		roles         *roles
+		listeners     map[int]func(DatabaseEvent, any)
+		listenersLock sync.RWMutex
+		lock          sync.RWMutex
 	}
+
+	DatabaseOptions struct {
+		MaxMemory      *int
+		EvictionPolicy *EvictionPolicy
+		Metrics        Metrics
+		Profile        *ProfileOptions
+		Interceptors   []Interceptor
+	}
+
+	DatabaseStats struct {
+		Name        string
+		Collections map[string]CollectionStats
+		Count       int
+		Size        int
+		Indexes     int
+	}
+
+	DatabaseEvent int
+
+	CollectionRename struct {
+		From string
+		To   string
+	}
+)
+
+const (
+	EventCollectionCreate DatabaseEvent = iota
+	EventCollectionDrop
+	EventCollectionRename
+)
+
+var (
+	ErrCodeCollectionExists   = "collection_exists"
+	ErrCodeCollectionNotFound = "collection_notfound"
+
+	ErrCollectionExists   = errors.New(ErrCodeCollectionExists)
+	ErrCollectionNotFound = errors.New(ErrCodeCollectionNotFound)
 )
 
-func New(name string) *Database {
-	return &Database{
-		name:        name,
-		collections: map[string]*Collection{},
-		lock:        sync.RWMutex{},
+func New(name string, opts ...*DatabaseOptions) *Database {
+	opt := mergeDatabaseOptions(opts)
+
+	db := &Database{
+		name:         name,
+		collections:  map[string]*Collection{},
+		views:        map[string]*View{},
+		materialized: map[string]*materializer{},
+		listeners:    map[int]func(DatabaseEvent, any){},
+		lock:         sync.RWMutex{},
+	}
+	db.profiler = newProfiler(db)
+	db.chain = &chain{}
+	db.roles = &roles{data: map[string]Role{}}
+	if !util.IsNil(opt) {
+		db.policy = util.UnPtr(opt.EvictionPolicy)
+		db.metrics = opt.Metrics
+		if maxMemory := util.UnPtr(opt.MaxMemory); maxMemory > 0 {
+			db.budget = &budget{
+				limit:       maxMemory,
+				collections: db.list,
+			}
+		}
+		if !util.IsNil(opt.Profile) {
+			db.profiler.configure(opt.Profile)
+		}
+		db.chain.use(opt.Interceptors...)
 	}
+	return db
 }
 
 func (db *Database) Name() string {
@@ -27,27 +101,438 @@ func (db *Database) Name() string {
 	return db.name
 }
 
-func (db *Database) Collection(name string) *Collection {
-	db.lock.Lock()
-	defer db.lock.Unlock()
+func (db *Database) Stats() (DatabaseStats, error) {
+	return db.StatsContext(context.Background())
+}
 
-	if coll, ok := db.collections[name]; ok {
-		return coll
+func (db *Database) StatsContext(ctx context.Context) (DatabaseStats, error) {
+	stats := DatabaseStats{
+		Name:        db.Name(),
+		Collections: map[string]CollectionStats{},
+	}
+	for _, coll := range db.list() {
+		s, err := coll.StatsContext(ctx)
+		if err != nil {
+			return DatabaseStats{}, err
+		}
+		stats.Collections[s.Name] = s
+		stats.Count += s.Count
+		stats.Size += s.Size
+		stats.Indexes += len(s.Indexes)
 	}
+	return stats, nil
+}
+
+func (db *Database) Use(interceptors ...Interceptor) {
+	db.chain.use(interceptors...)
+}
+
+func (db *Database) SetProfile(opts ...*ProfileOptions) {
+	db.profiler.configure(mergeProfileOptions(opts))
+}
+
+func (db *Database) Memory() MemoryStats {
+	if db.budget == nil {
+		return MemoryStats{}
+	}
+	return db.budget.stats()
+}
+
+func (db *Database) Watch(listener func(event DatabaseEvent, val any)) int {
+	db.listenersLock.Lock()
+	defer db.listenersLock.Unlock()
+
+	id := 1
+	for {
+		if _, ok := db.listeners[id]; ok {
+			id += 1
+		} else {
+			break
+		}
+	}
+	db.listeners[id] = listener
+
+	return id
+}
+
+func (db *Database) Unwatch(listenerID int) {
+	db.listenersLock.Lock()
+	defer db.listenersLock.Unlock()
+
+	delete(db.listeners, listenerID)
+}
+
+func (db *Database) Collection(name string, opts ...*CollectionOptions) *Collection {
+	coll, created := func() (*Collection, bool) {
+		db.lock.Lock()
+		defer db.lock.Unlock()
+
+		if coll, ok := db.collections[name]; ok {
+			return coll, false
+		}
+		if _, ok := db.views[name]; ok {
+			coll := newCollection(name)
+			coll.err = errors.Wrap(ErrCollectionExists, name)
+			return coll, false
+		}
+
+		coll, err := db.newCollection(name, opts...)
+		if err != nil {
+			return coll, false
+		}
+		db.collections[name] = coll
 
-	coll := newCollection(name)
-	db.collections[name] = coll
+		return coll, true
+	}()
 
+	if created {
+		db.emit(EventCollectionCreate, name)
+	}
 	return coll
 }
 
+func (db *Database) CreateCollection(name string, opts ...*CollectionOptions) (*Collection, error) {
+	coll, err := func() (*Collection, error) {
+		db.lock.Lock()
+		defer db.lock.Unlock()
+
+		if _, ok := db.collections[name]; ok {
+			return nil, ErrCollectionExists
+		}
+		if _, ok := db.views[name]; ok {
+			return nil, ErrCollectionExists
+		}
+
+		coll, err := db.newCollection(name, opts...)
+		if err != nil {
+			return nil, err
+		}
+		db.collections[name] = coll
+
+		return coll, nil
+	}()
+	if err != nil {
+		return nil, err
+	}
+
+	db.emit(EventCollectionCreate, name)
+	return coll, nil
+}
+
+func (db *Database) ListCollections() []string {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	names := make([]string, 0, len(db.collections))
+	for name := range db.collections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func (db *Database) HasCollection(name string) bool {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	_, ok := db.collections[name]
+	return ok
+}
+
+func (db *Database) DropCollection(name string) error {
+	var m *materializer
+	materialized := map[string]*materializer{}
+	coll, err := func() (*Collection, error) {
+		db.lock.Lock()
+		defer db.lock.Unlock()
+
+		coll, ok := db.collections[name]
+		if !ok {
+			return nil, ErrCollectionNotFound
+		}
+		delete(db.collections, name)
+
+		for n, v := range db.views {
+			if v.Source() == name {
+				delete(db.views, n)
+			}
+		}
+
+		m = db.materialized[name]
+		delete(db.materialized, name)
+
+		for n, m := range db.materialized {
+			materialized[n] = m
+		}
+
+		return coll, nil
+	}()
+	if err != nil {
+		return err
+	}
+
+	if m != nil {
+		m.stop()
+	}
+	for n, m := range materialized {
+		if !m.reads(coll) {
+			continue
+		}
+		m.stop()
+
+		func() {
+			db.lock.Lock()
+			defer db.lock.Unlock()
+
+			if db.materialized[n] == m {
+				delete(db.materialized, n)
+			}
+		}()
+	}
+
+	coll.Drop()
+	db.emit(EventCollectionDrop, name)
+
+	return nil
+}
+
+func (db *Database) RenameCollection(from, to string) error {
+	if err := func() error {
+		db.lock.Lock()
+		defer db.lock.Unlock()
+
+		coll, ok := db.collections[from]
+		if !ok {
+			return ErrCollectionNotFound
+		}
+		if _, ok := db.collections[to]; ok {
+			return ErrCollectionExists
+		}
+		if _, ok := db.views[to]; ok {
+			return ErrCollectionExists
+		}
+
+		delete(db.collections, from)
+		db.collections[to] = coll
+		coll.rename(to)
+
+		if m, ok := db.materialized[from]; ok {
+			delete(db.materialized, from)
+			db.materialized[to] = m
+		}
+
+		for _, v := range db.views {
+			if v.Source() == from {
+				v.rename(to)
+			}
+		}
+
+		return nil
+	}(); err != nil {
+		return err
+	}
+
+	db.emit(EventCollectionRename, CollectionRename{From: from, To: to})
+	return nil
+}
+
 func (db *Database) Drop() {
-	db.lock.Lock()
-	defer db.lock.Unlock()
+	var materialized map[string]*materializer
+	func() {
+		db.lock.Lock()
+		defer db.lock.Unlock()
+
+		materialized = db.materialized
+		db.materialized = map[string]*materializer{}
+	}()
+	for _, m := range materialized {
+		m.stop()
+	}
+
+	var names []string
+	func() {
+		db.lock.Lock()
+		defer db.lock.Unlock()
+
+		for name, coll := range db.collections {
+			coll.Drop()
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		db.collections = map[string]*Collection{}
+		db.views = map[string]*View{}
+	}()
+
+	for _, name := range names {
+		db.emit(EventCollectionDrop, name)
+	}
+}
+
+func (db *Database) newCollection(name string, opts ...*CollectionOptions) (*Collection, error) {
+	opts = append([]*CollectionOptions{{
+		EvictionPolicy: util.Ptr(db.policy),
+		Metrics:        db.metrics,
+	}}, opts...)
+
+	coll := newCollection(name, opts...)
+	coll.db = db
+	if name != ProfileCollection {
+		coll.profiler = db.profiler
+		coll.chain = db.chain
+	}
+	if coll.err != nil || db.budget == nil {
+		return coll, coll.err
+	}
+	if coll.memory == nil {
+		opt := mergeCollectionOptions(opts)
+		coll.memory = newMemory(0, util.UnPtr(opt.EvictionPolicy), util.UnPtr(opt.TTLKey))
+	}
+	coll.budget = db.budget
+	return coll, nil
+}
 
+func (db *Database) get(name string) (*Collection, bool) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	coll, ok := db.collections[name]
+	return coll, ok
+}
+
+func (db *Database) list() []*Collection {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	colls := make([]*Collection, 0, len(db.collections))
 	for _, coll := range db.collections {
+		colls = append(colls, coll)
+	}
+	return colls
+}
+
+func (db *Database) restore(collections map[string]*Collection) {
+	replaced := map[*Collection]*Collection{}
+	var dropped []*Collection
+	var created []string
+	var views []*View
+	var materialized []*materializer
+	func() {
+		db.lock.Lock()
+		defer db.lock.Unlock()
+
+		for name, coll := range db.collections {
+			if next, ok := collections[name]; ok {
+				next.inherit(coll)
+				replaced[coll] = next
+			} else {
+				dropped = append(dropped, coll)
+			}
+		}
+		for name := range collections {
+			if _, ok := db.collections[name]; !ok {
+				created = append(created, name)
+			}
+		}
+		db.collections = collections
+
+		for name, v := range db.views {
+			if _, ok := collections[v.Source()]; !ok {
+				delete(db.views, name)
+				continue
+			}
+			views = append(views, v)
+		}
+		for _, m := range db.materialized {
+			materialized = append(materialized, m)
+		}
+	}()
+
+	for _, v := range views {
+		v.rebind(replaced)
+	}
+	for _, m := range materialized {
+		if m.rebind(replaced) {
+			continue
+		}
+		m.stop()
+
+		func() {
+			db.lock.Lock()
+			defer db.lock.Unlock()
+
+			for name, curr := range db.materialized {
+				if curr == m {
+					delete(db.materialized, name)
+				}
+			}
+		}()
+	}
+
+	sort.Strings(created)
+	for _, name := range created {
+		db.emit(EventCollectionCreate, name)
+	}
+	for _, coll := range dropped {
 		coll.Drop()
+		db.emit(EventCollectionDrop, coll.Name())
 	}
+}
+
+func (db *Database) emit(event DatabaseEvent, val any) {
+	db.listenersLock.RLock()
+	defer db.listenersLock.RUnlock()
 
-	db.collections = map[string]*Collection{}
+	for _, lt := range db.listeners {
+		lt(event, val)
+	}
+}
+
+func mergeDatabaseOptions(options []*DatabaseOptions) *DatabaseOptions {
+	if len(options) == 0 {
+		return nil
+	}
+	opt := &DatabaseOptions{}
+	for _, curr := range options {
+		if util.IsNil(curr) {
+			continue
+		}
+		if !util.IsNil(curr.MaxMemory) {
+			opt.MaxMemory = curr.MaxMemory
+		}
+		if !util.IsNil(curr.EvictionPolicy) {
+			opt.EvictionPolicy = curr.EvictionPolicy
+		}
+		if !util.IsNil(curr.Metrics) {
+			opt.Metrics = curr.Metrics
+		}
+		if !util.IsNil(curr.Profile) {
+			opt.Profile = curr.Profile
+		}
+		opt.Interceptors = append(opt.Interceptors, curr.Interceptors...)
+	}
+	return opt
+}
+
+func mergeProfileOptions(options []*ProfileOptions) *ProfileOptions {
+	if len(options) == 0 {
+		return nil
+	}
+	opt := &ProfileOptions{}
+	for _, curr := range options {
+		if util.IsNil(curr) {
+			continue
+		}
+		if !util.IsNil(curr.Threshold) {
+			opt.Threshold = curr.Threshold
+		}
+		if !util.IsNil(curr.SampleRate) {
+			opt.SampleRate = curr.SampleRate
+		}
+		if !util.IsNil(curr.MaxEntries) {
+			opt.MaxEntries = curr.MaxEntries
+		}
+	}
+	return opt
 }
@@ -2,6 +2,7 @@ package memdb
 
 import (
 	"github.com/go-faker/faker/v4"
+	"github.com/siyul-park/memdb/internal/util"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -20,6 +21,186 @@ func TestDatabase_Collection(t *testing.T) {
 	assert.NotNil(t, coll)
 }
 
+func TestDatabase_CreateCollection(t *testing.T) {
+	db := New(faker.Word())
+
+	name := faker.UUIDHyphenated()
+
+	coll, err := db.CreateCollection(name, &CollectionOptions{
+		Validator: &Schema{Required: []string{"name"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, coll, db.Collection(name))
+
+	_, err = coll.InsertOne(map[string]any{"id": faker.UUIDHyphenated()})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	_, err = db.CreateCollection(name)
+	assert.ErrorIs(t, err, ErrCollectionExists)
+
+	_, err = db.CreateCollection(faker.UUIDHyphenated(), &CollectionOptions{
+		Validator: &Schema{Pattern: "("},
+	})
+	assert.ErrorIs(t, err, ErrInvalidSchema)
+}
+
+func TestDatabase_ListCollections(t *testing.T) {
+	db := New(faker.Word())
+
+	db.Collection("b")
+	db.Collection("a")
+
+	assert.Equal(t, []string{"a", "b"}, db.ListCollections())
+}
+
+func TestDatabase_HasCollection(t *testing.T) {
+	db := New(faker.Word())
+
+	name := faker.UUIDHyphenated()
+	assert.False(t, db.HasCollection(name))
+
+	db.Collection(name)
+	assert.True(t, db.HasCollection(name))
+}
+
+func TestDatabase_DropCollection(t *testing.T) {
+	db := New(faker.Word())
+
+	name := faker.UUIDHyphenated()
+	coll := db.Collection(name)
+
+	_, err := coll.InsertOne(map[string]any{"id": faker.UUIDHyphenated()})
+	assert.NoError(t, err)
+
+	err = db.DropCollection(name)
+	assert.NoError(t, err)
+	assert.False(t, db.HasCollection(name))
+
+	docs, err := coll.FindMany(nil)
+	assert.NoError(t, err)
+	assert.Len(t, docs, 0)
+
+	err = db.DropCollection(name)
+	assert.ErrorIs(t, err, ErrCollectionNotFound)
+}
+
+func TestDatabase_RenameCollection(t *testing.T) {
+	db := New(faker.Word())
+
+	from := faker.UUIDHyphenated()
+	to := faker.UUIDHyphenated()
+
+	coll := db.Collection(from)
+
+	doc := map[string]any{"id": faker.UUIDHyphenated()}
+	_, err := coll.InsertOne(doc)
+	assert.NoError(t, err)
+
+	err = db.RenameCollection(from, to)
+	assert.NoError(t, err)
+	assert.False(t, db.HasCollection(from))
+	assert.Equal(t, coll, db.Collection(to))
+	assert.Equal(t, to, coll.Name())
+
+	r, err := db.Collection(to).FindOne(Where("id").EQ(doc["id"]))
+	assert.NoError(t, err)
+	assert.Equal(t, doc, r)
+
+	err = db.RenameCollection(from, to)
+	assert.ErrorIs(t, err, ErrCollectionNotFound)
+
+	db.Collection(from)
+
+	err = db.RenameCollection(from, to)
+	assert.ErrorIs(t, err, ErrCollectionExists)
+}
+
+func TestDatabase_Stats(t *testing.T) {
+	db := New(faker.Word())
+
+	c1 := db.Collection(faker.UUIDHyphenated())
+	c2 := db.Collection(faker.UUIDHyphenated())
+
+	_, err := c1.InsertMany([]map[string]any{{"id": 1}, {"id": 2}})
+	assert.NoError(t, err)
+	_, err = c2.InsertOne(map[string]any{"id": 1})
+	assert.NoError(t, err)
+
+	stats, err := db.Stats()
+	assert.NoError(t, err)
+	assert.Equal(t, db.Name(), stats.Name)
+	assert.Len(t, stats.Collections, 2)
+	assert.Equal(t, 3, stats.Count)
+	assert.Equal(t, 2, stats.Indexes)
+	assert.Equal(t, 2, stats.Collections[c1.Name()].Count)
+}
+
+func TestDatabase_Memory(t *testing.T) {
+	db := New(faker.Word(), &DatabaseOptions{
+		MaxMemory:      util.Ptr(100),
+		EvictionPolicy: util.Ptr(EvictionLRU),
+	})
+
+	c1 := db.Collection(faker.UUIDHyphenated())
+	c2 := db.Collection(faker.UUIDHyphenated())
+
+	for i := 0; i < 3; i++ {
+		_, err := c1.InsertOne(map[string]any{"id": i, "data": "0123456789"})
+		assert.NoError(t, err)
+	}
+	for i := 0; i < 2; i++ {
+		_, err := c2.InsertOne(map[string]any{"id": i, "data": "0123456789"})
+		assert.NoError(t, err)
+	}
+
+	r, err := c1.FindOne(Where("id").EQ(0))
+	assert.NoError(t, err)
+	assert.Nil(t, r)
+
+	stats := db.Memory()
+	assert.Equal(t, 96, stats.Size)
+	assert.Equal(t, 100, stats.Limit)
+	assert.Equal(t, 1, stats.Evictions)
+}
+
+func TestDatabase_Watch(t *testing.T) {
+	db := New(faker.Word())
+
+	var events []DatabaseEvent
+	var values []any
+	db.Watch(func(event DatabaseEvent, val any) {
+		events = append(events, event)
+		values = append(values, val)
+	})
+
+	_, err := db.CreateCollection("a")
+	assert.NoError(t, err)
+
+	db.Collection("a")
+
+	err = db.RenameCollection("a", "b")
+	assert.NoError(t, err)
+
+	err = db.DropCollection("b")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []DatabaseEvent{EventCollectionCreate, EventCollectionRename, EventCollectionDrop}, events)
+	assert.Equal(t, []any{"a", CollectionRename{From: "a", To: "b"}, "b"}, values)
+}
+
+func TestDatabase_Unwatch(t *testing.T) {
+	db := New(faker.Word())
+
+	count := 0
+	id := db.Watch(func(_ DatabaseEvent, _ any) {
+		count += 1
+	})
+	db.Unwatch(id)
+
+	db.Collection(faker.UUIDHyphenated())
+	assert.Equal(t, 0, count)
+}
+
 func TestDatabase_Drop(t *testing.T) {
 	db := New(faker.Word())
 
@@ -0,0 +1,115 @@
+package memdb
+
+import (
+	"encoding"
+	"github.com/pkg/errors"
+	"github.com/siyul-park/memdb/internal/util/reflectutil"
+	"math/big"
+	"strings"
+)
+
+type (
+	Decimal struct {
+		rat *big.Rat
+	}
+)
+
+const (
+	maxDecimalScale = 1024
+)
+
+var (
+	ErrCodeInvalidDecimal = "invalid_decimal"
+
+	ErrInvalidDecimal = errors.New(ErrCodeInvalidDecimal)
+)
+
+var _ reflectutil.Rational = Decimal{}
+var _ encoding.TextMarshaler = Decimal{}
+var _ encoding.TextUnmarshaler = (*Decimal)(nil)
+
+func NewDecimal(unscaled int64, scale int32) Decimal {
+	rat := new(big.Rat).SetInt64(unscaled)
+	if scale >= 0 {
+		exp := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)
+		rat.Quo(rat, new(big.Rat).SetInt(exp))
+	} else {
+		exp := new(big.Int).Exp(big.NewInt(10), big.NewInt(-int64(scale)), nil)
+		rat.Mul(rat, new(big.Rat).SetInt(exp))
+	}
+	return Decimal{rat: rat}
+}
+
+func ParseDecimal(value string) (Decimal, error) {
+	if strings.Contains(value, "/") {
+		return Decimal{}, errors.Wrap(ErrInvalidDecimal, value)
+	}
+	rat, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return Decimal{}, errors.Wrap(ErrInvalidDecimal, value)
+	}
+	return Decimal{rat: rat}, nil
+}
+
+func MustParseDecimal(value string) Decimal {
+	d, err := ParseDecimal(value)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func (d Decimal) Rat() *big.Rat {
+	if d.rat == nil {
+		return new(big.Rat)
+	}
+	return new(big.Rat).Set(d.rat)
+}
+
+func (d Decimal) Cmp(other Decimal) int {
+	return d.Rat().Cmp(other.Rat())
+}
+
+func (d Decimal) Float64() float64 {
+	f, _ := d.Rat().Float64()
+	return f
+}
+
+func (d Decimal) String() string {
+	rat := d.Rat()
+	if rat.IsInt() {
+		return rat.Num().String()
+	}
+
+	scale := 0
+	for r := new(big.Rat).Set(rat); !r.IsInt() && scale < maxDecimalScale; scale++ {
+		r.Mul(r, big.NewRat(10, 1))
+	}
+	return rat.FloatString(scale)
+}
+
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Decimal) UnmarshalText(text []byte) error {
+	parsed, err := ParseDecimal(string(text))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+func (d Decimal) GobEncode() ([]byte, error) {
+	return d.Rat().GobEncode()
+}
+
+func (d *Decimal) GobDecode(data []byte) error {
+	rat := new(big.Rat)
+	if err := rat.GobDecode(data); err != nil {
+		return err
+	}
+	d.rat = rat
+	return nil
+}
@@ -0,0 +1,96 @@
+package memdb
+
+import (
+	"encoding/json"
+	"github.com/go-faker/faker/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseDecimal(t *testing.T) {
+	d, err := ParseDecimal("12.50")
+	assert.NoError(t, err)
+	assert.Equal(t, "12.5", d.String())
+	assert.Equal(t, 0, d.Cmp(NewDecimal(1250, 2)))
+
+	d, err = ParseDecimal("1e3")
+	assert.NoError(t, err)
+	assert.Equal(t, "1000", d.String())
+	assert.Equal(t, 0, d.Cmp(NewDecimal(1, -3)))
+
+	_, err = ParseDecimal("1/3")
+	assert.ErrorIs(t, err, ErrInvalidDecimal)
+
+	_, err = ParseDecimal("abc")
+	assert.ErrorIs(t, err, ErrInvalidDecimal)
+}
+
+func TestDecimal_MarshalText(t *testing.T) {
+	d := NewDecimal(-314, 2)
+
+	b, err := json.Marshal(d)
+	assert.NoError(t, err)
+	assert.Equal(t, `"-3.14"`, string(b))
+
+	var decoded Decimal
+	err = json.Unmarshal(b, &decoded)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, d.Cmp(decoded))
+}
+
+func TestCollection_RichValues(t *testing.T) {
+	coll := newCollection(faker.Name())
+
+	err := coll.Indexes().Create(IndexModel{Name: "price", Keys: []string{"price"}})
+	assert.NoError(t, err)
+	err = coll.Indexes().Create(IndexModel{Name: "createdAt", Keys: []string{"createdAt"}})
+	assert.NoError(t, err)
+	err = coll.Indexes().Create(IndexModel{Name: "hash", Keys: []string{"hash"}, Unique: true})
+	assert.NoError(t, err)
+
+	now := time.Now()
+	_, err = coll.InsertMany([]map[string]any{
+		{"id": 1, "price": MustParseDecimal("9.99"), "createdAt": now, "hash": []byte{1}, "ttl": time.Minute},
+		{"id": 2, "price": MustParseDecimal("10"), "createdAt": now.Add(time.Hour), "hash": []byte{2}, "ttl": time.Second},
+		{"id": 3, "price": 12.5, "createdAt": now.Add(-time.Hour), "hash": []byte{3}, "ttl": time.Hour},
+	})
+	assert.NoError(t, err)
+
+	_, err = coll.InsertOne(map[string]any{"id": 4, "hash": []byte{1}})
+	assert.ErrorIs(t, err, ErrIndexConflict)
+
+	docs, err := coll.FindMany(Where("createdAt").LT(now))
+	assert.NoError(t, err)
+	assert.Equal(t, []any{3}, ids(docs))
+
+	docs, err = coll.FindMany(Where("createdAt").EQ(now.In(time.FixedZone("KST", 9*60*60))))
+	assert.NoError(t, err)
+	assert.Equal(t, []any{1}, ids(docs))
+
+	docs, err = coll.FindMany(Where("price").EQ(10))
+	assert.NoError(t, err)
+	assert.Equal(t, []any{2}, ids(docs))
+
+	docs, err = coll.FindMany(Where("price").GT(MustParseDecimal("9.995")), &FindOptions{
+		Sorts: []Sort{{Key: "price", Order: OrderDESC}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []any{3, 2}, ids(docs))
+
+	docs, err = coll.FindMany(Where("hash").EQ([]byte{2}))
+	assert.NoError(t, err)
+	assert.Equal(t, []any{2}, ids(docs))
+
+	docs, err = coll.FindMany(nil, &FindOptions{
+		Sorts: []Sort{{Key: "ttl", Order: OrderASC}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []any{2, 1, 3}, ids(docs))
+
+	docs, err = coll.FindMany(nil, &FindOptions{
+		Sorts: []Sort{{Key: "createdAt", Order: OrderASC}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []any{3, 1, 2}, ids(docs))
+}
@@ -0,0 +1,339 @@
+package memdb
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/gob"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/siyul-park/memdb/internal/util"
+	"github.com/siyul-park/memdb/internal/util/reflectutil"
+	"reflect"
+	"strings"
+	"time"
+)
+
+type (
+	KeyProvider interface {
+		Key() ([]byte, error)
+	}
+
+	KeyProviderFunc func() ([]byte, error)
+
+	EncryptedField struct {
+		Path          string
+		Deterministic bool
+	}
+
+	EncryptionOptions struct {
+		KeyProvider KeyProvider
+		Fields      []EncryptedField
+	}
+
+	encryptor struct {
+		provider KeyProvider
+		fields   []EncryptedField
+	}
+
+	sealed struct {
+		V any
+	}
+
+	ciphertext struct {
+		token string
+		data  string
+	}
+)
+
+var (
+	ErrCodeEncryption     = "encryption"
+	ErrCodeEncryptedField = "encrypted_field"
+
+	ErrEncryption     = errors.New(ErrCodeEncryption)
+	ErrEncryptedField = errors.New(ErrCodeEncryptedField)
+)
+
+var _ KeyProvider = KeyProviderFunc(nil)
+
+func init() {
+	gob.Register(map[string]any{})
+	gob.Register([]any{})
+	gob.Register(time.Time{})
+	gob.Register(Decimal{})
+
+	_ = reflectutil.Register(reflect.TypeOf(ciphertext{}), reflectutil.Codec{
+		Compare: func(x, y any) int {
+			return strings.Compare(x.(ciphertext).key(), y.(ciphertext).key())
+		},
+		Key: func(value any) any {
+			return value.(ciphertext).key()
+		},
+	})
+}
+
+func NewStaticKeyProvider(key []byte) KeyProvider {
+	key = append([]byte(nil), key...)
+	return KeyProviderFunc(func() ([]byte, error) {
+		return key, nil
+	})
+}
+
+func (f KeyProviderFunc) Key() ([]byte, error) {
+	return f()
+}
+
+func newEncryptor(opt *EncryptionOptions) *encryptor {
+	if util.IsNil(opt) || len(opt.Fields) == 0 {
+		return nil
+	}
+	return &encryptor{
+		provider: opt.KeyProvider,
+		fields:   opt.Fields,
+	}
+}
+
+func (opt *EncryptionOptions) compile(pk primaryKey) error {
+	if util.IsNil(opt) || len(opt.Fields) == 0 {
+		return nil
+	}
+	if util.IsNil(opt.KeyProvider) {
+		return errors.Wrap(ErrEncryption, "key provider is required")
+	}
+	if len(pk) == 0 {
+		pk = primaryKey{keyID}
+	}
+	for _, field := range opt.Fields {
+		for _, k := range pk {
+			if field.Path == k || strings.HasPrefix(k, field.Path+".") {
+				return errors.Wrapf(ErrEncryptedField, "primary key %s cannot be encrypted", k)
+			}
+		}
+	}
+	return nil
+}
+
+func (e *encryptor) encrypt(document map[string]any) (map[string]any, error) {
+	if e == nil || document == nil {
+		return document, nil
+	}
+
+	doc := util.Copy(document)
+	for _, field := range e.fields {
+		v, ok := reflectutil.Get[any](doc, field.Path)
+		if !ok || util.IsNil(v) {
+			continue
+		}
+		if _, ok := v.(ciphertext); ok {
+			continue
+		}
+		c, err := e.seal(v, field.Deterministic)
+		if err != nil {
+			return nil, err
+		}
+		reflectutil.Set(doc, field.Path, c)
+	}
+	return doc, nil
+}
+
+func (e *encryptor) decrypt(document map[string]any) (map[string]any, error) {
+	if e == nil || document == nil {
+		return document, nil
+	}
+
+	doc := util.Copy(document)
+	for _, field := range e.fields {
+		v, ok := reflectutil.Get[ciphertext](doc, field.Path)
+		if !ok {
+			continue
+		}
+		p, err := e.open(v)
+		if err != nil {
+			return nil, err
+		}
+		reflectutil.Set(doc, field.Path, p)
+	}
+	return doc, nil
+}
+
+func (e *encryptor) filter(filter *Filter) (*Filter, error) {
+	if e == nil || util.IsNil(filter) {
+		return filter, nil
+	}
+
+	switch filter.OP {
+	case AND, OR:
+		children, _ := filter.Value.([]*Filter)
+		var next []*Filter
+		for _, child := range children {
+			c, err := e.filter(child)
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, c)
+		}
+		return &Filter{OP: filter.OP, Value: next}, nil
+	}
+
+	field, ok := e.field(filter.Key)
+	if !ok {
+		if e.covers(filter.Key) && filter.OP != NULL && filter.OP != NNULL {
+			return nil, errors.Wrapf(ErrEncryptedField, "%s contains encrypted fields", filter.Key)
+		}
+		return filter, nil
+	}
+
+	switch filter.OP {
+	case NULL, NNULL:
+		return filter, nil
+	case EQ, NE:
+		if !field.Deterministic {
+			return nil, errors.Wrapf(ErrEncryptedField, "%s is not deterministically encrypted", filter.Key)
+		}
+		c, err := e.seal(filter.Value, true)
+		if err != nil {
+			return nil, err
+		}
+		return &Filter{OP: filter.OP, Key: filter.Key, Value: c}, nil
+	case IN, NIN:
+		if !field.Deterministic {
+			return nil, errors.Wrapf(ErrEncryptedField, "%s is not deterministically encrypted", filter.Key)
+		}
+		values, ok := toArray(filter.Value)
+		if !ok {
+			return filter, nil
+		}
+		var sealed []any
+		for _, v := range values {
+			c, err := e.seal(v, true)
+			if err != nil {
+				return nil, err
+			}
+			sealed = append(sealed, c)
+		}
+		return &Filter{OP: filter.OP, Key: filter.Key, Value: sealed}, nil
+	}
+	return nil, errors.Wrapf(ErrEncryptedField, "%s does not support range filters", filter.Key)
+}
+
+func (e *encryptor) sort(sorts []Sort) error {
+	if e == nil {
+		return nil
+	}
+	for _, s := range sorts {
+		if e.covers(s.Key) {
+			return errors.Wrapf(ErrEncryptedField, "%s cannot be sorted", s.Key)
+		}
+	}
+	return nil
+}
+
+func (e *encryptor) field(path string) (EncryptedField, bool) {
+	for _, field := range e.fields {
+		if field.Path == path {
+			return field, true
+		}
+	}
+	return EncryptedField{}, false
+}
+
+func (e *encryptor) covers(path string) bool {
+	for _, field := range e.fields {
+		if field.Path == path || strings.HasPrefix(path, field.Path+".") || strings.HasPrefix(field.Path, path+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *encryptor) seal(value any, deterministic bool) (ciphertext, error) {
+	aead, mac, err := e.keys()
+	if err != nil {
+		return ciphertext{}, err
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(sealed{V: value}); err != nil {
+		return ciphertext{}, errors.Wrap(ErrEncryption, err.Error())
+	}
+	plaintext := buf.Bytes()
+
+	var token string
+	nonce := make([]byte, aead.NonceSize())
+	if deterministic {
+		k := reflectutil.Key(value)
+		h := hmac.New(sha256.New, mac)
+		h.Write([]byte(fmt.Sprintf("%T:%#v", k, k)))
+		token = base64.RawStdEncoding.EncodeToString(h.Sum(nil))
+
+		h = hmac.New(sha256.New, mac)
+		h.Write([]byte(token))
+		h.Write(plaintext)
+		copy(nonce, h.Sum(nil))
+	} else if _, err := rand.Read(nonce); err != nil {
+		return ciphertext{}, errors.Wrap(ErrEncryption, err.Error())
+	}
+
+	data := aead.Seal(nonce, nonce, plaintext, nil)
+	return ciphertext{token: token, data: base64.RawStdEncoding.EncodeToString(data)}, nil
+}
+
+func (e *encryptor) open(value ciphertext) (any, error) {
+	aead, _, err := e.keys()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := base64.RawStdEncoding.DecodeString(value.data)
+	if err != nil || len(data) < aead.NonceSize() {
+		return nil, errors.Wrap(ErrEncryption, "malformed ciphertext")
+	}
+
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.Wrap(ErrEncryption, err.Error())
+	}
+
+	var s sealed
+	if err := gob.NewDecoder(bytes.NewReader(plaintext)).Decode(&s); err != nil {
+		return nil, errors.Wrap(ErrEncryption, err.Error())
+	}
+	return s.V, nil
+}
+
+func (e *encryptor) keys() (cipher.AEAD, []byte, error) {
+	if util.IsNil(e.provider) {
+		return nil, nil, errors.Wrap(ErrEncryption, "key provider is required")
+	}
+	key, err := e.provider.Key()
+	if err != nil {
+		return nil, nil, errors.Wrap(ErrEncryption, err.Error())
+	}
+
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte("enc"))
+	block, err := aes.NewCipher(h.Sum(nil))
+	if err != nil {
+		return nil, nil, errors.Wrap(ErrEncryption, err.Error())
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, errors.Wrap(ErrEncryption, err.Error())
+	}
+
+	h = hmac.New(sha256.New, key)
+	h.Write([]byte("mac"))
+
+	return aead, h.Sum(nil), nil
+}
+
+func (c ciphertext) key() string {
+	if c.token != "" {
+		return c.token
+	}
+	return c.data
+}
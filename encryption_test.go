@@ -0,0 +1,152 @@
+package memdb
+
+import (
+	"context"
+	"github.com/go-faker/faker/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCollection_Encryption(t *testing.T) {
+	coll := newCollection(faker.Name(), &CollectionOptions{
+		Encryption: &EncryptionOptions{
+			KeyProvider: NewStaticKeyProvider([]byte(faker.Password())),
+			Fields: []EncryptedField{
+				{Path: "email", Deterministic: true},
+				{Path: "profile.ssn"},
+			},
+		},
+	})
+
+	err := coll.Indexes().Create(IndexModel{Keys: []string{"email"}, Name: "email", Unique: true})
+	assert.NoError(t, err)
+
+	_, err = coll.InsertMany([]map[string]any{
+		{"id": 1, "email": "a@example.com", "profile": map[string]any{"ssn": "000-00-0001", "age": 20}},
+		{"id": 2, "email": "b@example.com", "profile": map[string]any{"ssn": "000-00-0002", "age": 30}},
+	})
+	assert.NoError(t, err)
+
+	stored, err := coll.findOne(context.Background(), Where("id").EQ(1))
+	assert.NoError(t, err)
+	assert.IsType(t, ciphertext{}, stored["email"])
+	assert.IsType(t, ciphertext{}, stored["profile"].(map[string]any)["ssn"])
+
+	doc, err := coll.FindOne(Where("email").EQ("a@example.com"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, doc["id"])
+	assert.Equal(t, "a@example.com", doc["email"])
+	assert.Equal(t, "000-00-0001", doc["profile"].(map[string]any)["ssn"])
+	assert.Equal(t, 20, doc["profile"].(map[string]any)["age"])
+
+	docs, err := coll.FindMany(Where("email").IN("a@example.com", "b@example.com"))
+	assert.NoError(t, err)
+	assert.Len(t, docs, 2)
+
+	_, err = coll.FindMany(Where("email").GT("a@example.com"))
+	assert.ErrorIs(t, err, ErrEncryptedField)
+
+	_, err = coll.FindMany(Where("profile.ssn").EQ("000-00-0001"))
+	assert.ErrorIs(t, err, ErrEncryptedField)
+
+	_, err = coll.FindMany(Where("profile").EQ(map[string]any{}))
+	assert.ErrorIs(t, err, ErrEncryptedField)
+
+	_, err = coll.FindMany(nil, &FindOptions{Sorts: []Sort{{Key: "email", Order: OrderASC}}})
+	assert.ErrorIs(t, err, ErrEncryptedField)
+
+	_, err = coll.InsertOne(map[string]any{"id": 3, "email": "a@example.com"})
+	assert.ErrorIs(t, err, ErrIndexConflict)
+
+	ok, err := coll.UpdateOne(Where("email").EQ("b@example.com"), map[string]any{"email": "c@example.com"})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	doc, err = coll.FindOne(Where("id").EQ(2))
+	assert.NoError(t, err)
+	assert.Equal(t, "c@example.com", doc["email"])
+
+	ok, err = coll.DeleteOne(Where("email").EQ("c@example.com"))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestDatabase_CreateCollection_Encryption(t *testing.T) {
+	db := New(faker.Name())
+
+	_, err := db.CreateCollection(faker.Name(), &CollectionOptions{
+		Encryption: &EncryptionOptions{
+			KeyProvider: NewStaticKeyProvider([]byte(faker.Password())),
+			Fields:      []EncryptedField{{Path: "id", Deterministic: true}},
+		},
+	})
+	assert.ErrorIs(t, err, ErrEncryptedField)
+
+	_, err = db.CreateCollection(faker.Name(), &CollectionOptions{
+		Encryption: &EncryptionOptions{
+			Fields: []EncryptedField{{Path: "email"}},
+		},
+	})
+	assert.ErrorIs(t, err, ErrEncryption)
+}
+
+func TestCollection_Encryption_Untrusted(t *testing.T) {
+	coll := newCollection(faker.Name(), &CollectionOptions{
+		Encryption: &EncryptionOptions{
+			KeyProvider: NewStaticKeyProvider([]byte(faker.Password())),
+			Fields:      []EncryptedField{{Path: "secret"}},
+		},
+	})
+
+	_, err := coll.InsertOne(map[string]any{"id": 1, "secret": "enc:v1:AAAA"})
+	assert.NoError(t, err)
+
+	stored, err := coll.findOne(context.Background(), Where("id").EQ(1))
+	assert.NoError(t, err)
+	assert.IsType(t, ciphertext{}, stored["secret"])
+
+	doc, err := coll.FindOne(Where("id").EQ(1))
+	assert.NoError(t, err)
+	assert.Equal(t, "enc:v1:AAAA", doc["secret"])
+}
+
+func TestCollection_Encryption_Deterministic(t *testing.T) {
+	coll := newCollection(faker.Name(), &CollectionOptions{
+		Encryption: &EncryptionOptions{
+			KeyProvider: NewStaticKeyProvider([]byte(faker.Password())),
+			Fields:      []EncryptedField{{Path: "code", Deterministic: true}},
+		},
+	})
+
+	_, err := coll.InsertMany([]map[string]any{
+		{"id": 1, "code": 7},
+		{"id": 2, "code": map[string]any{"a": 1, "b": 2, "c": 3}},
+	})
+	assert.NoError(t, err)
+
+	doc, err := coll.FindOne(Where("code").EQ(7.0))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, doc["id"])
+
+	doc, err = coll.FindOne(Where("code").EQ(map[string]any{"c": 3, "b": 2, "a": 1}))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, doc["id"])
+}
+
+func TestDatabase_Collection_Encryption(t *testing.T) {
+	db := New(faker.Name())
+
+	name := faker.Name()
+	coll := db.Collection(name, &CollectionOptions{
+		Encryption: &EncryptionOptions{
+			Fields: []EncryptedField{{Path: "email"}},
+		},
+	})
+	assert.False(t, db.HasCollection(name))
+
+	_, err := coll.InsertOne(map[string]any{"id": 1, "email": "a@example.com"})
+	assert.ErrorIs(t, err, ErrEncryption)
+
+	_, err = coll.FindMany(nil)
+	assert.ErrorIs(t, err, ErrEncryption)
+}
@@ -3,6 +3,7 @@ package memdb
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/siyul-park/memdb/internal/text"
 	"github.com/siyul-park/memdb/internal/util"
 	"github.com/siyul-park/memdb/internal/util/reflectutil"
 	"strings"
@@ -34,6 +35,10 @@ const (
 	NNULL
 	AND
 	OR
+	TEXT
+	NEAR
+	WITHIN
+	INTERSECTS
 )
 
 var (
@@ -50,6 +55,10 @@ var (
 		"IS NOT NULL",
 		"AND",
 		"OR",
+		"TEXT",
+		"NEAR",
+		"WITHIN",
+		"INTERSECTS",
 	}
 )
 
@@ -137,6 +146,54 @@ func (fh *filterHelper) IsNotNull() *Filter {
 	}
 }
 
+func (fh *filterHelper) Text(query string) *Filter {
+	return &Filter{
+		OP:    TEXT,
+		Key:   fh.key,
+		Value: query,
+	}
+}
+
+func (fh *filterHelper) Near(point GeoPoint, maxDistance float64) *Filter {
+	return &Filter{
+		OP:    NEAR,
+		Key:   fh.key,
+		Value: GeoCircle{Center: point, Radius: maxDistance},
+	}
+}
+
+func (fh *filterHelper) WithinRadius(center GeoPoint, radius float64) *Filter {
+	return &Filter{
+		OP:    WITHIN,
+		Key:   fh.key,
+		Value: GeoCircle{Center: center, Radius: radius},
+	}
+}
+
+func (fh *filterHelper) WithinBox(min GeoPoint, max GeoPoint) *Filter {
+	return &Filter{
+		OP:    WITHIN,
+		Key:   fh.key,
+		Value: GeoBox{Min: min, Max: max},
+	}
+}
+
+func (fh *filterHelper) WithinPolygon(polygon GeoPolygon) *Filter {
+	return &Filter{
+		OP:    WITHIN,
+		Key:   fh.key,
+		Value: polygon,
+	}
+}
+
+func (fh *filterHelper) Intersects(geometry any) *Filter {
+	return &Filter{
+		OP:    INTERSECTS,
+		Key:   fh.key,
+		Value: geometry,
+	}
+}
+
 func (ft *Filter) And(x ...*Filter) *Filter {
 	var v []*Filter
 	for _, e := range append([]*Filter{ft}, x...) {
@@ -191,6 +248,10 @@ func (ft *Filter) String() (string, error) {
 }
 
 func parseFilter(filter *Filter) func(map[string]any) bool {
+	return parseFilterWith(filter, nil)
+}
+
+func parseFilterWith(filter *Filter, collation *Collation) func(map[string]any) bool {
 	if util.IsNil(filter) {
 		return func(_ map[string]any) bool {
 			return true
@@ -200,96 +261,99 @@ func parseFilter(filter *Filter) func(map[string]any) bool {
 	switch filter.OP {
 	case EQ:
 		return func(m map[string]any) bool {
-			if v, ok := reflectutil.Get[any](m, filter.Key); !ok {
-				return false
-			} else {
-				return reflectutil.Equal(v, filter.Value)
-			}
+			return containsElement(m, filter.Key, func(v any) bool {
+				return collation.equal(v, filter.Value)
+			})
 		}
 	case NE:
 		return func(m map[string]any) bool {
-			if v, ok := reflectutil.Get[any](m, filter.Key); !ok {
+			if _, ok := resolve(m, filter.Key); !ok {
 				return false
-			} else {
-				return !reflectutil.Equal(v, filter.Value)
 			}
+			return !containsElement(m, filter.Key, func(v any) bool {
+				return collation.equal(v, filter.Value)
+			})
 		}
 	case LT:
 		return func(m map[string]any) bool {
-			if v, ok := reflectutil.Get[any](m, filter.Key); !ok {
-				return false
-			} else {
-				return reflectutil.Compare(v, filter.Value) < 0
-			}
+			return containsScalar(m, filter.Key, func(v any) bool {
+				return collation.compare(v, filter.Value) < 0
+			})
 		}
 	case LTE:
 		return func(m map[string]any) bool {
-			if v, ok := reflectutil.Get[any](m, filter.Key); !ok {
-				return false
-			} else {
-				return reflectutil.Compare(v, filter.Value) <= 0
-			}
+			return containsScalar(m, filter.Key, func(v any) bool {
+				return collation.compare(v, filter.Value) <= 0
+			})
 		}
 	case GT:
 		return func(m map[string]any) bool {
-			if v, ok := reflectutil.Get[any](m, filter.Key); !ok {
-				return false
-			} else {
-				return reflectutil.Compare(v, filter.Value) > 0
-			}
+			return containsScalar(m, filter.Key, func(v any) bool {
+				return collation.compare(v, filter.Value) > 0
+			})
 		}
 	case GTE:
 		return func(m map[string]any) bool {
-			if v, ok := reflectutil.Get[any](m, filter.Key); !ok {
-				return false
-			} else {
-				return reflectutil.Compare(v, filter.Value) >= 0
-			}
+			return containsScalar(m, filter.Key, func(v any) bool {
+				return collation.compare(v, filter.Value) >= 0
+			})
 		}
 	case IN:
 		return func(m map[string]any) bool {
-			if v, ok := reflectutil.Get[any](m, filter.Key); !ok {
-				return false
-			} else if children, ok := filter.Value.([]any); !ok {
+			if children, ok := filter.Value.([]any); !ok {
 				return false
 			} else {
-				for _, child := range children {
-					if reflectutil.Equal(v, child) {
-						return true
+				return containsElement(m, filter.Key, func(v any) bool {
+					for _, child := range children {
+						if collation.equal(v, child) {
+							return true
+						}
 					}
-				}
-				return false
+					return false
+				})
 			}
 		}
 	case NIN:
 		return func(m map[string]any) bool {
-			if v, ok := reflectutil.Get[any](m, filter.Key); !ok {
+			if _, ok := resolve(m, filter.Key); !ok {
 				return false
 			} else if children, ok := filter.Value.([]any); !ok {
 				return false
 			} else {
-				for _, child := range children {
-					if reflectutil.Equal(v, child) {
-						return false
+				return !containsElement(m, filter.Key, func(v any) bool {
+					for _, child := range children {
+						if collation.equal(v, child) {
+							return true
+						}
 					}
-				}
-				return true
+					return false
+				})
 			}
 		}
 	case NULL:
 		return func(m map[string]any) bool {
-			if v, ok := reflectutil.Get[any](m, filter.Key); !ok {
+			if values, ok := resolve(m, filter.Key); !ok {
 				return true
 			} else {
-				return util.IsNil(v)
+				for _, v := range values {
+					if util.IsNil(v) {
+						return true
+					}
+				}
+				return false
 			}
 		}
 	case NNULL:
 		return func(m map[string]any) bool {
-			if v, ok := reflectutil.Get[any](m, filter.Key); !ok {
+			if values, ok := resolve(m, filter.Key); !ok {
 				return false
 			} else {
-				return !util.IsNil(v)
+				for _, v := range values {
+					if util.IsNil(v) {
+						return false
+					}
+				}
+				return true
 			}
 		}
 	case AND:
@@ -300,7 +364,7 @@ func parseFilter(filter *Filter) func(map[string]any) bool {
 		} else {
 			var parsed []func(map[string]any) bool
 			for _, child := range children {
-				parsed = append(parsed, parseFilter(child))
+				parsed = append(parsed, parseFilterWith(child, collation))
 			}
 			return func(m map[string]any) bool {
 				for _, p := range parsed {
@@ -319,7 +383,7 @@ func parseFilter(filter *Filter) func(map[string]any) bool {
 		} else {
 			var parsed []func(map[string]any) bool
 			for _, child := range children {
-				parsed = append(parsed, parseFilter(child))
+				parsed = append(parsed, parseFilterWith(child, collation))
 			}
 			return func(m map[string]any) bool {
 				for _, p := range parsed {
@@ -330,6 +394,26 @@ func parseFilter(filter *Filter) func(map[string]any) bool {
 				return false
 			}
 		}
+	case TEXT:
+		query := text.Parse(fmt.Sprint(filter.Value))
+		return func(m map[string]any) bool {
+			if v, ok := reflectutil.Get[string](m, filter.Key); !ok {
+				return false
+			} else {
+				return query.Match(text.Analyze(v))
+			}
+		}
+	case NEAR, WITHIN, INTERSECTS:
+		match := parseGeoFilter(filter)
+		return func(m map[string]any) bool {
+			if v, ok := reflectutil.Get[any](m, filter.Key); !ok {
+				return false
+			} else if g, ok := parseGeometry(v); !ok {
+				return false
+			} else {
+				return match(g)
+			}
+		}
 	}
 
 	return func(_ map[string]any) bool {
@@ -375,23 +459,33 @@ func filterToExample(filter *Filter) ([]map[string]any, bool) {
 		if children, ok := filter.Value.([]*Filter); !ok {
 			return nil, false
 		} else {
-			example := map[string]any{}
+			examples := []map[string]any{{}}
 			for _, child := range children {
 				e, _ := filterToExample(child)
 				if len(e) == 0 {
-				} else if len(e) == 1 {
-					for k, v := range e[0] {
-						if _, ok := example[k]; ok {
-							return nil, true
-						} else {
-							example[k] = v
+					continue
+				}
+
+				var next []map[string]any
+				for _, example := range examples {
+					for _, other := range e {
+						merged := make(map[string]any, len(example)+len(other))
+						for k, v := range example {
+							merged[k] = v
 						}
+						for k, v := range other {
+							if prev, ok := merged[k]; !ok {
+								merged[k] = v
+							} else if !reflectutil.Equal(prev, v) {
+								return nil, false
+							}
+						}
+						next = append(next, merged)
 					}
-				} else {
-					return nil, false
 				}
+				examples = next
 			}
-			return []map[string]any{example}, true
+			return examples, true
 		}
 	case OR:
 		if children, ok := filter.Value.([]*Filter); !ok {
@@ -411,3 +505,88 @@ func filterToExample(filter *Filter) ([]map[string]any, bool) {
 
 	return nil, false
 }
+
+func findFilter(filter *Filter, ops ...operator) *Filter {
+	if util.IsNil(filter) {
+		return nil
+	}
+
+	for _, op := range ops {
+		if filter.OP == op {
+			return filter
+		}
+	}
+
+	switch filter.OP {
+	case AND, OR:
+		children, _ := filter.Value.([]*Filter)
+		for _, child := range children {
+			if f := findFilter(child, ops...); f != nil {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+func resolve(value any, key string) ([]any, bool) {
+	if v, ok := reflectutil.Get[any](value, key); ok {
+		return []any{v}, true
+	}
+
+	head, rest, ok := strings.Cut(key, ".")
+	if !ok {
+		return nil, false
+	}
+	v, ok := reflectutil.Get[any](value, head)
+	if !ok {
+		return nil, false
+	}
+	elements, ok := toArray(v)
+	if !ok {
+		return nil, false
+	}
+
+	var values []any
+	for _, e := range elements {
+		if vs, ok := resolve(e, rest); ok {
+			values = append(values, vs...)
+		}
+	}
+	return values, len(values) > 0
+}
+
+func containsElement(value any, key string, match func(any) bool) bool {
+	values, _ := resolve(value, key)
+	for _, v := range values {
+		if match(v) {
+			return true
+		}
+		if elements, ok := toArray(v); ok {
+			for _, e := range elements {
+				if match(e) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func containsScalar(value any, key string, match func(any) bool) bool {
+	values, _ := resolve(value, key)
+	for _, v := range values {
+		if elements, ok := toArray(v); !ok {
+			if match(v) {
+				return true
+			}
+		} else {
+			for _, e := range elements {
+				if match(e) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
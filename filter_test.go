@@ -138,6 +138,19 @@ func TestFilterHelper_IsNotNull(t *testing.T) {
 	}, wh.IsNotNull())
 }
 
+func TestFilterHelper_Text(t *testing.T) {
+	f := faker.UUIDHyphenated()
+	v := faker.Sentence()
+
+	wh := Where(f)
+
+	assert.Equal(t, &Filter{
+		Key:   f,
+		OP:    TEXT,
+		Value: v,
+	}, wh.Text(v))
+}
+
 func TestFilter_And(t *testing.T) {
 	f1 := faker.UUIDHyphenated()
 	f2 := faker.UUIDHyphenated()
@@ -673,6 +686,71 @@ func TestParseFilter(t *testing.T) {
 			expect:     false,
 		},
 
+		{
+			whenFilter: Where("a").EQ("x"),
+			whenValue: map[string]any{
+				"a": []any{"x", "y"},
+			},
+			expect: true,
+		},
+		{
+			whenFilter: Where("a").EQ([]any{"x", "y"}),
+			whenValue: map[string]any{
+				"a": []any{"x", "y"},
+			},
+			expect: true,
+		},
+		{
+			whenFilter: Where("a").NE("x"),
+			whenValue: map[string]any{
+				"a": []any{"x", "y"},
+			},
+			expect: false,
+		},
+		{
+			whenFilter: Where("a").GT(2),
+			whenValue: map[string]any{
+				"a": []any{1, 3},
+			},
+			expect: true,
+		},
+		{
+			whenFilter: Where("a").IN("z", "y"),
+			whenValue: map[string]any{
+				"a": []any{"x", "y"},
+			},
+			expect: true,
+		},
+		{
+			whenFilter: Where("a").NotIN("z", "y"),
+			whenValue: map[string]any{
+				"a": []any{"x", "y"},
+			},
+			expect: false,
+		},
+		{
+			whenFilter: Where("a.b").EQ(2),
+			whenValue: map[string]any{
+				"a": []any{map[string]any{"b": 1}, map[string]any{"b": 2}},
+			},
+			expect: true,
+		},
+
+		{
+			whenFilter: Where("a").Text("jumping"),
+			whenValue: map[string]any{
+				"a": "The fox jumps",
+			},
+			expect: true,
+		},
+		{
+			whenFilter: Where("a").Text("cat"),
+			whenValue: map[string]any{
+				"a": "The fox jumps",
+			},
+			expect: false,
+		},
+
 		{
 			whenFilter: Where("a").EQ(1).And(Where("b").EQ(2)),
 			whenValue: map[string]any{
@@ -864,6 +942,43 @@ func TestFilterToExample(t *testing.T) {
 			},
 			expectOK: true,
 		},
+		{
+			whenFilter: Where("a").EQ("1").
+				And(Where("a").EQ("1")),
+			expectExamples: []map[string]any{
+				{
+					"a": "1",
+				},
+			},
+			expectOK: true,
+		},
+		{
+			whenFilter: Where("a").EQ("1").
+				And(Where("a").EQ("2")),
+			expectExamples: nil,
+			expectOK:       false,
+		},
+		{
+			whenFilter: Where("a").IN("1", "2").
+				And(Where("b").EQ("1")),
+			expectExamples: []map[string]any{
+				{
+					"a": "1",
+					"b": "1",
+				},
+				{
+					"a": "2",
+					"b": "1",
+				},
+			},
+			expectOK: true,
+		},
+		{
+			whenFilter: Where("a").IN("1", "2").
+				And(Where("a").EQ("1")),
+			expectExamples: nil,
+			expectOK:       false,
+		},
 		{
 			whenFilter: Where("a").EQ("1").
 				Or(Where("b").EQ("1")),
@@ -0,0 +1,541 @@
+package memdb
+
+import (
+	"github.com/siyul-park/memdb/internal/pool"
+	"github.com/siyul-park/memdb/internal/util"
+	"github.com/siyul-park/memdb/internal/util/reflectutil"
+	"math"
+	"sync"
+)
+
+type (
+	GeoPoint struct {
+		Lng float64
+		Lat float64
+	}
+
+	GeoPolygon [][]GeoPoint
+
+	GeoBox struct {
+		Min GeoPoint
+		Max GeoPoint
+	}
+
+	GeoCircle struct {
+		Center GeoPoint
+		Radius float64
+	}
+
+	geometry struct {
+		point   *GeoPoint
+		polygon GeoPolygon
+	}
+
+	geoLocator struct {
+		path      string
+		center    GeoPoint
+		pk        primaryKey
+		distances sync.Map
+	}
+
+	geoWide struct{}
+)
+
+const (
+	KeyGeoDistance = "$distance"
+)
+
+const (
+	earthRadius  = 6371008.8
+	geoPrecision = 7
+	geoMaxCells  = 64
+	geoBase32    = "0123456789bcdefghjkmnpqrstuvwxyz"
+)
+
+func (iv *IndexView) insertGeo(data *sync.Map, model IndexModel, id any, document map[string]any) {
+	for _, k := range model.Keys {
+		v, ok := reflectutil.Get[any](document, k)
+		if !ok {
+			continue
+		}
+		g, ok := parseGeometry(v)
+		if !ok {
+			continue
+		}
+
+		field := loadOrStoreMap(data, k)
+		for _, cell := range g.cells() {
+			loadOrStoreMap(field, cell).Store(id, nil)
+		}
+	}
+}
+
+func (iv *IndexView) deleteGeo(data *sync.Map, model IndexModel, id any, document map[string]any) {
+	for _, k := range model.Keys {
+		v, ok := reflectutil.Get[any](document, k)
+		if !ok {
+			continue
+		}
+		g, ok := parseGeometry(v)
+		if !ok {
+			continue
+		}
+		sub, ok := data.Load(k)
+		if !ok {
+			continue
+		}
+		field := sub.(*sync.Map)
+
+		for _, cell := range g.cells() {
+			if sub, ok := field.Load(cell); ok {
+				ids := sub.(*sync.Map)
+				ids.Delete(id)
+				if isEmptyMap(ids) {
+					field.Delete(cell)
+					pool.PutMap(ids)
+				}
+			}
+		}
+		if isEmptyMap(field) {
+			data.Delete(k)
+			pool.PutMap(field)
+		}
+	}
+}
+
+func (iv *IndexView) findGeo(filter *Filter) ([]any, bool) {
+	if util.IsNil(filter) {
+		return nil, false
+	}
+
+	var bounds GeoBox
+	switch filter.OP {
+	case AND:
+		children, _ := filter.Value.([]*Filter)
+		for _, child := range children {
+			if ids, ok := iv.findGeo(child); ok {
+				return ids, true
+			}
+		}
+		return nil, false
+	case NEAR:
+		if c, ok := filter.Value.(GeoCircle); !ok || c.Radius <= 0 {
+			return nil, false
+		} else {
+			bounds = c.bounds()
+		}
+	case WITHIN, INTERSECTS:
+		if g, ok := parseShape(filter.Value); !ok {
+			return nil, false
+		} else {
+			bounds = g.bounds()
+		}
+	default:
+		return nil, false
+	}
+
+	cells, ok := geoCover(bounds)
+	if !ok {
+		return nil, false
+	}
+	field, ok := iv.field(IndexGeo, filter.Key)
+	if !ok {
+		return nil, false
+	} else if field == nil {
+		return nil, true
+	}
+
+	visits := map[any]struct{}{}
+	var ids []any
+	for _, cell := range append(cells, geoWide{}) {
+		if sub, ok := field.Load(cell); ok {
+			sub.(*sync.Map).Range(func(id, _ any) bool {
+				if _, ok := visits[id]; !ok {
+					visits[id] = struct{}{}
+					ids = append(ids, id)
+				}
+				return true
+			})
+		}
+	}
+	return ids, true
+}
+
+func (coll *Collection) locator(filter *Filter) *geoLocator {
+	f := findFilter(filter, NEAR)
+	if f == nil {
+		f = findFilter(filter, WITHIN)
+	}
+	if f == nil {
+		return nil
+	}
+	c, ok := f.Value.(GeoCircle)
+	if !ok {
+		return nil
+	}
+	return &geoLocator{
+		path:   f.Key,
+		center: c.Center,
+		pk:     coll.pk,
+	}
+}
+
+func (l *geoLocator) key() string {
+	return KeyGeoDistance
+}
+
+func (l *geoLocator) value(document map[string]any) any {
+	id, _ := l.pk.id(document)
+	if v, ok := l.distances.Load(id); ok {
+		return v
+	}
+
+	var distance any
+	if v, ok := reflectutil.Get[any](document, l.path); ok {
+		if g, ok := parseGeometry(v); ok {
+			distance = g.distance(l.center)
+		}
+	}
+	l.distances.Store(id, distance)
+	return distance
+}
+
+func parseGeoFilter(filter *Filter) func(geometry) bool {
+	switch filter.OP {
+	case NEAR:
+		if c, ok := filter.Value.(GeoCircle); ok {
+			return func(g geometry) bool {
+				return c.Radius <= 0 || g.distance(c.Center) <= c.Radius
+			}
+		}
+	case WITHIN:
+		switch shape := filter.Value.(type) {
+		case GeoCircle:
+			return func(g geometry) bool {
+				return g.within(func(p GeoPoint) bool {
+					return haversine(p, shape.Center) <= shape.Radius
+				})
+			}
+		case GeoBox:
+			return func(g geometry) bool {
+				return g.within(shape.contains)
+			}
+		default:
+			if other, ok := parseGeometry(shape); ok && other.point == nil {
+				return func(g geometry) bool {
+					return g.within(other.polygon.contains)
+				}
+			}
+		}
+	case INTERSECTS:
+		if other, ok := parseShape(filter.Value); ok {
+			return func(g geometry) bool {
+				return g.intersects(other)
+			}
+		}
+	}
+
+	return func(_ geometry) bool {
+		return false
+	}
+}
+
+func parseShape(value any) (geometry, bool) {
+	if c, ok := value.(GeoCircle); ok {
+		return geometry{polygon: GeoPolygon{c.bounds().ring()}}, true
+	}
+	return parseGeometry(value)
+}
+
+func parseGeometry(value any) (geometry, bool) {
+	switch v := value.(type) {
+	case GeoPoint:
+		return geometry{point: &v}, true
+	case *GeoPoint:
+		if v != nil {
+			return geometry{point: v}, true
+		}
+	case GeoPolygon:
+		if len(v) > 0 && len(v[0]) >= 3 {
+			return geometry{polygon: v}, true
+		}
+	case GeoBox:
+		return geometry{polygon: GeoPolygon{v.ring()}}, true
+	case map[string]any:
+		switch v["type"] {
+		case "Point":
+			if p, ok := toGeoPoint(v["coordinates"]); ok {
+				return geometry{point: &p}, true
+			}
+		case "Polygon":
+			rings, _ := toArray(v["coordinates"])
+			var polygon GeoPolygon
+			for _, r := range rings {
+				points, _ := toArray(r)
+				var ring []GeoPoint
+				for _, point := range points {
+					if p, ok := toGeoPoint(point); ok {
+						ring = append(ring, p)
+					} else {
+						return geometry{}, false
+					}
+				}
+				polygon = append(polygon, ring)
+			}
+			return parseGeometry(polygon)
+		}
+	default:
+		if p, ok := toGeoPoint(value); ok {
+			return geometry{point: &p}, true
+		}
+	}
+	return geometry{}, false
+}
+
+func toGeoPoint(value any) (GeoPoint, bool) {
+	coordinates, ok := toArray(value)
+	if !ok || len(coordinates) < 2 {
+		return GeoPoint{}, false
+	}
+	lng, ok := toFloat(coordinates[0])
+	if !ok {
+		return GeoPoint{}, false
+	}
+	lat, ok := toFloat(coordinates[1])
+	if !ok {
+		return GeoPoint{}, false
+	}
+	return GeoPoint{Lng: lng, Lat: lat}, true
+}
+
+func (g geometry) cells() []any {
+	if g.point == nil {
+		return []any{geoWide{}}
+	}
+
+	hash := geohash(*g.point, geoPrecision)
+	cells := make([]any, 0, geoPrecision)
+	for i := 1; i <= geoPrecision; i++ {
+		cells = append(cells, hash[:i])
+	}
+	return cells
+}
+
+func (g geometry) bounds() GeoBox {
+	if g.point != nil {
+		return GeoBox{Min: *g.point, Max: *g.point}
+	}
+
+	b := GeoBox{
+		Min: GeoPoint{Lng: math.Inf(1), Lat: math.Inf(1)},
+		Max: GeoPoint{Lng: math.Inf(-1), Lat: math.Inf(-1)},
+	}
+	for _, p := range g.polygon[0] {
+		b.Min.Lng = math.Min(b.Min.Lng, p.Lng)
+		b.Min.Lat = math.Min(b.Min.Lat, p.Lat)
+		b.Max.Lng = math.Max(b.Max.Lng, p.Lng)
+		b.Max.Lat = math.Max(b.Max.Lat, p.Lat)
+	}
+	return b
+}
+
+func (g geometry) distance(p GeoPoint) float64 {
+	if g.point != nil {
+		return haversine(*g.point, p)
+	}
+	if g.polygon.contains(p) {
+		return 0
+	}
+
+	d := math.Inf(1)
+	for _, q := range g.polygon[0] {
+		d = math.Min(d, haversine(p, q))
+	}
+	return d
+}
+
+func (g geometry) within(contains func(GeoPoint) bool) bool {
+	if g.point != nil {
+		return contains(*g.point)
+	}
+	for _, p := range g.polygon[0] {
+		if !contains(p) {
+			return false
+		}
+	}
+	return true
+}
+
+func (g geometry) intersects(other geometry) bool {
+	switch {
+	case g.point != nil && other.point != nil:
+		return *g.point == *other.point
+	case g.point != nil:
+		return other.polygon.contains(*g.point)
+	case other.point != nil:
+		return g.polygon.contains(*other.point)
+	}
+
+	for _, p := range g.polygon[0] {
+		if other.polygon.contains(p) {
+			return true
+		}
+	}
+	for _, p := range other.polygon[0] {
+		if g.polygon.contains(p) {
+			return true
+		}
+	}
+
+	a, b := g.polygon[0], other.polygon[0]
+	for i := range a {
+		for j := range b {
+			if segmentsIntersect(a[i], a[(i+1)%len(a)], b[j], b[(j+1)%len(b)]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p GeoPolygon) contains(point GeoPoint) bool {
+	if len(p) == 0 || !ringContains(p[0], point) {
+		return false
+	}
+	for _, hole := range p[1:] {
+		if ringContains(hole, point) {
+			return false
+		}
+	}
+	return true
+}
+
+func (b GeoBox) contains(p GeoPoint) bool {
+	return p.Lng >= math.Min(b.Min.Lng, b.Max.Lng) && p.Lng <= math.Max(b.Min.Lng, b.Max.Lng) &&
+		p.Lat >= math.Min(b.Min.Lat, b.Max.Lat) && p.Lat <= math.Max(b.Min.Lat, b.Max.Lat)
+}
+
+func (b GeoBox) ring() []GeoPoint {
+	return []GeoPoint{
+		{Lng: b.Min.Lng, Lat: b.Min.Lat},
+		{Lng: b.Max.Lng, Lat: b.Min.Lat},
+		{Lng: b.Max.Lng, Lat: b.Max.Lat},
+		{Lng: b.Min.Lng, Lat: b.Max.Lat},
+	}
+}
+
+func (c GeoCircle) bounds() GeoBox {
+	dLat := c.Radius / earthRadius * 180 / math.Pi
+	if math.Abs(c.Center.Lat)+dLat >= 90 {
+		return GeoBox{
+			Min: GeoPoint{Lng: -180, Lat: math.Max(c.Center.Lat-dLat, -90)},
+			Max: GeoPoint{Lng: 180, Lat: math.Min(c.Center.Lat+dLat, 90)},
+		}
+	}
+	dLng := dLat / math.Cos(c.Center.Lat*math.Pi/180)
+	return GeoBox{
+		Min: GeoPoint{Lng: c.Center.Lng - dLng, Lat: c.Center.Lat - dLat},
+		Max: GeoPoint{Lng: c.Center.Lng + dLng, Lat: c.Center.Lat + dLat},
+	}
+}
+
+func ringContains(ring []GeoPoint, p GeoPoint) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.Lat > p.Lat) != (b.Lat > p.Lat) && p.Lng < (b.Lng-a.Lng)*(p.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lng {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+func segmentsIntersect(p1, p2, q1, q2 GeoPoint) bool {
+	orient := func(a, b, c GeoPoint) float64 {
+		return (b.Lng-a.Lng)*(c.Lat-a.Lat) - (b.Lat-a.Lat)*(c.Lng-a.Lng)
+	}
+	d1 := orient(q1, q2, p1)
+	d2 := orient(q1, q2, p2)
+	d3 := orient(p1, p2, q1)
+	d4 := orient(p1, p2, q2)
+	return ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0))
+}
+
+func haversine(p, q GeoPoint) float64 {
+	lat1 := p.Lat * math.Pi / 180
+	lat2 := q.Lat * math.Pi / 180
+	dLat := lat2 - lat1
+	dLng := (q.Lng - p.Lng) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+func geohash(p GeoPoint, level int) string {
+	lngBits, latBits := geoBits(level)
+	return encodeGeohash(geoCell(p.Lng, -180, 360, lngBits), geoCell(p.Lat, -90, 180, latBits), level)
+}
+
+func geoCover(b GeoBox) ([]any, bool) {
+	if b.Min.Lng < -180 || b.Max.Lng > 180 || b.Min.Lng > b.Max.Lng || b.Min.Lat > b.Max.Lat {
+		return nil, false
+	}
+
+	for level := geoPrecision; level >= 1; level-- {
+		lngBits, latBits := geoBits(level)
+		x0, x1 := geoCell(b.Min.Lng, -180, 360, lngBits), geoCell(b.Max.Lng, -180, 360, lngBits)
+		y0, y1 := geoCell(b.Min.Lat, -90, 180, latBits), geoCell(b.Max.Lat, -90, 180, latBits)
+		if (x1-x0+1)*(y1-y0+1) > geoMaxCells {
+			continue
+		}
+
+		var cells []any
+		for x := x0; x <= x1; x++ {
+			for y := y0; y <= y1; y++ {
+				cells = append(cells, encodeGeohash(x, y, level))
+			}
+		}
+		return cells, true
+	}
+	return nil, false
+}
+
+func geoBits(level int) (int, int) {
+	return (5*level + 1) / 2, 5 * level / 2
+}
+
+func geoCell(v, min, span float64, bits int) uint64 {
+	n := uint64(1) << bits
+	f := (v - min) / span * float64(n)
+	if f < 0 {
+		return 0
+	}
+	if i := uint64(f); i < n {
+		return i
+	}
+	return n - 1
+}
+
+func encodeGeohash(x, y uint64, level int) string {
+	lngBits, latBits := geoBits(level)
+
+	out := make([]byte, 0, level)
+	ch, n := 0, 0
+	for i := 0; i < 5*level; i++ {
+		var bit uint64
+		if i%2 == 0 {
+			lngBits--
+			bit = (x >> lngBits) & 1
+		} else {
+			latBits--
+			bit = (y >> latBits) & 1
+		}
+		ch = ch<<1 | int(bit)
+		n++
+		if n == 5 {
+			out = append(out, geoBase32[ch])
+			ch, n = 0, 0
+		}
+	}
+	return string(out)
+}
@@ -0,0 +1,93 @@
+package memdb
+
+import (
+	"github.com/go-faker/faker/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCollection_FindMany_Geo(t *testing.T) {
+	for _, indexed := range []bool{false, true} {
+		coll := newCollection(faker.Name())
+
+		if indexed {
+			err := coll.Indexes().Create(IndexModel{Keys: []string{"location"}, Name: "location_geo", Kind: IndexGeo})
+			assert.NoError(t, err)
+		}
+
+		_, err := coll.InsertMany([]map[string]any{
+			{"id": 1, "location": map[string]any{"type": "Point", "coordinates": []any{126.9780, 37.5665}}},
+			{"id": 2, "location": map[string]any{"type": "Point", "coordinates": []any{126.9920, 37.5700}}},
+			{"id": 3, "location": map[string]any{"type": "Point", "coordinates": []any{129.0756, 35.1796}}},
+			{"id": 4, "location": GeoPoint{Lng: 127.0276, Lat: 37.4979}},
+			{"id": 5, "location": map[string]any{"type": "Polygon", "coordinates": []any{
+				[]any{[]any{126.97, 37.56}, []any{126.99, 37.56}, []any{126.99, 37.58}, []any{126.97, 37.58}, []any{126.97, 37.56}},
+			}}},
+		})
+		assert.NoError(t, err)
+
+		center := GeoPoint{Lng: 126.9780, Lat: 37.5665}
+
+		if indexed {
+			candidates, err := coll.indexView.findMany(Where("location").WithinRadius(center, 2000))
+			assert.NoError(t, err)
+			assert.Subset(t, candidates, []any{1, 2, 5})
+			assert.NotContains(t, candidates, 3)
+		}
+
+		docs, err := coll.FindMany(Where("location").WithinRadius(center, 2000))
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []any{1, 2, 5}, ids(docs))
+
+		docs, err = coll.FindMany(Where("location").Near(center, 10000))
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []any{1, 5}, ids(docs)[:2])
+		assert.Equal(t, []any{2, 4}, ids(docs)[2:])
+		assert.Equal(t, 0.0, docs[0][KeyGeoDistance])
+
+		docs, err = coll.FindMany(Where("location").Near(center, 0), &FindOptions{
+			Sorts: []Sort{{Key: KeyGeoDistance, Order: OrderDESC}},
+			Limit: &[]int{1}[0],
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []any{3}, ids(docs))
+
+		docs, err = coll.FindMany(Where("location").WithinBox(GeoPoint{Lng: 126.9, Lat: 37.5}, GeoPoint{Lng: 127.0, Lat: 37.6}))
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []any{1, 2, 5}, ids(docs))
+
+		docs, err = coll.FindMany(Where("location").WithinPolygon(GeoPolygon{{
+			{Lng: 126.95, Lat: 37.55}, {Lng: 126.985, Lat: 37.55}, {Lng: 126.985, Lat: 37.58}, {Lng: 126.95, Lat: 37.58},
+		}}))
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []any{1}, ids(docs))
+
+		docs, err = coll.FindMany(Where("location").Intersects(GeoPoint{Lng: 126.98, Lat: 37.57}))
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []any{5}, ids(docs))
+
+		docs, err = coll.FindMany(Where("location").Intersects(GeoBox{Min: GeoPoint{Lng: 126.975, Lat: 37.565}, Max: GeoPoint{Lng: 126.98, Lat: 37.57}}))
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []any{1, 5}, ids(docs))
+
+		_, err = coll.DeleteOne(Where("id").EQ(5))
+		assert.NoError(t, err)
+
+		docs, err = coll.FindMany(Where("location").WithinRadius(center, 2000))
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []any{1, 2}, ids(docs))
+	}
+}
+
+func TestGeohash(t *testing.T) {
+	assert.Equal(t, "ezs42", geohash(GeoPoint{Lng: -5.6, Lat: 42.6}, 5))
+	assert.Equal(t, "wydm9qy", geohash(GeoPoint{Lng: 126.9780, Lat: 37.5665}, 7))
+}
+
+func ids(docs []map[string]any) []any {
+	var ids []any
+	for _, doc := range docs {
+		ids = append(ids, doc["id"])
+	}
+	return ids
+}
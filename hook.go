@@ -0,0 +1,115 @@
+package memdb
+
+import (
+	"context"
+	"github.com/siyul-park/memdb/internal/util"
+	"sync"
+)
+
+type (
+	BeforeInsertHook func(ctx context.Context, document map[string]any) error
+	BeforeUpdateHook func(ctx context.Context, old map[string]any, document map[string]any) error
+	BeforeDeleteHook func(ctx context.Context, document map[string]any) error
+	AfterCommitHook  func(ctx context.Context, event Event, document map[string]any)
+
+	hooks struct {
+		entries []hookEntry
+		seq     int
+		lock    sync.RWMutex
+	}
+
+	hookEntry struct {
+		id   int
+		hook any
+	}
+
+	hooksKey struct{}
+)
+
+func (h *hooks) add(hook any) int {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.seq++
+	h.entries = append(h.entries, hookEntry{id: h.seq, hook: hook})
+	return h.seq
+}
+
+func (h *hooks) remove(id int) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	for i, entry := range h.entries {
+		if entry.id == id {
+			h.entries = append(h.entries[:i:i], h.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+func (h *hooks) list(ctx context.Context) []hookEntry {
+	if skip, _ := ctx.Value(hooksKey{}).(bool); skip {
+		return nil
+	}
+
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	return h.entries
+}
+
+func (h *hooks) beforeInsert(ctx context.Context, documents ...map[string]any) error {
+	for _, entry := range h.list(ctx) {
+		if hook, ok := entry.hook.(BeforeInsertHook); ok {
+			for _, doc := range documents {
+				if err := hook(ctx, doc); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (h *hooks) beforeUpdate(ctx context.Context, old []map[string]any, documents []map[string]any) error {
+	for _, entry := range h.list(ctx) {
+		if hook, ok := entry.hook.(BeforeUpdateHook); ok {
+			for i, doc := range documents {
+				if err := hook(ctx, old[i], doc); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (h *hooks) beforeDelete(ctx context.Context, documents ...map[string]any) error {
+	for _, entry := range h.list(ctx) {
+		if hook, ok := entry.hook.(BeforeDeleteHook); ok {
+			for _, doc := range documents {
+				if util.IsNil(doc) {
+					continue
+				}
+				if err := hook(ctx, doc); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (h *hooks) afterCommit(ctx context.Context, event Event, documents ...map[string]any) {
+	for _, entry := range h.list(ctx) {
+		if hook, ok := entry.hook.(AfterCommitHook); ok {
+			for _, doc := range documents {
+				hook(ctx, event, doc)
+			}
+		}
+	}
+}
+
+func withoutHooks(ctx context.Context) context.Context {
+	return context.WithValue(ctx, hooksKey{}, true)
+}
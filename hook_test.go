@@ -0,0 +1,116 @@
+package memdb
+
+import (
+	"context"
+	"github.com/go-faker/faker/v4"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCollection_BeforeInsert(t *testing.T) {
+	coll := newCollection(faker.Name())
+
+	err := coll.Indexes().Create(IndexModel{Keys: []string{"name"}, Name: "name"})
+	assert.NoError(t, err)
+
+	invalid := errors.New("invalid")
+	coll.BeforeInsert(func(_ context.Context, doc map[string]any) error {
+		if doc["name"] == nil {
+			return invalid
+		}
+		doc["createdAt"] = 1
+		return nil
+	})
+
+	_, err = coll.InsertOne(map[string]any{"id": 1, "name": "a"})
+	assert.NoError(t, err)
+
+	doc, err := coll.FindOne(Where("id").EQ(1))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, doc["createdAt"])
+
+	_, err = coll.InsertMany([]map[string]any{{"id": 2, "name": "b"}, {"id": 3}})
+	assert.ErrorIs(t, err, invalid)
+
+	docs, err := coll.FindMany(Where("name").EQ("b"))
+	assert.NoError(t, err)
+	assert.Len(t, docs, 0)
+}
+
+func TestCollection_BeforeUpdate(t *testing.T) {
+	coll := newCollection(faker.Name())
+
+	immutable := errors.New("immutable")
+	id := coll.BeforeUpdate(func(_ context.Context, old map[string]any, doc map[string]any) error {
+		if old["name"] != doc["name"] {
+			return immutable
+		}
+		doc["updatedAt"] = 1
+		return nil
+	})
+
+	_, err := coll.InsertOne(map[string]any{"id": 1, "name": "a"})
+	assert.NoError(t, err)
+
+	_, err = coll.UpdateOne(Where("id").EQ(1), map[string]any{"name": "b"})
+	assert.ErrorIs(t, err, immutable)
+
+	ok, err := coll.UpdateOne(Where("id").EQ(1), map[string]any{"name": "a", "age": 1})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	doc, err := coll.FindOne(Where("id").EQ(1))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"id": 1, "name": "a", "age": 1, "updatedAt": 1}, doc)
+
+	coll.RemoveHook(id)
+
+	count, err := coll.UpdateMany(nil, map[string]any{"name": "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestCollection_BeforeDelete(t *testing.T) {
+	coll := newCollection(faker.Name())
+
+	protected := errors.New("protected")
+	coll.BeforeDelete(func(_ context.Context, doc map[string]any) error {
+		if doc["protected"] == true {
+			return protected
+		}
+		return nil
+	})
+
+	_, err := coll.InsertMany([]map[string]any{{"id": 1, "protected": true}, {"id": 2}})
+	assert.NoError(t, err)
+
+	_, err = coll.DeleteMany(nil)
+	assert.ErrorIs(t, err, protected)
+
+	ok, err := coll.DeleteOne(Where("id").EQ(2))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	docs, err := coll.FindMany(nil)
+	assert.NoError(t, err)
+	assert.Len(t, docs, 1)
+}
+
+func TestCollection_AfterCommit(t *testing.T) {
+	coll := newCollection(faker.Name())
+
+	var events []Event
+	coll.AfterCommit(func(_ context.Context, event Event, _ map[string]any) {
+		events = append(events, event)
+	})
+
+	_, err := coll.InsertOne(map[string]any{"id": 1})
+	assert.NoError(t, err)
+	_, err = coll.UpdateOne(Where("id").EQ(1), map[string]any{"name": "a"})
+	assert.NoError(t, err)
+	_, err = coll.DeleteOne(Where("id").EQ(1))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Event{EventInsert, EventUpdate, EventDelete}, events)
+}
@@ -0,0 +1,186 @@
+package memdb
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	IDGenerator interface {
+		Generate() any
+	}
+
+	IDGeneratorFunc func() any
+
+	uuidV4Generator struct{}
+
+	uuidV7Generator struct {
+		last uint64
+		seq  uint16
+		lock sync.Mutex
+	}
+
+	ulidGenerator struct {
+		last    uint64
+		entropy [10]byte
+		lock    sync.Mutex
+	}
+
+	sequenceGenerator struct {
+		next int64
+	}
+
+	objectIDGenerator struct {
+		process [5]byte
+		counter uint32
+	}
+)
+
+const (
+	crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+)
+
+var _ IDGenerator = IDGeneratorFunc(nil)
+var _ IDGenerator = (*uuidV4Generator)(nil)
+var _ IDGenerator = (*uuidV7Generator)(nil)
+var _ IDGenerator = (*ulidGenerator)(nil)
+var _ IDGenerator = (*sequenceGenerator)(nil)
+var _ IDGenerator = (*objectIDGenerator)(nil)
+
+func NewUUIDv4Generator() IDGenerator {
+	return &uuidV4Generator{}
+}
+
+func NewUUIDv7Generator() IDGenerator {
+	return &uuidV7Generator{}
+}
+
+func NewULIDGenerator() IDGenerator {
+	return &ulidGenerator{}
+}
+
+func NewSequenceGenerator(start int64) IDGenerator {
+	return &sequenceGenerator{next: start}
+}
+
+func NewObjectIDGenerator() IDGenerator {
+	g := &objectIDGenerator{}
+	_, _ = rand.Read(g.process[:])
+
+	var counter [4]byte
+	_, _ = rand.Read(counter[:])
+	g.counter = binary.BigEndian.Uint32(counter[:])
+
+	return g
+}
+
+func (f IDGeneratorFunc) Generate() any {
+	return f()
+}
+
+func (g *uuidV4Generator) Generate() any {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return formatUUID(b)
+}
+
+func (g *uuidV7Generator) Generate() any {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	ms := uint64(time.Now().UnixMilli())
+	if ms <= g.last {
+		ms = g.last
+		g.seq++
+		if g.seq > 0x0fff {
+			ms++
+			g.seq = 0
+		}
+	} else {
+		g.seq = 0
+	}
+	g.last = ms
+
+	var b [16]byte
+	_, _ = rand.Read(b[8:])
+
+	b[0] = byte(ms >> 40)
+	b[1] = byte(ms >> 32)
+	b[2] = byte(ms >> 24)
+	b[3] = byte(ms >> 16)
+	b[4] = byte(ms >> 8)
+	b[5] = byte(ms)
+	b[6] = 0x70 | byte(g.seq>>8)
+	b[7] = byte(g.seq)
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return formatUUID(b)
+}
+
+func (g *ulidGenerator) Generate() any {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	ms := uint64(time.Now().UnixMilli())
+	if ms <= g.last {
+		ms = g.last
+		for i := len(g.entropy) - 1; i >= 0; i-- {
+			g.entropy[i]++
+			if g.entropy[i] != 0 {
+				break
+			}
+		}
+	} else {
+		_, _ = rand.Read(g.entropy[:])
+	}
+	g.last = ms
+
+	var b [16]byte
+	b[0] = byte(ms >> 40)
+	b[1] = byte(ms >> 32)
+	b[2] = byte(ms >> 24)
+	b[3] = byte(ms >> 16)
+	b[4] = byte(ms >> 8)
+	b[5] = byte(ms)
+	copy(b[6:], g.entropy[:])
+
+	var out [26]byte
+	hi := binary.BigEndian.Uint64(b[:8])
+	lo := binary.BigEndian.Uint64(b[8:])
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = (lo >> 5) | (hi << 59)
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+func (g *sequenceGenerator) Generate() any {
+	return atomic.AddInt64(&g.next, 1) - 1
+}
+
+func (g *objectIDGenerator) Generate() any {
+	var b [12]byte
+	binary.BigEndian.PutUint32(b[0:4], uint32(time.Now().Unix()))
+	copy(b[4:9], g.process[:])
+
+	c := atomic.AddUint32(&g.counter, 1)
+	b[9] = byte(c >> 16)
+	b[10] = byte(c >> 8)
+	b[11] = byte(c)
+
+	return hex.EncodeToString(b[:])
+}
+
+func formatUUID(b [16]byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
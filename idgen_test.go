@@ -0,0 +1,72 @@
+package memdb
+
+import (
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+)
+
+func TestIDGenerator_Generate(t *testing.T) {
+	testCases := []struct {
+		name      string
+		generator IDGenerator
+		pattern   *regexp.Regexp
+	}{
+		{
+			name:      "UUIDv4",
+			generator: NewUUIDv4Generator(),
+			pattern:   regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`),
+		},
+		{
+			name:      "UUIDv7",
+			generator: NewUUIDv7Generator(),
+			pattern:   regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`),
+		},
+		{
+			name:      "ULID",
+			generator: NewULIDGenerator(),
+			pattern:   regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{26}$`),
+		},
+		{
+			name:      "ObjectID",
+			generator: NewObjectIDGenerator(),
+			pattern:   regexp.MustCompile(`^[0-9a-f]{24}$`),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			seen := map[any]struct{}{}
+			for i := 0; i < 1000; i++ {
+				id := tc.generator.Generate()
+				assert.Regexp(t, tc.pattern, id)
+
+				_, ok := seen[id]
+				assert.False(t, ok)
+				seen[id] = struct{}{}
+			}
+		})
+	}
+
+	t.Run("Sequence", func(t *testing.T) {
+		g := NewSequenceGenerator(10)
+		assert.Equal(t, int64(10), g.Generate())
+		assert.Equal(t, int64(11), g.Generate())
+	})
+
+	t.Run("Func", func(t *testing.T) {
+		g := IDGeneratorFunc(func() any { return "id" })
+		assert.Equal(t, "id", g.Generate())
+	})
+}
+
+func TestIDGenerator_Monotonic(t *testing.T) {
+	for _, g := range []IDGenerator{NewUUIDv7Generator(), NewULIDGenerator()} {
+		prev := g.Generate().(string)
+		for i := 0; i < 1000; i++ {
+			next := g.Generate().(string)
+			assert.Less(t, prev, next)
+			prev = next
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package memdb
 
 import (
+	"context"
+	"fmt"
 	"github.com/pkg/errors"
 	"github.com/siyul-park/memdb/internal/pool"
+	"github.com/siyul-park/memdb/internal/util"
 	"github.com/siyul-park/memdb/internal/util/reflectutil"
 	"sync"
 )
@@ -12,19 +15,46 @@ type (
 		names  []string
 		models []IndexModel
 		data   []*sync.Map
+		shards []*IndexView
+		shared *sync.Mutex
+		source *shard
+		pk     primaryKey
 		lock   sync.RWMutex
 	}
 
 	IndexModel struct {
-		Keys    []string
+		Keys      []string
+		Name      string
+		Unique    bool
+		Partial   *Filter
+		Kind      IndexKind
+		Vector    *VectorOptions
+		Collation *Collation
+	}
+
+	IndexKind int
+
+	IndexStats struct {
 		Name    string
-		Unique  bool
-		Partial *Filter
+		Entries int
+		Keys    int
+		Depth   int
 	}
+
+	primaryKey   []string
+	compositeKey string
 )
 
 const (
-	keyID = "id"
+	keyID   = "id"
+	indexID = "_id"
+)
+
+const (
+	IndexDefault IndexKind = iota
+	IndexText
+	IndexGeo
+	IndexVector
 )
 
 var (
@@ -36,15 +66,34 @@ var (
 )
 
 func newIndexView() *IndexView {
+	return newShardedIndexView(0, nil)
+}
+
+func newShardedIndexView(size int, pk primaryKey) *IndexView {
+	if len(pk) == 0 {
+		pk = primaryKey{keyID}
+	}
+
 	iv := &IndexView{
 		names:  nil,
 		models: nil,
 		data:   nil,
+		pk:     pk,
 		lock:   sync.RWMutex{},
 	}
+	if size > 1 {
+		iv.shared = &sync.Mutex{}
+		for i := 0; i < size; i++ {
+			iv.shards = append(iv.shards, &IndexView{
+				shared: iv.shared,
+				pk:     pk,
+				lock:   sync.RWMutex{},
+			})
+		}
+	}
 	iv.Create(IndexModel{
-		Keys:    []string{"id"},
-		Name:    "_id",
+		Keys:    pk,
+		Name:    indexID,
 		Unique:  true,
 		Partial: nil,
 	})
@@ -59,31 +108,182 @@ func (iv *IndexView) List() []IndexModel {
 	return iv.models
 }
 
-func (iv *IndexView) Create(index IndexModel) {
+func (iv *IndexView) Stats() []IndexStats {
+	iv.lock.RLock()
+	defer iv.lock.RUnlock()
+
+	var stats []IndexStats
+	for i, model := range iv.models {
+		stat := IndexStats{
+			Name:  model.Name,
+			Depth: len(model.Keys),
+		}
+		if !model.Unique {
+			stat.Depth += 1
+		}
+		if model.Kind == IndexVector {
+			stat.Depth = 2
+		} else if model.Kind != IndexDefault {
+			stat.Depth = 3
+		}
+
+		var data []*sync.Map
+		if len(iv.shards) == 0 {
+			data = append(data, iv.data[i])
+		} else {
+			visits := map[*sync.Map]bool{}
+			for _, shard := range iv.shards {
+				func() {
+					shard.lock.RLock()
+					defer shard.lock.RUnlock()
+
+					if d := shard.data[i]; !visits[d] {
+						visits[d] = true
+						data = append(data, d)
+					}
+				}()
+			}
+		}
+
+		keys := map[any]struct{}{}
+		for _, d := range data {
+			walkIndex(d, model, nil, func(key []any, entries int) {
+				stat.Entries += entries
+				if len(data) == 1 {
+					stat.Keys += 1
+				} else {
+					keys[compositeKey(fmt.Sprintf("%#v", key))] = struct{}{}
+				}
+			})
+		}
+		if len(data) > 1 {
+			stat.Keys = len(keys)
+		}
+
+		stats = append(stats, stat)
+	}
+	return stats
+}
+
+func (iv *IndexView) Create(index IndexModel) error {
+	return iv.CreateContext(context.Background(), index)
+}
+
+func (iv *IndexView) CreateContext(ctx context.Context, index IndexModel) error {
+	if index.Kind != IndexDefault {
+		index.Unique = false
+	}
+
+	if len(iv.shards) == 0 {
+		return iv.build(ctx, index, pool.GetMap())
+	}
+
 	iv.lock.Lock()
 	defer iv.lock.Unlock()
 
-	name := index.Name
-
-	for i, n := range iv.names {
-		if n == name {
-			iv.names = append(iv.names[:i], iv.names[i+1:]...)
-			iv.models = append(iv.models[:i], iv.models[i+1:]...)
-			iv.data = append(iv.data[:i], iv.data[i+1:]...)
+	var shared *sync.Map
+	if index.global() {
+		shared = pool.GetMap()
+	}
+	for i, shard := range iv.shards {
+		data := shared
+		if data == nil {
+			data = pool.GetMap()
+		}
+		if err := shard.build(ctx, index, data); err != nil {
+			for _, shard := range iv.shards[:i] {
+				func() {
+					shard.lock.Lock()
+					defer shard.lock.Unlock()
+
+					shard.drop(index.Name)
+				}()
+			}
+			return err
 		}
 	}
+	iv.create(index, nil)
 
-	iv.names = append(iv.names, name)
-	iv.models = append(iv.models, index)
-	iv.data = append(iv.data, pool.GetMap())
+	return nil
 }
 
 func (iv *IndexView) Drop(name string) {
+	_ = iv.DropContext(context.Background(), name)
+}
+
+func (iv *IndexView) DropContext(ctx context.Context, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	iv.lock.Lock()
+	defer iv.lock.Unlock()
+
+	for _, shard := range iv.shards {
+		func() {
+			shard.lock.Lock()
+			defer shard.lock.Unlock()
+
+			shard.drop(name)
+		}()
+	}
+	iv.drop(name)
+
+	return nil
+}
+
+func (iv *IndexView) build(ctx context.Context, index IndexModel, data *sync.Map) error {
+	if iv.source != nil {
+		iv.source.lock.Lock()
+		defer iv.source.lock.Unlock()
+	}
+
 	iv.lock.Lock()
 	defer iv.lock.Unlock()
 
-	for i, n := range iv.names {
-		if n == name {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	iv.create(index, data)
+	if iv.source == nil {
+		return nil
+	}
+
+	i := len(iv.models) - 1
+
+	var err error
+	n := 0
+	iv.source.data.Range(func(_, value any) bool {
+		if n%checkInterval == 0 {
+			if err = ctx.Err(); err != nil {
+				return false
+			}
+		}
+		n++
+
+		err = iv.insertIndex(i, value.(map[string]any))
+		return err == nil
+	})
+	if err != nil {
+		iv.drop(index.Name)
+		return err
+	}
+
+	return nil
+}
+
+func (iv *IndexView) create(index IndexModel, data *sync.Map) {
+	iv.drop(index.Name)
+
+	iv.names = append(iv.names, index.Name)
+	iv.models = append(iv.models, index)
+	iv.data = append(iv.data, data)
+}
+
+func (iv *IndexView) drop(name string) {
+	for i := len(iv.names) - 1; i >= 0; i-- {
+		if iv.names[i] == name {
 			iv.names = append(iv.names[:i], iv.names[i+1:]...)
 			iv.models = append(iv.models[:i], iv.models[i+1:]...)
 			iv.data = append(iv.data[:i], iv.data[i+1:]...)
@@ -98,7 +298,7 @@ func (iv *IndexView) insertMany(documents []map[string]any) error {
 	for i, doc := range documents {
 		if err := iv.insertOne(doc); err != nil {
 			for i--; i >= 0; i-- {
-				_ = iv.deleteOne(doc)
+				_ = iv.deleteOne(documents[i])
 			}
 			return err
 		}
@@ -112,8 +312,8 @@ func (iv *IndexView) deleteMany(documents []map[string]any) error {
 
 	for i, doc := range documents {
 		if err := iv.deleteOne(doc); err != nil {
-			for ; i >= 0; i-- {
-				_ = iv.insertOne(doc)
+			for i--; i >= 0; i-- {
+				_ = iv.insertOne(documents[i])
 			}
 			return err
 		}
@@ -125,17 +325,57 @@ func (iv *IndexView) deleteAll() {
 	iv.lock.Lock()
 	defer iv.lock.Unlock()
 
-	iv.data = nil
+	for i, model := range iv.models {
+		if len(iv.shards) == 0 {
+			iv.data[i] = pool.GetMap()
+			continue
+		}
+
+		var shared *sync.Map
+		if model.global() {
+			shared = pool.GetMap()
+		}
+		for _, shard := range iv.shards {
+			func() {
+				shard.lock.Lock()
+				defer shard.lock.Unlock()
+
+				if shared != nil {
+					shard.data[i] = shared
+				} else {
+					shard.data[i] = pool.GetMap()
+				}
+			}()
+		}
+	}
 }
 
 func (iv *IndexView) findMany(filter *Filter) ([]any, error) {
+	return iv.findManyWith(filter, nil)
+}
+
+func (iv *IndexView) findManyWith(filter *Filter, collation *Collation) ([]any, error) {
 	iv.lock.RLock()
 	defer iv.lock.RUnlock()
 
+	if ids, ok := iv.findText(filter); ok {
+		return ids, nil
+	}
+	if ids, ok := iv.findGeo(filter); ok {
+		return ids, nil
+	}
+
 	examples, ok := filterToExample(filter)
 	if !ok {
 		return nil, ErrIndexNotFound
 	}
+	for _, example := range examples {
+		for _, v := range example {
+			if _, ok := toArray(v); ok {
+				return nil, ErrIndexNotFound
+			}
+		}
+	}
 
 	ids := pool.GetMap()
 	defer pool.PutMap(ids)
@@ -143,6 +383,9 @@ func (iv *IndexView) findMany(filter *Filter) ([]any, error) {
 	for _, example := range examples {
 		if err := func() error {
 			for i, model := range iv.models {
+				if model.Kind != IndexDefault || !model.Collation.covers(collation) {
+					continue
+				}
 				curr := iv.data[i]
 
 				visits := map[string]bool{}
@@ -156,7 +399,7 @@ func (iv *IndexView) findMany(filter *Filter) ([]any, error) {
 				for i, k = range model.Keys {
 					if v, ok := example[k]; ok {
 						visits[k] = true
-						if sub, ok := curr.Load(v); ok {
+						if sub, ok := curr.Load(indexKey(model.Collation.normalize(v))); ok {
 							if i < len(model.Keys)-1 {
 								curr = sub.(*sync.Map)
 							} else {
@@ -236,125 +479,354 @@ func (iv *IndexView) findMany(filter *Filter) ([]any, error) {
 	return uniqueIds, nil
 }
 
+func (model IndexModel) global() bool {
+	return model.Unique && model.Name != indexID
+}
+
 func (iv *IndexView) insertOne(document map[string]any) error {
-	id, ok := document[keyID]
+	if _, ok := iv.pk.id(document); !ok {
+		return ErrIndexConflict
+	}
+
+	for i := range iv.models {
+		if err := iv.insertIndex(i, document); err != nil {
+			_ = iv.deleteOne(document)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (iv *IndexView) deleteOne(document map[string]any) error {
+	if _, ok := iv.pk.id(document); !ok {
+		return ErrIndexConflict
+	}
+
+	for i := range iv.models {
+		if err := iv.deleteIndex(i, document); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (iv *IndexView) insertIndex(i int, document map[string]any) error {
+	id, ok := iv.pk.id(document)
 	if !ok {
 		return ErrIndexConflict
 	}
 
-	for i, model := range iv.models {
-		if err := func() error {
-			curr := iv.data[i]
+	model := iv.models[i]
+	curr := iv.data[i]
 
-			if !parseFilter(model.Partial)(document) {
-				return nil
-			}
+	if !parseFilter(model.Partial)(document) {
+		return nil
+	}
+	if model.Kind == IndexText {
+		iv.insertText(curr, model, id, document)
+		return nil
+	}
+	if model.Kind == IndexGeo {
+		iv.insertGeo(curr, model, id, document)
+		return nil
+	}
+	if model.Kind == IndexVector {
+		return iv.insertVector(curr, model, id, document)
+	}
 
-			for i, k := range model.Keys {
-				v, ok := reflectutil.Get[any](document, k)
-				if !ok {
-					v = nil
+	if model.global() && iv.shared != nil {
+		iv.shared.Lock()
+		defer iv.shared.Unlock()
+	}
+
+	for _, key := range indexKeys(document, model.Keys, model.Collation) {
+		curr := curr
+		for i, v := range key {
+			if i < len(key)-1 {
+				cm := pool.GetMap()
+				sub, load := curr.LoadOrStore(v, cm)
+				if load {
+					pool.PutMap(cm)
 				}
-				if i < len(model.Keys)-1 {
-					cm := pool.GetMap()
-					sub, load := curr.LoadOrStore(v, cm)
-					if load {
-						pool.PutMap(cm)
-					}
-					curr = sub.(*sync.Map)
-				} else if model.Unique {
-					if r, loaded := curr.LoadOrStore(v, id); loaded && r != id {
-						return ErrIndexConflict
-					}
-				} else {
-					cm := pool.GetMap()
-					r, load := curr.LoadOrStore(v, cm)
-					if load {
-						pool.PutMap(cm)
-					}
-					r.(*sync.Map).Store(id, nil)
+				curr = sub.(*sync.Map)
+			} else if model.Unique {
+				if r, loaded := curr.LoadOrStore(v, id); loaded && r != id {
+					return ErrIndexConflict
+				}
+			} else {
+				cm := pool.GetMap()
+				r, load := curr.LoadOrStore(v, cm)
+				if load {
+					pool.PutMap(cm)
 				}
+				r.(*sync.Map).Store(id, nil)
 			}
-
-			return nil
-		}(); err != nil {
-			_ = iv.deleteOne(document)
-			return err
 		}
 	}
 
 	return nil
 }
 
-func (iv *IndexView) deleteOne(document map[string]any) error {
-	id, ok := document[keyID]
+func (iv *IndexView) deleteIndex(i int, document map[string]any) error {
+	id, ok := iv.pk.id(document)
 	if !ok {
 		return ErrIndexConflict
 	}
 
-	for i, model := range iv.models {
-		if err := func() error {
-			curr := iv.data[i]
+	model := iv.models[i]
+	curr := iv.data[i]
 
-			if !parseFilter(model.Partial)(document) {
-				return nil
+	if !parseFilter(model.Partial)(document) {
+		return nil
+	}
+	if model.Kind == IndexText {
+		iv.deleteText(curr, model, id, document)
+		return nil
+	}
+	if model.Kind == IndexGeo {
+		iv.deleteGeo(curr, model, id, document)
+		return nil
+	}
+	if model.Kind == IndexVector {
+		iv.deleteVector(curr, model, id)
+		return nil
+	}
+
+	if model.global() && iv.shared != nil {
+		iv.shared.Lock()
+		defer iv.shared.Unlock()
+	}
+
+	for _, key := range indexKeys(document, model.Keys, model.Collation) {
+		iv.deleteKey(curr, key, id, model.Unique)
+	}
+
+	return nil
+}
+
+func (iv *IndexView) deleteKey(curr *sync.Map, key []any, id any, unique bool) {
+	var nodes []*sync.Map
+	nodes = append(nodes, curr)
+	var keys []any
+	keys = append(keys, nil)
+
+	for i, v := range key {
+		if i < len(key)-1 {
+			if sub, ok := curr.Load(v); ok {
+				curr = sub.(*sync.Map)
+
+				nodes = append(nodes, curr)
+				keys = append(keys, v)
+			} else {
+				return
+			}
+		} else if unique {
+			if r, loaded := curr.Load(v); loaded && reflectutil.Equal(r, id) {
+				curr.Delete(v)
+			}
+		} else {
+			if r, loaded := curr.Load(v); loaded {
+				nodes = append(nodes, r.(*sync.Map))
+				keys = append(keys, v)
+				r.(*sync.Map).Delete(id)
 			}
+		}
+	}
 
-			var nodes []*sync.Map
-			nodes = append(nodes, curr)
-			var keys []any
-			keys = append(keys, nil)
+	for i := len(nodes) - 1; i >= 0; i-- {
+		node := nodes[i]
 
-			for i, k := range model.Keys {
-				v, ok := reflectutil.Get[any](document, k)
-				if !ok {
-					v = nil
-				}
-				if i < len(model.Keys)-1 {
-					if sub, ok := curr.Load(v); ok {
-						curr = sub.(*sync.Map)
+		empty := true
+		node.Range(func(_, _ any) bool {
+			empty = false
+			return false
+		})
 
-						nodes = append(nodes, curr)
-						keys = append(keys, v)
-					} else {
-						return nil
-					}
-				} else if model.Unique {
-					if r, loaded := curr.Load(v); loaded && reflectutil.Equal(r, id) {
-						curr.Delete(k)
-					}
-				} else {
-					if r, loaded := curr.Load(v); loaded {
-						nodes = append(nodes, r.(*sync.Map))
-						keys = append(keys, v)
-						r.(*sync.Map).Delete(id)
-					}
-				}
+		if empty && i > 0 {
+			parent := nodes[i-1]
+			key := keys[i]
+
+			parent.Delete(key)
+			pool.PutMap(node)
+		}
+	}
+}
+
+func (iv *IndexView) field(kind IndexKind, key string) (*sync.Map, bool) {
+	for i, model := range iv.models {
+		if model.Kind != kind {
+			continue
+		}
+		for _, k := range model.Keys {
+			if k != key {
+				continue
 			}
+			if sub, ok := iv.data[i].Load(key); ok {
+				return sub.(*sync.Map), true
+			}
+			return nil, true
+		}
+	}
+	return nil, false
+}
 
-			for i := len(nodes) - 1; i >= 0; i-- {
-				node := nodes[i]
+func indexKeys(document map[string]any, keys []string, collation *Collation) [][]any {
+	product := [][]any{nil}
+	for _, k := range keys {
+		values := indexValues(document, k, collation)
 
-				empty := true
-				node.Range(func(_, _ any) bool {
-					empty = false
-					return false
-				})
+		next := make([][]any, 0, len(product)*len(values))
+		for _, prefix := range product {
+			for _, v := range values {
+				next = append(next, append(prefix[:len(prefix):len(prefix)], v))
+			}
+		}
+		product = next
+	}
+	return product
+}
 
-				if empty && i > 0 {
-					parent := nodes[i-1]
-					key := keys[i]
+func indexValues(document map[string]any, key string, collation *Collation) []any {
+	var values []any
+	visits := map[any]struct{}{}
+	add := func(v any) {
+		v = indexKey(collation.normalize(v))
+		if _, ok := visits[v]; !ok {
+			visits[v] = struct{}{}
+			values = append(values, v)
+		}
+	}
 
-					parent.Delete(key)
-					pool.PutMap(node)
-				}
+	resolved, _ := resolve(document, key)
+	for _, v := range resolved {
+		if elements, ok := toArray(v); ok {
+			for _, e := range elements {
+				add(e)
 			}
+		} else {
+			add(v)
+		}
+	}
+	if len(values) == 0 {
+		values = append(values, nil)
+	}
+	return values
+}
 
-			return nil
-		}(); err != nil {
-			return err
+func indexKey(value any) any {
+	return reflectutil.Key(value)
+}
+
+func walkIndex(data *sync.Map, model IndexModel, key []any, visit func(key []any, entries int)) {
+	if model.Kind == IndexVector {
+		walkGraphs(data, visit)
+		return
+	}
+	if model.Kind != IndexDefault {
+		walkPostings(data, visit)
+		return
+	}
+	data.Range(func(k, v any) bool {
+		key := append(key[:len(key):len(key)], k)
+		if len(key) < len(model.Keys) {
+			walkIndex(v.(*sync.Map), model, key, visit)
+		} else if model.Unique {
+			visit(key, 1)
+		} else {
+			entries := 0
+			v.(*sync.Map).Range(func(_, _ any) bool {
+				entries++
+				return true
+			})
+			visit(key, entries)
+		}
+		return true
+	})
+}
+
+func walkPostings(data *sync.Map, visit func(key []any, entries int)) {
+	data.Range(func(field, value any) bool {
+		value.(*sync.Map).Range(func(term, postings any) bool {
+			if _, ok := term.(textLengths); ok {
+				return true
+			}
+			entries := 0
+			postings.(*sync.Map).Range(func(_, _ any) bool {
+				entries++
+				return true
+			})
+			visit([]any{field, term}, entries)
+			return true
+		})
+		return true
+	})
+}
+
+func loadOrStoreMap(data *sync.Map, key any) *sync.Map {
+	cm := pool.GetMap()
+	sub, load := data.LoadOrStore(key, cm)
+	if load {
+		pool.PutMap(cm)
+	}
+	return sub.(*sync.Map)
+}
+
+func isEmptyMap(data *sync.Map) bool {
+	empty := true
+	data.Range(func(_, _ any) bool {
+		empty = false
+		return false
+	})
+	return empty
+}
+
+func (pk primaryKey) id(document map[string]any) (any, bool) {
+	if len(pk) == 1 {
+		v, ok := document[pk[0]]
+		return v, ok && !util.IsNil(v)
+	}
+
+	parts := make([]any, 0, len(pk))
+	for _, k := range pk {
+		v, ok := document[k]
+		if !ok || util.IsNil(v) {
+			return nil, false
 		}
+		parts = append(parts, v)
 	}
+	return compositeKey(fmt.Sprintf("%#v", parts)), true
+}
 
-	return nil
+func (pk primaryKey) value(document map[string]any) any {
+	if len(pk) == 1 {
+		return document[pk[0]]
+	}
+
+	parts := make([]any, 0, len(pk))
+	for _, k := range pk {
+		parts = append(parts, document[k])
+	}
+	return parts
+}
+
+func (pk primaryKey) filter(document map[string]any) *Filter {
+	if len(pk) == 1 {
+		return Where(pk[0]).EQ(document[pk[0]])
+	}
+
+	var filters []*Filter
+	for _, k := range pk {
+		filters = append(filters, Where(k).EQ(document[k]))
+	}
+	return filters[0].And(filters[1:]...)
+}
+
+func (pk primaryKey) sorts() []Sort {
+	var sorts []Sort
+	for _, k := range pk {
+		sorts = append(sorts, Sort{Key: k, Order: OrderASC})
+	}
+	return sorts
 }
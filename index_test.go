@@ -1,6 +1,7 @@
 package memdb
 
 import (
+	"context"
 	"github.com/go-faker/faker/v4"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -51,6 +52,131 @@ func TestIndexView_Drop(t *testing.T) {
 	assert.Len(t, models, 1)
 }
 
+func TestIndexView_CreateContext(t *testing.T) {
+	coll := newCollection(faker.Name())
+
+	docs := []map[string]any{
+		{"id": faker.UUIDHyphenated(), "name": faker.UUIDHyphenated()},
+		{"id": faker.UUIDHyphenated(), "name": faker.UUIDHyphenated()},
+	}
+	_, err := coll.InsertMany(docs)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = coll.Indexes().CreateContext(ctx, IndexModel{Keys: []string{"name"}, Name: "name"})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Len(t, coll.Indexes().List(), 1)
+
+	err = coll.Indexes().CreateContext(context.Background(), IndexModel{Keys: []string{"name"}, Name: "name"})
+	assert.NoError(t, err)
+	assert.Len(t, coll.Indexes().List(), 2)
+
+	ids, err := coll.Indexes().findMany(Where("name").EQ(docs[0]["name"]))
+	assert.NoError(t, err)
+	assert.Equal(t, []any{docs[0]["id"]}, ids)
+
+	_, err = coll.InsertOne(map[string]any{"id": faker.UUIDHyphenated(), "name": docs[0]["name"]})
+	assert.NoError(t, err)
+
+	err = coll.Indexes().CreateContext(context.Background(), IndexModel{Keys: []string{"name"}, Name: "name_unique", Unique: true})
+	assert.ErrorIs(t, err, ErrIndexConflict)
+	assert.Len(t, coll.Indexes().List(), 2)
+}
+
+func TestIndexView_DropContext(t *testing.T) {
+	iv := newIndexView()
+
+	model := IndexModel{
+		Keys: []string{"sub_key"},
+		Name: faker.UUIDHyphenated(),
+	}
+
+	_ = iv.Create(model)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := iv.DropContext(ctx, model.Name)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Len(t, iv.List(), 2)
+
+	err = iv.DropContext(context.Background(), model.Name)
+	assert.NoError(t, err)
+	assert.Len(t, iv.List(), 1)
+}
+
+func TestIndexView_Shards(t *testing.T) {
+	iv := newShardedIndexView(2, nil)
+
+	iv.Create(IndexModel{
+		Keys:   []string{"name"},
+		Name:   "name",
+		Unique: true,
+	})
+	iv.Create(IndexModel{
+		Keys: []string{"type"},
+		Name: "type",
+	})
+
+	assert.Len(t, iv.List(), 3)
+	for _, shard := range iv.shards {
+		assert.Len(t, shard.List(), 3)
+	}
+	assert.NotSame(t, iv.shards[0].data[0], iv.shards[1].data[0])
+	assert.Same(t, iv.shards[0].data[1], iv.shards[1].data[1])
+
+	name := faker.UUIDHyphenated()
+
+	err := iv.shards[0].insertMany([]map[string]any{{"id": faker.UUIDHyphenated(), "name": name}})
+	assert.NoError(t, err)
+
+	err = iv.shards[1].insertMany([]map[string]any{{"id": faker.UUIDHyphenated(), "name": name}})
+	assert.ErrorIs(t, err, ErrIndexConflict)
+
+	iv.Drop("type")
+	assert.Len(t, iv.List(), 2)
+	for _, shard := range iv.shards {
+		assert.Len(t, shard.List(), 2)
+	}
+}
+
+func TestIndexView_Stats(t *testing.T) {
+	for _, size := range []int{0, 3} {
+		iv := newShardedIndexView(size, nil)
+
+		err := iv.Create(IndexModel{
+			Keys: []string{"group", "kind"},
+			Name: "group_kind",
+		})
+		assert.NoError(t, err)
+
+		var docs []map[string]any
+		for i := 0; i < 12; i++ {
+			docs = append(docs, map[string]any{
+				"id":    i,
+				"group": i % 2,
+				"kind":  i % 3,
+			})
+		}
+
+		if size == 0 {
+			assert.NoError(t, iv.insertMany(docs))
+		} else {
+			for _, doc := range docs {
+				assert.NoError(t, iv.shards[doc["id"].(int)%size].insertMany([]map[string]any{doc}))
+			}
+		}
+
+		stats := iv.Stats()
+		assert.Equal(t, []IndexStats{
+			{Name: "_id", Entries: 12, Keys: 12, Depth: 1},
+			{Name: "group_kind", Entries: 12, Keys: 6, Depth: 3},
+		}, stats)
+	}
+}
+
 func TestIndexView_InsertMany(t *testing.T) {
 	t.Run("error: nil", func(t *testing.T) {
 		iv := newIndexView()
@@ -406,3 +532,58 @@ func TestIndexView_FindMany(t *testing.T) {
 		assert.ErrorIs(t, err, ErrIndexNotFound)
 	})
 }
+
+func TestIndexView_Multikey(t *testing.T) {
+	iv := newIndexView()
+
+	err := iv.Create(IndexModel{Keys: []string{"tags"}, Name: "tags"})
+	assert.NoError(t, err)
+	err = iv.Create(IndexModel{Keys: []string{"sku", "sizes"}, Name: "sku_sizes", Unique: true})
+	assert.NoError(t, err)
+	err = iv.Create(IndexModel{Keys: []string{"items.name"}, Name: "items_name"})
+	assert.NoError(t, err)
+
+	docs := []map[string]any{
+		{"id": 1, "tags": []any{"a", "b"}, "sku": "x", "sizes": []any{"s", "m"}, "items": []any{map[string]any{"name": "p"}}},
+		{"id": 2, "tags": []any{"b", "c", "c"}, "sku": "x", "sizes": []any{"l"}, "items": []any{map[string]any{"name": "q"}, map[string]any{"name": "p"}}},
+		{"id": 3, "tags": []any{}, "sku": "y", "sizes": []any{map[string]any{"w": 1}}},
+	}
+	err = iv.insertMany(docs)
+	assert.NoError(t, err)
+
+	ids, err := iv.findMany(Where("tags").EQ("b"))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []any{1, 2}, ids)
+
+	ids, err = iv.findMany(Where("tags").IN("a", "c"))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []any{1, 2}, ids)
+
+	ids, err = iv.findMany(Where("items.name").EQ("p"))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []any{1, 2}, ids)
+
+	ids, err = iv.findMany(Where("sku").EQ("x").And(Where("sizes").EQ("l")))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []any{2}, ids)
+
+	ids, err = iv.findMany(Where("sku").EQ("y").And(Where("sizes").EQ(map[string]any{"w": 1})))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []any{3}, ids)
+
+	_, err = iv.findMany(Where("tags").EQ([]any{"a", "b"}))
+	assert.ErrorIs(t, err, ErrIndexNotFound)
+
+	err = iv.insertMany([]map[string]any{{"id": 4, "sku": "x", "sizes": []any{"xl", "m"}}})
+	assert.ErrorIs(t, err, ErrIndexConflict)
+
+	err = iv.deleteMany(docs[:1])
+	assert.NoError(t, err)
+
+	ids, err = iv.findMany(Where("tags").EQ("b"))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []any{2}, ids)
+
+	err = iv.insertMany([]map[string]any{{"id": 4, "sku": "x", "sizes": []any{"xl", "m"}}})
+	assert.NoError(t, err)
+}
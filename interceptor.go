@@ -0,0 +1,63 @@
+package memdb
+
+import (
+	"context"
+	"sync"
+)
+
+type (
+	OperationType string
+
+	Operation struct {
+		Collection string
+		Type       OperationType
+		Filter     *Filter
+		Documents  []map[string]any
+		Update     map[string]any
+		Options    any
+	}
+
+	Handler func(ctx context.Context, op *Operation) (any, error)
+
+	Interceptor func(ctx context.Context, op *Operation, next Handler) (any, error)
+
+	chain struct {
+		interceptors []Interceptor
+		lock         sync.RWMutex
+	}
+)
+
+const (
+	OperationInsertOne  OperationType = "insert_one"
+	OperationInsertMany OperationType = "insert_many"
+	OperationUpdateOne  OperationType = "update_one"
+	OperationUpdateMany OperationType = "update_many"
+	OperationDeleteOne  OperationType = "delete_one"
+	OperationDeleteMany OperationType = "delete_many"
+	OperationFindOne    OperationType = "find_one"
+	OperationFindMany   OperationType = "find_many"
+
+	OperationNearestNeighbors OperationType = "nearest_neighbors"
+)
+
+func (c *chain) use(interceptors ...Interceptor) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.interceptors = append(c.interceptors, interceptors...)
+}
+
+func (c *chain) intercept(ctx context.Context, op *Operation, handler Handler) (any, error) {
+	c.lock.RLock()
+	interceptors := c.interceptors
+	c.lock.RUnlock()
+
+	next := handler
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, h := interceptors[i], next
+		next = func(ctx context.Context, op *Operation) (any, error) {
+			return interceptor(ctx, op, h)
+		}
+	}
+	return next(ctx, op)
+}
@@ -0,0 +1,86 @@
+package memdb
+
+import (
+	"context"
+	"github.com/go-faker/faker/v4"
+	"github.com/pkg/errors"
+	"github.com/siyul-park/memdb/internal/util"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDatabase_Use(t *testing.T) {
+	t.Run("Modify", func(t *testing.T) {
+		db := New(faker.Word())
+		db.Use(func(ctx context.Context, op *Operation, next Handler) (any, error) {
+			for _, doc := range op.Documents {
+				doc["tenant"] = "a"
+			}
+			if op.Type == OperationFindMany || op.Type == OperationFindOne {
+				op.Filter = Where("tenant").EQ("a").And(op.Filter)
+			}
+			return next(ctx, op)
+		})
+
+		coll := db.Collection(faker.UUIDHyphenated())
+
+		_, err := coll.InsertOne(map[string]any{"id": 1})
+		assert.NoError(t, err)
+
+		doc, err := coll.FindOne(Where("id").EQ(1))
+		assert.NoError(t, err)
+		assert.Equal(t, "a", doc["tenant"])
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		denied := errors.New("denied")
+
+		db := New(faker.Word())
+		db.Use(func(ctx context.Context, op *Operation, next Handler) (any, error) {
+			if op.Type == OperationDeleteMany {
+				return nil, denied
+			}
+			return next(ctx, op)
+		})
+
+		coll := db.Collection(faker.UUIDHyphenated())
+
+		_, err := coll.InsertOne(map[string]any{"id": 1})
+		assert.NoError(t, err)
+
+		count, err := coll.DeleteMany(nil)
+		assert.ErrorIs(t, err, denied)
+		assert.Equal(t, 0, count)
+
+		docs, err := coll.FindMany(nil)
+		assert.NoError(t, err)
+		assert.Len(t, docs, 1)
+	})
+
+	t.Run("Observe", func(t *testing.T) {
+		var trace []string
+
+		db := New(faker.Word(), &DatabaseOptions{
+			Interceptors: []Interceptor{
+				func(ctx context.Context, op *Operation, next Handler) (any, error) {
+					trace = append(trace, "outer:before:"+string(op.Type))
+					r, err := next(ctx, op)
+					trace = append(trace, "outer:after")
+					return r, err
+				},
+			},
+		})
+		db.Use(func(ctx context.Context, op *Operation, next Handler) (any, error) {
+			trace = append(trace, "inner:"+op.Collection)
+			return next(ctx, op)
+		})
+
+		coll := db.Collection("users")
+
+		ok, err := coll.UpdateOne(Where("id").EQ(1), map[string]any{"name": "a"}, &UpdateOptions{Upsert: util.Ptr(true)})
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		assert.Equal(t, []string{"outer:before:update_one", "inner:users", "outer:after"}, trace)
+	})
+}
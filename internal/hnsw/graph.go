@@ -0,0 +1,356 @@
+package hnsw
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+type (
+	Graph struct {
+		distance       func(x, y []float64) float64
+		m              int
+		efConstruction int
+		levelMult      float64
+		nodes          map[any]*node
+		entry          *node
+		random         *rand.Rand
+		lock           sync.RWMutex
+	}
+
+	Result struct {
+		ID       any
+		Distance float64
+	}
+
+	node struct {
+		id      any
+		vector  []float64
+		friends [][]*node
+		deleted bool
+	}
+
+	candidate struct {
+		node     *node
+		distance float64
+	}
+
+	minHeap []candidate
+	maxHeap []candidate
+)
+
+var _ heap.Interface = (*minHeap)(nil)
+var _ heap.Interface = (*maxHeap)(nil)
+
+const (
+	defaultM              = 16
+	defaultEfConstruction = 200
+)
+
+func New(distance func(x, y []float64) float64, m, efConstruction int) *Graph {
+	if m < 2 {
+		m = defaultM
+	}
+	if efConstruction < m {
+		efConstruction = defaultEfConstruction
+	}
+	return &Graph{
+		distance:       distance,
+		m:              m,
+		efConstruction: efConstruction,
+		levelMult:      1 / math.Log(float64(m)),
+		nodes:          map[any]*node{},
+		random:         rand.New(rand.NewSource(1)),
+	}
+}
+
+func (g *Graph) Len() int {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	return len(g.nodes)
+}
+
+func (g *Graph) Dimensions() int {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if g.entry == nil {
+		return 0
+	}
+	return len(g.entry.vector)
+}
+
+func (g *Graph) Insert(id any, vector []float64) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if _, ok := g.nodes[id]; ok {
+		g.delete(id)
+	}
+
+	level := int(-math.Log(1-g.random.Float64()) * g.levelMult)
+	n := &node{id: id, vector: vector, friends: make([][]*node, level+1)}
+	g.nodes[id] = n
+
+	if g.entry == nil {
+		g.entry = n
+		return
+	}
+
+	ep := g.entry
+	top := len(ep.friends) - 1
+	for l := top; l > level; l-- {
+		ep = g.greedy(vector, ep, l)
+	}
+	for l := min(top, level); l >= 0; l-- {
+		candidates := g.search(vector, ep, g.efConstruction, l, nil)
+		neighbors := candidates
+		if len(neighbors) > g.m {
+			neighbors = neighbors[:g.m]
+		}
+		for _, c := range neighbors {
+			n.friends[l] = append(n.friends[l], c.node)
+			c.node.friends[l] = append(c.node.friends[l], n)
+			if limit := g.limit(l); len(c.node.friends[l]) > limit {
+				c.node.friends[l] = g.closest(c.node, c.node.friends[l], limit)
+			}
+		}
+		if len(candidates) > 0 {
+			ep = candidates[0].node
+		}
+	}
+
+	if level > top {
+		g.entry = n
+	}
+}
+
+func (g *Graph) Delete(id any) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.delete(id)
+}
+
+func (g *Graph) Search(query []float64, k, ef int, filter func(id any) bool) []Result {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if g.entry == nil || k <= 0 {
+		return nil
+	}
+	if ef < k {
+		ef = k
+	}
+
+	ep := g.entry
+	for l := len(ep.friends) - 1; l > 0; l-- {
+		ep = g.greedy(query, ep, l)
+	}
+
+	candidates := g.search(query, ep, ef, 0, func(n *node) bool {
+		return filter == nil || filter(n.id)
+	})
+	if len(candidates) > k {
+		candidates = candidates[:k]
+	}
+
+	results := make([]Result, 0, len(candidates))
+	for _, c := range candidates {
+		results = append(results, Result{ID: c.node.id, Distance: c.distance})
+	}
+	return results
+}
+
+func (g *Graph) delete(id any) {
+	n, ok := g.nodes[id]
+	if !ok {
+		return
+	}
+	delete(g.nodes, id)
+	n.deleted = true
+
+	for l, friends := range n.friends {
+		for _, f := range friends {
+			f.friends[l] = remove(f.friends[l], n)
+		}
+		for _, f := range friends {
+			var candidates []*node
+			candidates = append(candidates, f.friends[l]...)
+			for _, c := range friends {
+				if c != f && !contains(candidates, c) {
+					candidates = append(candidates, c)
+				}
+			}
+			f.friends[l] = g.closest(f, candidates, g.limit(l))
+		}
+	}
+
+	if g.entry == n {
+		g.entry = nil
+		for _, c := range g.nodes {
+			if g.entry == nil || len(c.friends) > len(g.entry.friends) {
+				g.entry = c
+			}
+		}
+	}
+}
+
+func (g *Graph) greedy(query []float64, ep *node, level int) *node {
+	curr := ep
+	dist := g.distance(query, curr.vector)
+	for changed := true; changed; {
+		changed = false
+		for _, f := range curr.friends[level] {
+			if d := g.distance(query, f.vector); d < dist {
+				curr, dist, changed = f, d, true
+			}
+		}
+	}
+	return curr
+}
+
+func (g *Graph) search(query []float64, ep *node, ef, level int, accept func(*node) bool) []candidate {
+	visits := map[*node]struct{}{ep: {}}
+
+	start := candidate{node: ep, distance: g.distance(query, ep.vector)}
+	candidates := &minHeap{start}
+	results := &maxHeap{}
+	if !ep.deleted && (accept == nil || accept(ep)) {
+		heap.Push(results, start)
+	}
+
+	for candidates.Len() > 0 {
+		c := heap.Pop(candidates).(candidate)
+		if results.Len() >= ef && c.distance > (*results)[0].distance {
+			break
+		}
+
+		for _, f := range c.node.friends[level] {
+			if _, ok := visits[f]; ok {
+				continue
+			}
+			visits[f] = struct{}{}
+
+			d := g.distance(query, f.vector)
+			if results.Len() < ef || d < (*results)[0].distance {
+				heap.Push(candidates, candidate{node: f, distance: d})
+				if !f.deleted && (accept == nil || accept(f)) {
+					heap.Push(results, candidate{node: f, distance: d})
+					if results.Len() > ef {
+						heap.Pop(results)
+					}
+				}
+			}
+		}
+	}
+
+	sorted := make([]candidate, len(*results))
+	copy(sorted, *results)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].distance < sorted[j].distance
+	})
+	return sorted
+}
+
+func (g *Graph) closest(n *node, nodes []*node, limit int) []*node {
+	candidates := make([]candidate, 0, len(nodes))
+	for _, c := range nodes {
+		if c != n && !c.deleted {
+			candidates = append(candidates, candidate{node: c, distance: g.distance(n.vector, c.vector)})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	closest := make([]*node, 0, len(candidates))
+	for _, c := range candidates {
+		closest = append(closest, c.node)
+	}
+	return closest
+}
+
+func (g *Graph) limit(level int) int {
+	if level == 0 {
+		return 2 * g.m
+	}
+	return g.m
+}
+
+func remove(nodes []*node, n *node) []*node {
+	for i, c := range nodes {
+		if c == n {
+			return append(nodes[:i:i], nodes[i+1:]...)
+		}
+	}
+	return nodes
+}
+
+func contains(nodes []*node, n *node) bool {
+	for _, c := range nodes {
+		if c == n {
+			return true
+		}
+	}
+	return false
+}
+
+func min(x, y int) int {
+	if x < y {
+		return x
+	}
+	return y
+}
+
+func (h *minHeap) Len() int {
+	return len(*h)
+}
+
+func (h *minHeap) Less(i, j int) bool {
+	return (*h)[i].distance < (*h)[j].distance
+}
+
+func (h *minHeap) Swap(i, j int) {
+	(*h)[i], (*h)[j] = (*h)[j], (*h)[i]
+}
+
+func (h *minHeap) Push(x any) {
+	*h = append(*h, x.(candidate))
+}
+
+func (h *minHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+func (h *maxHeap) Len() int {
+	return len(*h)
+}
+
+func (h *maxHeap) Less(i, j int) bool {
+	return (*h)[i].distance > (*h)[j].distance
+}
+
+func (h *maxHeap) Swap(i, j int) {
+	(*h)[i], (*h)[j] = (*h)[j], (*h)[i]
+}
+
+func (h *maxHeap) Push(x any) {
+	*h = append(*h, x.(candidate))
+}
+
+func (h *maxHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
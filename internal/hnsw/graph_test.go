@@ -0,0 +1,105 @@
+package hnsw
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestGraph_Search(t *testing.T) {
+	g := New(l2, 8, 64)
+
+	random := rand.New(rand.NewSource(42))
+	vectors := map[int][]float64{}
+	for i := 0; i < 500; i++ {
+		v := []float64{random.Float64(), random.Float64(), random.Float64(), random.Float64()}
+		vectors[i] = v
+		g.Insert(i, v)
+	}
+	assert.Equal(t, 500, g.Len())
+
+	query := []float64{0.5, 0.5, 0.5, 0.5}
+	results := g.Search(query, 10, 100, nil)
+	assert.Len(t, results, 10)
+
+	expected := bruteForce(vectors, query, 10)
+	hits := 0
+	for _, r := range results {
+		for _, e := range expected {
+			if r.ID == e {
+				hits++
+			}
+		}
+	}
+	assert.GreaterOrEqual(t, hits, 9)
+
+	for i := 1; i < len(results); i++ {
+		assert.LessOrEqual(t, results[i-1].Distance, results[i].Distance)
+	}
+}
+
+func TestGraph_Search_Filter(t *testing.T) {
+	g := New(l2, 8, 64)
+
+	for i := 0; i < 100; i++ {
+		g.Insert(i, []float64{float64(i)})
+	}
+
+	results := g.Search([]float64{50}, 3, 10, func(id any) bool {
+		return id.(int)%2 == 1
+	})
+	assert.Len(t, results, 3)
+	for _, r := range results {
+		assert.Equal(t, 1, r.ID.(int)%2)
+	}
+	assert.Contains(t, []any{49, 51}, results[0].ID)
+}
+
+func TestGraph_Delete(t *testing.T) {
+	g := New(l2, 4, 16)
+
+	for i := 0; i < 50; i++ {
+		g.Insert(i, []float64{float64(i)})
+	}
+	for i := 0; i < 50; i += 2 {
+		g.Delete(i)
+	}
+	assert.Equal(t, 25, g.Len())
+
+	results := g.Search([]float64{10}, 2, 10, nil)
+	assert.Len(t, results, 2)
+	assert.ElementsMatch(t, []any{9, 11}, []any{results[0].ID, results[1].ID})
+
+	for i := 1; i < 50; i += 2 {
+		g.Delete(i)
+	}
+	assert.Equal(t, 0, g.Len())
+	assert.Len(t, g.Search([]float64{10}, 2, 10, nil), 0)
+}
+
+func l2(x, y []float64) float64 {
+	sum := 0.0
+	for i := range x {
+		d := x[i] - y[i]
+		sum += d * d
+	}
+	return math.Sqrt(sum)
+}
+
+func bruteForce(vectors map[int][]float64, query []float64, k int) []any {
+	var ids []int
+	for id := range vectors {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return l2(vectors[ids[i]], query) < l2(vectors[ids[j]], query)
+	})
+
+	var result []any
+	for _, id := range ids[:k] {
+		result = append(result, id)
+	}
+	return result
+}
@@ -0,0 +1,117 @@
+package raft
+
+type (
+	raftLog struct {
+		snapshot  *Snapshot
+		entries   []Entry
+		committed uint64
+		applied   uint64
+	}
+)
+
+func newRaftLog() *raftLog {
+	return &raftLog{
+		snapshot: &Snapshot{},
+	}
+}
+
+func (l *raftLog) firstIndex() uint64 {
+	return l.snapshot.Index + 1
+}
+
+func (l *raftLog) lastIndex() uint64 {
+	return l.snapshot.Index + uint64(len(l.entries))
+}
+
+func (l *raftLog) lastTerm() uint64 {
+	t, _ := l.term(l.lastIndex())
+	return t
+}
+
+func (l *raftLog) term(index uint64) (uint64, bool) {
+	if index == l.snapshot.Index {
+		return l.snapshot.Term, true
+	}
+	if index < l.snapshot.Index || index > l.lastIndex() {
+		return 0, false
+	}
+	return l.entries[index-l.snapshot.Index-1].Term, true
+}
+
+func (l *raftLog) entry(index uint64) (Entry, bool) {
+	if index <= l.snapshot.Index || index > l.lastIndex() {
+		return Entry{}, false
+	}
+	return l.entries[index-l.snapshot.Index-1], true
+}
+
+func (l *raftLog) slice(lo, hi uint64) []Entry {
+	if lo < l.firstIndex() || lo >= hi {
+		return nil
+	}
+	if hi > l.lastIndex()+1 {
+		hi = l.lastIndex() + 1
+	}
+	entries := make([]Entry, hi-lo)
+	copy(entries, l.entries[lo-l.snapshot.Index-1:hi-l.snapshot.Index-1])
+	return entries
+}
+
+func (l *raftLog) matchTerm(index, term uint64) bool {
+	t, ok := l.term(index)
+	return ok && t == term
+}
+
+func (l *raftLog) isUpToDate(index, term uint64) bool {
+	return term > l.lastTerm() || (term == l.lastTerm() && index >= l.lastIndex())
+}
+
+func (l *raftLog) append(entries ...Entry) uint64 {
+	for i, e := range entries {
+		if e.Index <= l.snapshot.Index {
+			continue
+		}
+		if t, ok := l.term(e.Index); ok {
+			if t == e.Term {
+				continue
+			}
+			l.entries = l.entries[:e.Index-l.snapshot.Index-1]
+		}
+		l.entries = append(l.entries, entries[i:]...)
+		break
+	}
+	if len(entries) == 0 {
+		return l.lastIndex()
+	}
+	return entries[len(entries)-1].Index
+}
+
+func (l *raftLog) commitTo(index uint64) bool {
+	if index > l.lastIndex() {
+		index = l.lastIndex()
+	}
+	if index <= l.committed {
+		return false
+	}
+	l.committed = index
+	return true
+}
+
+func (l *raftLog) compact(snapshot *Snapshot) {
+	if snapshot.Index <= l.snapshot.Index {
+		return
+	}
+	if snapshot.Index >= l.lastIndex() {
+		l.entries = nil
+	} else {
+		l.entries = append([]Entry(nil), l.entries[snapshot.Index-l.snapshot.Index:]...)
+	}
+	l.snapshot = snapshot
+}
+
+func (l *raftLog) restore(snapshot *Snapshot) {
+	l.snapshot = snapshot
+	l.entries = nil
+	l.committed = snapshot.Index
+	l.applied = snapshot.Index
+}
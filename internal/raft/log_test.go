@@ -0,0 +1,60 @@
+package raft
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRaftLog_Append(t *testing.T) {
+	l := newRaftLog()
+
+	last := l.append(Entry{Term: 1, Index: 1}, Entry{Term: 1, Index: 2}, Entry{Term: 1, Index: 3})
+	assert.Equal(t, uint64(3), last)
+	assert.Equal(t, uint64(3), l.lastIndex())
+
+	last = l.append(Entry{Term: 2, Index: 2})
+	assert.Equal(t, uint64(2), last)
+	assert.Equal(t, uint64(2), l.lastIndex())
+	assert.Equal(t, uint64(2), l.lastTerm())
+}
+
+func TestRaftLog_Slice(t *testing.T) {
+	l := newRaftLog()
+	l.append(Entry{Term: 1, Index: 1}, Entry{Term: 1, Index: 2}, Entry{Term: 2, Index: 3})
+
+	entries := l.slice(2, 10)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, uint64(2), entries[0].Index)
+	assert.Equal(t, uint64(3), entries[1].Index)
+}
+
+func TestRaftLog_Compact(t *testing.T) {
+	l := newRaftLog()
+	l.append(Entry{Term: 1, Index: 1}, Entry{Term: 1, Index: 2}, Entry{Term: 2, Index: 3})
+
+	l.compact(&Snapshot{Index: 2, Term: 1})
+
+	assert.Equal(t, uint64(3), l.firstIndex())
+	assert.Equal(t, uint64(3), l.lastIndex())
+
+	_, ok := l.term(1)
+	assert.False(t, ok)
+
+	term, ok := l.term(2)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), term)
+
+	e, ok := l.entry(3)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(2), e.Term)
+}
+
+func TestRaftLog_IsUpToDate(t *testing.T) {
+	l := newRaftLog()
+	l.append(Entry{Term: 1, Index: 1}, Entry{Term: 2, Index: 2})
+
+	assert.True(t, l.isUpToDate(2, 2))
+	assert.True(t, l.isUpToDate(1, 3))
+	assert.False(t, l.isUpToDate(3, 1))
+	assert.False(t, l.isUpToDate(1, 2))
+}
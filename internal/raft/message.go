@@ -0,0 +1,58 @@
+package raft
+
+type (
+	Message struct {
+		Type       MessageType
+		From       string
+		To         string
+		Term       uint64
+		LogIndex   uint64
+		LogTerm    uint64
+		Entries    []Entry
+		Commit     uint64
+		Index      uint64
+		Reject     bool
+		RejectHint uint64
+		Snapshot   *Snapshot
+	}
+	MessageType int
+
+	Entry struct {
+		Term  uint64
+		Index uint64
+		Type  EntryType
+		Data  any
+	}
+	EntryType int
+
+	ConfChange struct {
+		Type ConfChangeType
+		ID   string
+	}
+	ConfChangeType int
+
+	Snapshot struct {
+		Index   uint64
+		Term    uint64
+		Members []string
+		Data    any
+	}
+)
+
+const (
+	MsgVote MessageType = iota
+	MsgVoteResp
+	MsgApp
+	MsgAppResp
+	MsgSnap
+)
+
+const (
+	EntryNormal EntryType = iota
+	EntryConfChange
+)
+
+const (
+	ConfChangeAddNode ConfChangeType = iota
+	ConfChangeRemoveNode
+)
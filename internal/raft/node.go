@@ -0,0 +1,690 @@
+package raft
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+type (
+	Node struct {
+		id        string
+		config    Config
+		transport Transport
+		sm        StateMachine
+
+		inbox     chan Message
+		proposals chan *proposal
+		done      chan struct{}
+		wait      sync.WaitGroup
+
+		state            State
+		term             uint64
+		vote             string
+		leader           string
+		members          map[string]bool
+		log              *raftLog
+		votes            map[string]bool
+		next             map[string]uint64
+		match            map[string]uint64
+		electionElapsed  int
+		heartbeatElapsed int
+		electionTimeout  int
+		pending          map[uint64]*proposal
+		pendingConf      uint64
+		rand             *rand.Rand
+
+		status     Status
+		statusLock sync.RWMutex
+	}
+
+	Config struct {
+		ID                string
+		Peers             []string
+		Transport         Transport
+		StateMachine      StateMachine
+		TickInterval      time.Duration
+		ElectionTick      int
+		HeartbeatTick     int
+		SnapshotThreshold int
+		MaxEntries        int
+	}
+
+	StateMachine interface {
+		Apply(data any) any
+		Snapshot() (any, error)
+		Restore(data any) error
+	}
+
+	Status struct {
+		ID      string
+		State   State
+		Term    uint64
+		Leader  string
+		Members []string
+		Commit  uint64
+		Applied uint64
+	}
+
+	State int
+
+	proposal struct {
+		typ    EntryType
+		data   any
+		term   uint64
+		result chan proposalResult
+	}
+
+	proposalResult struct {
+		value any
+		err   error
+	}
+)
+
+const (
+	StateFollower State = iota
+	StateCandidate
+	StateLeader
+)
+
+var (
+	ErrCodeNotLeader         = "not_leader"
+	ErrCodeProposalDropped   = "proposal_dropped"
+	ErrCodeConfChangePending = "conf_change_pending"
+	ErrCodeStopped           = "stopped"
+
+	ErrNotLeader         = errors.New(ErrCodeNotLeader)
+	ErrProposalDropped   = errors.New(ErrCodeProposalDropped)
+	ErrConfChangePending = errors.New(ErrCodeConfChangePending)
+	ErrStopped           = errors.New(ErrCodeStopped)
+)
+
+func NewNode(config Config) *Node {
+	if config.TickInterval <= 0 {
+		config.TickInterval = 10 * time.Millisecond
+	}
+	if config.HeartbeatTick <= 0 {
+		config.HeartbeatTick = 1
+	}
+	if config.ElectionTick <= config.HeartbeatTick {
+		config.ElectionTick = config.HeartbeatTick * 10
+	}
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = 64
+	}
+
+	n := &Node{
+		id:        config.ID,
+		config:    config,
+		transport: config.Transport,
+		sm:        config.StateMachine,
+		inbox:     make(chan Message, 1024),
+		proposals: make(chan *proposal, 64),
+		done:      make(chan struct{}),
+		state:     StateFollower,
+		members:   map[string]bool{},
+		log:       newRaftLog(),
+		votes:     map[string]bool{},
+		next:      map[string]uint64{},
+		match:     map[string]uint64{},
+		pending:   map[uint64]*proposal{},
+		rand:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	peers := append([]string(nil), config.Peers...)
+	sort.Strings(peers)
+	for i, peer := range peers {
+		n.log.entries = append(n.log.entries, Entry{
+			Term:  1,
+			Index: uint64(i + 1),
+			Type:  EntryConfChange,
+			Data:  ConfChange{Type: ConfChangeAddNode, ID: peer},
+		})
+	}
+	if len(peers) > 0 {
+		n.term = 1
+		n.log.commitTo(n.log.lastIndex())
+		n.applyCommitted()
+	}
+
+	n.resetElectionTimeout()
+	n.publish()
+
+	n.transport.Bind(n.id, n.inbox)
+
+	n.wait.Add(1)
+	go n.run()
+
+	return n
+}
+
+func (n *Node) ID() string {
+	return n.id
+}
+
+func (n *Node) Status() Status {
+	n.statusLock.RLock()
+	defer n.statusLock.RUnlock()
+
+	status := n.status
+	status.Members = append([]string(nil), n.status.Members...)
+	return status
+}
+
+func (n *Node) Propose(ctx context.Context, data any) (any, error) {
+	return n.propose(ctx, EntryNormal, data)
+}
+
+func (n *Node) ProposeConfChange(ctx context.Context, cc ConfChange) error {
+	_, err := n.propose(ctx, EntryConfChange, cc)
+	return err
+}
+
+func (n *Node) Stop() {
+	select {
+	case <-n.done:
+		return
+	default:
+	}
+
+	n.transport.Unbind(n.id)
+	close(n.done)
+	n.wait.Wait()
+}
+
+func (n *Node) propose(ctx context.Context, typ EntryType, data any) (any, error) {
+	p := &proposal{
+		typ:    typ,
+		data:   data,
+		result: make(chan proposalResult, 1),
+	}
+
+	select {
+	case n.proposals <- p:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-n.done:
+		return nil, ErrStopped
+	}
+
+	select {
+	case r := <-p.result:
+		return r.value, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-n.done:
+		return nil, ErrStopped
+	}
+}
+
+func (n *Node) run() {
+	defer n.wait.Done()
+
+	ticker := time.NewTicker(n.config.TickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-n.done:
+			n.dropPending(ErrStopped)
+			return
+		case <-ticker.C:
+			n.tick()
+		case msg := <-n.inbox:
+			n.step(msg)
+		case p := <-n.proposals:
+			n.handleProposal(p)
+		}
+		n.publish()
+	}
+}
+
+func (n *Node) tick() {
+	if n.state == StateLeader {
+		n.heartbeatElapsed++
+		if n.heartbeatElapsed >= n.config.HeartbeatTick {
+			n.heartbeatElapsed = 0
+			n.broadcastAppend()
+		}
+		return
+	}
+
+	n.electionElapsed++
+	if n.electionElapsed >= n.electionTimeout && n.members[n.id] {
+		n.campaign()
+	}
+}
+
+func (n *Node) campaign() {
+	n.becomeCandidate()
+	if n.poll() {
+		n.becomeLeader()
+		return
+	}
+
+	for id := range n.members {
+		if id == n.id {
+			continue
+		}
+		n.send(Message{
+			Type:     MsgVote,
+			To:       id,
+			LogIndex: n.log.lastIndex(),
+			LogTerm:  n.log.lastTerm(),
+		})
+	}
+}
+
+func (n *Node) poll() bool {
+	granted := 0
+	for id, ok := range n.votes {
+		if ok && n.members[id] {
+			granted++
+		}
+	}
+	return granted >= n.quorum()
+}
+
+func (n *Node) quorum() int {
+	return len(n.members)/2 + 1
+}
+
+func (n *Node) becomeFollower(term uint64, leader string) {
+	if n.state == StateLeader {
+		n.dropPending(ErrProposalDropped)
+	}
+	if term != n.term {
+		n.vote = ""
+	}
+	n.state = StateFollower
+	n.term = term
+	n.leader = leader
+	n.electionElapsed = 0
+	n.resetElectionTimeout()
+}
+
+func (n *Node) becomeCandidate() {
+	n.state = StateCandidate
+	n.term++
+	n.vote = n.id
+	n.leader = ""
+	n.votes = map[string]bool{n.id: true}
+	n.electionElapsed = 0
+	n.resetElectionTimeout()
+}
+
+func (n *Node) becomeLeader() {
+	n.state = StateLeader
+	n.leader = n.id
+	n.heartbeatElapsed = 0
+	n.next = map[string]uint64{}
+	n.match = map[string]uint64{}
+	for id := range n.members {
+		n.next[id] = n.log.lastIndex() + 1
+		n.match[id] = 0
+	}
+	n.pendingConf = n.log.lastIndex()
+
+	n.appendEntry(Entry{Type: EntryNormal})
+}
+
+func (n *Node) resetElectionTimeout() {
+	n.electionTimeout = n.config.ElectionTick + n.rand.Intn(n.config.ElectionTick)
+}
+
+func (n *Node) step(msg Message) {
+	if msg.Term > n.term {
+		leader := ""
+		if msg.Type == MsgApp || msg.Type == MsgSnap {
+			leader = msg.From
+		}
+		n.becomeFollower(msg.Term, leader)
+	}
+
+	if msg.Term < n.term {
+		switch msg.Type {
+		case MsgVote:
+			n.send(Message{Type: MsgVoteResp, To: msg.From, Reject: true})
+		case MsgApp, MsgSnap:
+			n.send(Message{Type: MsgAppResp, To: msg.From, Reject: true})
+		}
+		return
+	}
+
+	switch msg.Type {
+	case MsgVote:
+		n.handleVote(msg)
+	case MsgVoteResp:
+		n.handleVoteResp(msg)
+	case MsgApp:
+		n.handleAppend(msg)
+	case MsgAppResp:
+		n.handleAppendResp(msg)
+	case MsgSnap:
+		n.handleSnapshot(msg)
+	}
+}
+
+func (n *Node) handleVote(msg Message) {
+	grant := (n.vote == "" || n.vote == msg.From) && n.leader == "" && n.log.isUpToDate(msg.LogIndex, msg.LogTerm)
+	if grant {
+		n.vote = msg.From
+		n.electionElapsed = 0
+	}
+	n.send(Message{Type: MsgVoteResp, To: msg.From, Reject: !grant})
+}
+
+func (n *Node) handleVoteResp(msg Message) {
+	if n.state != StateCandidate {
+		return
+	}
+
+	n.votes[msg.From] = !msg.Reject
+	if n.poll() {
+		n.becomeLeader()
+		return
+	}
+
+	rejected := 0
+	for id, ok := range n.votes {
+		if !ok && n.members[id] {
+			rejected++
+		}
+	}
+	if rejected >= n.quorum() {
+		n.becomeFollower(n.term, "")
+	}
+}
+
+func (n *Node) handleAppend(msg Message) {
+	if n.state != StateFollower {
+		n.becomeFollower(n.term, msg.From)
+	}
+	n.leader = msg.From
+	n.electionElapsed = 0
+
+	if msg.LogIndex < n.log.committed {
+		n.send(Message{Type: MsgAppResp, To: msg.From, Index: n.log.committed})
+		return
+	}
+	if !n.log.matchTerm(msg.LogIndex, msg.LogTerm) {
+		n.send(Message{Type: MsgAppResp, To: msg.From, Index: msg.LogIndex, Reject: true, RejectHint: n.log.lastIndex()})
+		return
+	}
+
+	last := n.log.append(msg.Entries...)
+	if len(msg.Entries) == 0 {
+		last = msg.LogIndex
+	}
+	commit := msg.Commit
+	if commit > last {
+		commit = last
+	}
+	if n.log.commitTo(commit) {
+		n.applyCommitted()
+	}
+
+	n.send(Message{Type: MsgAppResp, To: msg.From, Index: last})
+}
+
+func (n *Node) handleAppendResp(msg Message) {
+	if n.state != StateLeader {
+		return
+	}
+	if _, ok := n.next[msg.From]; !ok {
+		return
+	}
+
+	if msg.Reject {
+		next := n.next[msg.From] - 1
+		if msg.RejectHint+1 < next {
+			next = msg.RejectHint + 1
+		}
+		if next <= n.match[msg.From] {
+			next = n.match[msg.From] + 1
+		}
+		n.next[msg.From] = next
+		n.sendAppend(msg.From)
+		return
+	}
+
+	if msg.Index > n.match[msg.From] {
+		n.match[msg.From] = msg.Index
+	}
+	if msg.Index+1 > n.next[msg.From] {
+		n.next[msg.From] = msg.Index + 1
+	}
+	n.maybeCommit()
+
+	if n.state == StateLeader && n.next[msg.From] <= n.log.lastIndex() {
+		n.sendAppend(msg.From)
+	}
+}
+
+func (n *Node) handleSnapshot(msg Message) {
+	if n.state != StateFollower {
+		n.becomeFollower(n.term, msg.From)
+	}
+	n.leader = msg.From
+	n.electionElapsed = 0
+
+	snapshot := msg.Snapshot
+	if snapshot == nil || snapshot.Index <= n.log.committed {
+		n.send(Message{Type: MsgAppResp, To: msg.From, Index: n.log.committed})
+		return
+	}
+
+	if err := n.sm.Restore(snapshot.Data); err != nil {
+		n.send(Message{Type: MsgAppResp, To: msg.From, Index: n.log.committed})
+		return
+	}
+	n.log.restore(snapshot)
+	n.members = map[string]bool{}
+	for _, id := range snapshot.Members {
+		n.members[id] = true
+	}
+
+	n.send(Message{Type: MsgAppResp, To: msg.From, Index: snapshot.Index})
+}
+
+func (n *Node) handleProposal(p *proposal) {
+	if n.state != StateLeader {
+		p.result <- proposalResult{err: ErrNotLeader}
+		return
+	}
+	if p.typ == EntryConfChange {
+		if n.pendingConf > n.log.applied {
+			p.result <- proposalResult{err: ErrConfChangePending}
+			return
+		}
+		n.pendingConf = n.log.lastIndex() + 1
+	}
+
+	p.term = n.term
+	index := n.appendEntry(Entry{Type: p.typ, Data: p.data})
+	n.pending[index] = p
+}
+
+func (n *Node) appendEntry(e Entry) uint64 {
+	e.Term = n.term
+	e.Index = n.log.lastIndex() + 1
+	n.log.append(e)
+	n.match[n.id] = e.Index
+	n.next[n.id] = e.Index + 1
+
+	n.broadcastAppend()
+	n.maybeCommit()
+
+	return e.Index
+}
+
+func (n *Node) broadcastAppend() {
+	for id := range n.members {
+		if id != n.id {
+			n.sendAppend(id)
+		}
+	}
+}
+
+func (n *Node) sendAppend(to string) {
+	next, ok := n.next[to]
+	if !ok {
+		return
+	}
+
+	prev := next - 1
+	prevTerm, ok := n.log.term(prev)
+	if !ok {
+		n.send(Message{Type: MsgSnap, To: to, Snapshot: n.log.snapshot})
+		return
+	}
+
+	hi := next + uint64(n.config.MaxEntries)
+	n.send(Message{
+		Type:     MsgApp,
+		To:       to,
+		LogIndex: prev,
+		LogTerm:  prevTerm,
+		Entries:  n.log.slice(next, hi),
+		Commit:   n.log.committed,
+	})
+}
+
+func (n *Node) maybeCommit() {
+	if n.state != StateLeader || len(n.members) == 0 {
+		return
+	}
+
+	var matches []uint64
+	for id := range n.members {
+		matches = append(matches, n.match[id])
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i] > matches[j]
+	})
+
+	index := matches[n.quorum()-1]
+	if t, ok := n.log.term(index); !ok || t != n.term {
+		return
+	}
+	if n.log.commitTo(index) {
+		n.applyCommitted()
+		n.broadcastAppend()
+	}
+}
+
+func (n *Node) applyCommitted() {
+	for n.log.applied < n.log.committed {
+		index := n.log.applied + 1
+		e, _ := n.log.entry(index)
+
+		var value any
+		switch e.Type {
+		case EntryNormal:
+			if e.Data != nil {
+				value = n.sm.Apply(e.Data)
+			}
+		case EntryConfChange:
+			if cc, ok := e.Data.(ConfChange); ok {
+				n.applyConfChange(cc)
+			}
+		}
+		n.log.applied = index
+
+		if p, ok := n.pending[index]; ok {
+			delete(n.pending, index)
+			if p.term == e.Term {
+				p.result <- proposalResult{value: value}
+			} else {
+				p.result <- proposalResult{err: ErrProposalDropped}
+			}
+		}
+	}
+
+	n.maybeSnapshot()
+}
+
+func (n *Node) applyConfChange(cc ConfChange) {
+	switch cc.Type {
+	case ConfChangeAddNode:
+		n.members[cc.ID] = true
+		if n.state == StateLeader {
+			if _, ok := n.next[cc.ID]; !ok {
+				n.next[cc.ID] = n.log.lastIndex() + 1
+				n.match[cc.ID] = 0
+			}
+		}
+	case ConfChangeRemoveNode:
+		delete(n.members, cc.ID)
+		delete(n.next, cc.ID)
+		delete(n.match, cc.ID)
+		if cc.ID == n.id && n.state != StateFollower {
+			n.becomeFollower(n.term, "")
+		}
+	}
+}
+
+func (n *Node) maybeSnapshot() {
+	if n.config.SnapshotThreshold <= 0 || n.sm == nil {
+		return
+	}
+	if n.log.applied-n.log.snapshot.Index < uint64(n.config.SnapshotThreshold) {
+		return
+	}
+
+	data, err := n.sm.Snapshot()
+	if err != nil {
+		return
+	}
+	term, _ := n.log.term(n.log.applied)
+
+	var members []string
+	for id := range n.members {
+		members = append(members, id)
+	}
+	sort.Strings(members)
+
+	n.log.compact(&Snapshot{
+		Index:   n.log.applied,
+		Term:    term,
+		Members: members,
+		Data:    data,
+	})
+}
+
+func (n *Node) dropPending(err error) {
+	for index, p := range n.pending {
+		delete(n.pending, index)
+		p.result <- proposalResult{err: err}
+	}
+}
+
+func (n *Node) send(msg Message) {
+	msg.From = n.id
+	msg.Term = n.term
+	n.transport.Send(msg)
+}
+
+func (n *Node) publish() {
+	var members []string
+	for id := range n.members {
+		members = append(members, id)
+	}
+	sort.Strings(members)
+
+	n.statusLock.Lock()
+	defer n.statusLock.Unlock()
+
+	n.status = Status{
+		ID:      n.id,
+		State:   n.state,
+		Term:    n.term,
+		Leader:  n.leader,
+		Members: members,
+		Commit:  n.log.committed,
+		Applied: n.log.applied,
+	}
+}
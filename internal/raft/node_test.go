@@ -0,0 +1,246 @@
+package raft
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+type (
+	counter struct {
+		value int
+		lock  sync.RWMutex
+	}
+)
+
+func (c *counter) Apply(data any) any {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.value += data.(int)
+	return c.value
+}
+
+func (c *counter) Snapshot() (any, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.value, nil
+}
+
+func (c *counter) Restore(data any) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.value = data.(int)
+	return nil
+}
+
+func (c *counter) Value() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.value
+}
+
+func newTestCluster(t *testing.T, size int, threshold int) (*MemoryTransport, []*Node, []*counter) {
+	tr := NewMemoryTransport()
+
+	var peers []string
+	for i := 0; i < size; i++ {
+		peers = append(peers, fmt.Sprintf("node-%d", i))
+	}
+
+	var nodes []*Node
+	var counters []*counter
+	for _, id := range peers {
+		c := &counter{}
+		n := NewNode(Config{
+			ID:                id,
+			Peers:             peers,
+			Transport:         tr,
+			StateMachine:      c,
+			TickInterval:      time.Millisecond,
+			ElectionTick:      10,
+			HeartbeatTick:     1,
+			SnapshotThreshold: threshold,
+		})
+		nodes = append(nodes, n)
+		counters = append(counters, c)
+	}
+
+	t.Cleanup(func() {
+		for _, n := range nodes {
+			n.Stop()
+		}
+	})
+
+	return tr, nodes, counters
+}
+
+func waitLeader(t *testing.T, nodes []*Node) *Node {
+	var leader *Node
+	assert.Eventually(t, func() bool {
+		for _, n := range nodes {
+			if n.Status().State == StateLeader {
+				leader = n
+				return true
+			}
+		}
+		return false
+	}, time.Second, time.Millisecond)
+	return leader
+}
+
+func TestNode_Election(t *testing.T) {
+	_, nodes, _ := newTestCluster(t, 3, 0)
+
+	leader := waitLeader(t, nodes)
+	assert.NotNil(t, leader)
+
+	assert.Eventually(t, func() bool {
+		for _, n := range nodes {
+			if n.Status().Leader != leader.ID() {
+				return false
+			}
+		}
+		return true
+	}, time.Second, time.Millisecond)
+}
+
+func TestNode_Propose(t *testing.T) {
+	_, nodes, counters := newTestCluster(t, 3, 0)
+
+	leader := waitLeader(t, nodes)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	value, err := leader.Propose(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, value)
+
+	for _, n := range nodes {
+		if n != leader {
+			_, err := n.Propose(ctx, 1)
+			assert.ErrorIs(t, err, ErrNotLeader)
+		}
+	}
+
+	assert.Eventually(t, func() bool {
+		for _, c := range counters {
+			if c.Value() != 1 {
+				return false
+			}
+		}
+		return true
+	}, time.Second, time.Millisecond)
+}
+
+func TestNode_Failover(t *testing.T) {
+	tr, nodes, counters := newTestCluster(t, 3, 0)
+
+	leader := waitLeader(t, nodes)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_, err := leader.Propose(ctx, 1)
+	assert.NoError(t, err)
+
+	tr.Disconnect(leader.ID())
+	leader.Stop()
+
+	var remains []*Node
+	var values []*counter
+	for i, n := range nodes {
+		if n != leader {
+			remains = append(remains, n)
+			values = append(values, counters[i])
+		}
+	}
+
+	next := waitLeader(t, remains)
+	assert.NotEqual(t, leader.ID(), next.ID())
+
+	value, err := next.Propose(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, value)
+
+	assert.Eventually(t, func() bool {
+		for _, c := range values {
+			if c.Value() != 2 {
+				return false
+			}
+		}
+		return true
+	}, time.Second, time.Millisecond)
+}
+
+func TestNode_ProposeConfChange(t *testing.T) {
+	tr, nodes, _ := newTestCluster(t, 3, 0)
+
+	leader := waitLeader(t, nodes)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_, err := leader.Propose(ctx, 1)
+	assert.NoError(t, err)
+
+	c := &counter{}
+	joiner := NewNode(Config{
+		ID:            "node-3",
+		Transport:     tr,
+		StateMachine:  c,
+		TickInterval:  time.Millisecond,
+		ElectionTick:  10,
+		HeartbeatTick: 1,
+	})
+	defer joiner.Stop()
+
+	err = leader.ProposeConfChange(ctx, ConfChange{Type: ConfChangeAddNode, ID: joiner.ID()})
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return c.Value() == 1 && len(joiner.Status().Members) == 4
+	}, time.Second, time.Millisecond)
+
+	err = leader.ProposeConfChange(ctx, ConfChange{Type: ConfChangeRemoveNode, ID: joiner.ID()})
+	assert.NoError(t, err)
+	assert.Len(t, leader.Status().Members, 3)
+}
+
+func TestNode_Snapshot(t *testing.T) {
+	tr, nodes, counters := newTestCluster(t, 3, 4)
+
+	leader := waitLeader(t, nodes)
+
+	var follower *Node
+	var value *counter
+	for i, n := range nodes {
+		if n != leader {
+			follower = n
+			value = counters[i]
+			break
+		}
+	}
+	tr.Disconnect(follower.ID())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	for i := 0; i < 10; i++ {
+		_, err := leader.Propose(ctx, 1)
+		assert.NoError(t, err)
+	}
+
+	tr.Reconnect(follower.ID())
+
+	assert.Eventually(t, func() bool {
+		return value.Value() == 10
+	}, time.Second, time.Millisecond)
+}
@@ -0,0 +1,75 @@
+package raft
+
+import (
+	"sync"
+)
+
+type (
+	Transport interface {
+		Bind(id string, inbox chan<- Message)
+		Unbind(id string)
+		Send(msg Message)
+	}
+
+	MemoryTransport struct {
+		inboxes      map[string]chan<- Message
+		disconnected map[string]bool
+		lock         sync.RWMutex
+	}
+)
+
+var _ Transport = (*MemoryTransport)(nil)
+
+func NewMemoryTransport() *MemoryTransport {
+	return &MemoryTransport{
+		inboxes:      map[string]chan<- Message{},
+		disconnected: map[string]bool{},
+		lock:         sync.RWMutex{},
+	}
+}
+
+func (t *MemoryTransport) Bind(id string, inbox chan<- Message) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.inboxes[id] = inbox
+}
+
+func (t *MemoryTransport) Unbind(id string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	delete(t.inboxes, id)
+}
+
+func (t *MemoryTransport) Disconnect(id string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.disconnected[id] = true
+}
+
+func (t *MemoryTransport) Reconnect(id string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	delete(t.disconnected, id)
+}
+
+func (t *MemoryTransport) Send(msg Message) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if t.disconnected[msg.From] || t.disconnected[msg.To] {
+		return
+	}
+	inbox, ok := t.inboxes[msg.To]
+	if !ok {
+		return
+	}
+
+	select {
+	case inbox <- msg:
+	default:
+	}
+}
@@ -0,0 +1,30 @@
+package raft
+
+import (
+	"github.com/go-faker/faker/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMemoryTransport_Send(t *testing.T) {
+	tr := NewMemoryTransport()
+
+	from := faker.UUIDHyphenated()
+	to := faker.UUIDHyphenated()
+
+	inbox := make(chan Message, 1)
+	tr.Bind(to, inbox)
+
+	tr.Send(Message{From: from, To: to})
+	assert.Len(t, inbox, 1)
+	<-inbox
+
+	tr.Disconnect(to)
+	tr.Send(Message{From: from, To: to})
+	assert.Len(t, inbox, 0)
+
+	tr.Reconnect(to)
+	tr.Unbind(to)
+	tr.Send(Message{From: from, To: to})
+	assert.Len(t, inbox, 0)
+}
@@ -0,0 +1,47 @@
+package text
+
+import (
+	"strings"
+	"unicode"
+)
+
+type Token struct {
+	Term     string
+	Position int
+}
+
+var stopWords = map[string]struct{}{}
+
+func init() {
+	for _, w := range strings.Fields(`a about above after again against all am an and any are as at be because been
+		before being below between both but by can did do does doing down during each few for from further had
+		has have having he her here hers herself him himself his how i if in into is it its itself just me more
+		most my myself no nor not now of off on once only or other our ours ourselves out over own same she
+		should so some such than that the their theirs them themselves then there these they this those
+		through to too under until up very was we were what when where which while who whom why will with you
+		your yours yourself yourselves`) {
+		stopWords[w] = struct{}{}
+	}
+}
+
+func IsStopWord(word string) bool {
+	_, ok := stopWords[word]
+	return ok
+}
+
+func Analyze(s string) []Token {
+	var tokens []Token
+	for i, word := range split(s) {
+		if IsStopWord(word) {
+			continue
+		}
+		tokens = append(tokens, Token{Term: Stem(word), Position: i})
+	}
+	return tokens
+}
+
+func split(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,21 @@
+package text
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	tokens := Analyze("The Quick, brown foxes jumped!")
+	assert.Equal(t, []Token{
+		{Term: "quick", Position: 1},
+		{Term: "brown", Position: 2},
+		{Term: "fox", Position: 3},
+		{Term: "jump", Position: 4},
+	}, tokens)
+}
+
+func TestIsStopWord(t *testing.T) {
+	assert.True(t, IsStopWord("the"))
+	assert.False(t, IsStopWord("mouse"))
+}
@@ -0,0 +1,98 @@
+package text
+
+import (
+	"strings"
+)
+
+type Query struct {
+	Terms    []string
+	Prefixes []string
+	Phrases  [][]Token
+}
+
+func Parse(query string) Query {
+	var q Query
+
+	parts := strings.Split(query, `"`)
+	for i, part := range parts {
+		if i%2 == 1 {
+			if tokens := Analyze(part); len(tokens) > 0 {
+				q.Phrases = append(q.Phrases, tokens)
+			}
+			continue
+		}
+
+		for _, field := range strings.Fields(part) {
+			if strings.HasSuffix(field, "*") {
+				for _, word := range split(strings.TrimSuffix(field, "*")) {
+					q.Prefixes = append(q.Prefixes, word)
+				}
+				continue
+			}
+			for _, token := range Analyze(field) {
+				q.Terms = append(q.Terms, token.Term)
+			}
+		}
+	}
+	return q
+}
+
+func (q Query) Empty() bool {
+	return len(q.Terms) == 0 && len(q.Prefixes) == 0 && len(q.Phrases) == 0
+}
+
+func (q Query) Match(tokens []Token) bool {
+	positions := map[string][]int{}
+	for _, token := range tokens {
+		positions[token.Term] = append(positions[token.Term], token.Position)
+	}
+
+	matched := false
+	for _, phrase := range q.Phrases {
+		if !matchPhrase(positions, phrase) {
+			return false
+		}
+		matched = true
+	}
+	if matched {
+		return true
+	}
+
+	for _, term := range q.Terms {
+		if _, ok := positions[term]; ok {
+			return true
+		}
+	}
+	for _, prefix := range q.Prefixes {
+		for term := range positions {
+			if strings.HasPrefix(term, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchPhrase(positions map[string][]int, phrase []Token) bool {
+	for _, start := range positions[phrase[0].Term] {
+		ok := true
+		for _, token := range phrase[1:] {
+			want := start + token.Position - phrase[0].Position
+			found := false
+			for _, p := range positions[token.Term] {
+				if p == want {
+					found = true
+					break
+				}
+			}
+			if !found {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package text
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	q := Parse(`wireless mous* "quick brown"`)
+	assert.Equal(t, []string{"wireless"}, q.Terms)
+	assert.Equal(t, []string{"mous"}, q.Prefixes)
+	assert.Equal(t, [][]Token{{{Term: "quick", Position: 0}, {Term: "brown", Position: 1}}}, q.Phrases)
+	assert.False(t, q.Empty())
+
+	assert.True(t, Parse("the of").Empty())
+}
+
+func TestQuery_Match(t *testing.T) {
+	tokens := Analyze("The quick brown fox jumps over the lazy dog")
+
+	testCases := []struct {
+		query string
+		match bool
+	}{
+		{query: "fox", match: true},
+		{query: "cat dog", match: true},
+		{query: "cat", match: false},
+		{query: "jump", match: true},
+		{query: "laz*", match: true},
+		{query: `"brown fox"`, match: true},
+		{query: `"fox brown"`, match: false},
+		{query: `"over the lazy dog"`, match: true},
+		{query: `"quick fox" dog`, match: false},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.match, Parse(tc.query).Match(tokens), tc.query)
+	}
+}
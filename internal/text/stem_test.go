@@ -0,0 +1,34 @@
+package text
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestStem(t *testing.T) {
+	testCases := map[string]string{
+		"caresses":       "caress",
+		"ponies":         "poni",
+		"cats":           "cat",
+		"feed":           "feed",
+		"agreed":         "agre",
+		"plastered":      "plaster",
+		"motoring":       "motor",
+		"sing":           "sing",
+		"hopping":        "hop",
+		"filing":         "file",
+		"happy":          "happi",
+		"relational":     "relat",
+		"conditional":    "condit",
+		"generalization": "gener",
+		"electrical":     "electr",
+		"running":        "run",
+		"wireless":       "wireless",
+		"mice":           "mice",
+		"go":             "go",
+	}
+
+	for word, stem := range testCases {
+		assert.Equal(t, stem, Stem(word), word)
+	}
+}
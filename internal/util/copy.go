@@ -0,0 +1,33 @@
+package util
+
+func Copy[T any](value T) T {
+	if v, ok := deepCopy(value).(T); ok {
+		return v
+	}
+	return value
+}
+
+func deepCopy(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[k] = deepCopy(e)
+		}
+		return m
+	case []map[string]any:
+		s := make([]map[string]any, len(v))
+		for i, e := range v {
+			s[i] = deepCopy(e).(map[string]any)
+		}
+		return s
+	case []any:
+		s := make([]any, len(v))
+		for i, e := range v {
+			s[i] = deepCopy(e)
+		}
+		return s
+	default:
+		return value
+	}
+}
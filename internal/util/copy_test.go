@@ -0,0 +1,22 @@
+package util
+
+import (
+	"github.com/go-faker/faker/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCopy(t *testing.T) {
+	origin := map[string]any{
+		"id": faker.UUIDHyphenated(),
+		"sub": map[string]any{
+			"tags": []any{faker.Word()},
+		},
+	}
+
+	clone := Copy(origin)
+	assert.Equal(t, origin, clone)
+
+	clone["sub"].(map[string]any)["tags"].([]any)[0] = faker.UUIDHyphenated()
+	assert.NotEqual(t, origin, clone)
+}
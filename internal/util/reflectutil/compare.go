@@ -1,8 +1,12 @@
 package reflectutil
 
 import (
+	"bytes"
 	"math"
+	"math/big"
 	"reflect"
+	"sort"
+	"time"
 )
 
 func Equal(x any, y any) bool {
@@ -25,6 +29,12 @@ func compare(x, y reflect.Value) (int, bool) {
 	x = rawValue(x)
 	y = rawValue(y)
 
+	if x.IsValid() && y.IsValid() && x.Type() == y.Type() {
+		if codec, ok := lookup(x.Type()); ok {
+			return codec.Compare(x.Interface(), y.Interface()), true
+		}
+	}
+
 	k1 := basicKind(x)
 	k2 := basicKind(y)
 
@@ -50,21 +60,33 @@ func compare(x, y reflect.Value) (int, bool) {
 				return 1, true
 			}
 			return compareStrict(x.Uint(), uint64(y.Int())), true
+		case isNumber(k1) && isNumber(k2):
+			return compareNumber(x, y), true
 		default:
-			return compareStrict(k1, k2), true
+			return compareStrict(rank(k1), rank(k2)), true
 		}
 	} else {
 		switch k1 {
 		case nullKind:
 			return 0, true
+		case boolKind:
+			return compareStrict(boolToInt(x.Bool()), boolToInt(y.Bool())), true
 		case floatKind:
-			return compareStrict(x.Float(), y.Float()), true
+			return compareFloat(x.Float(), y.Float()), true
 		case intKind:
 			return compareStrict(x.Int(), y.Int()), true
 		case uintKind:
 			return compareStrict(x.Uint(), y.Uint()), true
+		case decimalKind:
+			return compareNumber(x, y), true
 		case stringKind:
 			return compareStrict(x.String(), y.String()), true
+		case bytesKind:
+			return bytes.Compare(toBytes(x), toBytes(y)), true
+		case timeKind:
+			return compareTime(x.Interface().(time.Time), y.Interface().(time.Time)), true
+		case mapKind:
+			return compareMap(x, y)
 		case iterableKind:
 			for i := 0; i < int(math.Min(float64(x.Len()), float64(y.Len()))); i++ {
 				if c, ok := compare(x.Index(i), y.Index(i)); ok && c != 0 {
@@ -80,6 +102,56 @@ func compare(x, y reflect.Value) (int, bool) {
 	}
 }
 
+func compareNumber(x, y reflect.Value) int {
+	r1, ok1 := numberToRat(x)
+	r2, ok2 := numberToRat(y)
+	if ok1 && ok2 {
+		return r1.Cmp(r2)
+	}
+	return compareFloat(numberToFloat(x), numberToFloat(y))
+}
+
+func compareMap(x, y reflect.Value) (int, bool) {
+	k1 := sortedKeys(x)
+	k2 := sortedKeys(y)
+
+	for i := 0; i < len(k1) && i < len(k2); i++ {
+		if c, ok := compare(k1[i], k2[i]); !ok {
+			return 0, false
+		} else if c != 0 {
+			return c, true
+		}
+		if c, ok := compare(x.MapIndex(k1[i]), y.MapIndex(k2[i])); !ok {
+			return 0, false
+		} else if c != 0 {
+			return c, true
+		}
+	}
+	return compareStrict(len(k1), len(k2)), true
+}
+
+func compareTime(x, y time.Time) int {
+	if x.Before(y) {
+		return -1
+	}
+	if x.After(y) {
+		return 1
+	}
+	return 0
+}
+
+func compareFloat(x, y float64) int {
+	switch {
+	case math.IsNaN(x) && math.IsNaN(y):
+		return 0
+	case math.IsNaN(x):
+		return -1
+	case math.IsNaN(y):
+		return 1
+	}
+	return compareStrict(x, y)
+}
+
 func compareStrict[T Ordered](x T, y T) int {
 	if x == y {
 		return 0
@@ -92,3 +164,81 @@ func compareStrict[T Ordered](x T, y T) int {
 	}
 	return 0
 }
+
+func sortedKeys(v reflect.Value) []reflect.Value {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		c, _ := compare(keys[i], keys[j])
+		return c < 0
+	})
+	return keys
+}
+
+func numberToRat(v reflect.Value) (*big.Rat, bool) {
+	switch basicKind(v) {
+	case intKind:
+		return new(big.Rat).SetInt64(v.Int()), true
+	case uintKind:
+		return new(big.Rat).SetUint64(v.Uint()), true
+	case floatKind:
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, false
+		}
+		return new(big.Rat).SetFloat64(f), true
+	case decimalKind:
+		return toRat(v)
+	}
+	return nil, false
+}
+
+func numberToFloat(v reflect.Value) float64 {
+	switch basicKind(v) {
+	case intKind:
+		return float64(v.Int())
+	case uintKind:
+		return float64(v.Uint())
+	case floatKind:
+		return v.Float()
+	case decimalKind:
+		r, _ := toRat(v)
+		f, _ := r.Float64()
+		return f
+	}
+	return math.NaN()
+}
+
+func isNumber(k basisKind) bool {
+	return k == intKind || k == uintKind || k == floatKind || k == decimalKind
+}
+
+func rank(k basisKind) int {
+	switch k {
+	case nullKind:
+		return 0
+	case intKind, uintKind, floatKind, decimalKind:
+		return 1
+	case stringKind:
+		return 2
+	case mapKind:
+		return 3
+	case structKind:
+		return 4
+	case iterableKind:
+		return 5
+	case bytesKind:
+		return 6
+	case boolKind:
+		return 7
+	case timeKind:
+		return 8
+	}
+	return 9
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -4,6 +4,9 @@ import "testing"
 
 import (
 	"github.com/stretchr/testify/assert"
+	"math"
+	"math/big"
+	"time"
 )
 
 func TestEqual(t *testing.T) {
@@ -460,3 +463,156 @@ func TestCompare(t *testing.T) {
 		assert.Equal(t, tc.expect, r)
 	}
 }
+
+func TestCompare_RichTypes(t *testing.T) {
+	now := time.Now()
+
+	var testCases = []struct {
+		whenX  any
+		whenY  any
+		expect int
+	}{
+		{
+			whenX:  now,
+			whenY:  now.Add(time.Second),
+			expect: -1,
+		},
+		{
+			whenX:  now.In(time.UTC),
+			whenY:  now.In(time.FixedZone("KST", 9*60*60)),
+			expect: 0,
+		},
+		{
+			whenX:  time.Minute,
+			whenY:  time.Second,
+			expect: 1,
+		},
+		{
+			whenX:  big.NewRat(3, 2),
+			whenY:  1,
+			expect: 1,
+		},
+		{
+			whenX:  big.NewRat(1, 2),
+			whenY:  0.5,
+			expect: 0,
+		},
+		{
+			whenX:  1,
+			whenY:  1.5,
+			expect: -1,
+		},
+		{
+			whenX:  uint64(math.MaxUint64),
+			whenY:  float64(1),
+			expect: 1,
+		},
+		{
+			whenX:  []byte{1, 2},
+			whenY:  []byte{1, 3},
+			expect: -1,
+		},
+		{
+			whenX:  [2]byte{1, 2},
+			whenY:  []byte{1, 2},
+			expect: 0,
+		},
+		{
+			whenX:  map[string]any{"a": 1, "b": 2},
+			whenY:  map[string]any{"a": 1, "b": 3},
+			expect: -1,
+		},
+		{
+			whenX:  map[string]any{"a": 1},
+			whenY:  map[string]any{"a": 1, "b": 1},
+			expect: -1,
+		},
+		{
+			whenX:  false,
+			whenY:  true,
+			expect: -1,
+		},
+		{
+			whenX:  math.NaN(),
+			whenY:  math.Inf(-1),
+			expect: -1,
+		},
+		{
+			whenX:  nil,
+			whenY:  1,
+			expect: -1,
+		},
+		{
+			whenX:  1,
+			whenY:  "1",
+			expect: -1,
+		},
+		{
+			whenX:  "a",
+			whenY:  map[string]any{},
+			expect: -1,
+		},
+		{
+			whenX:  map[string]any{},
+			whenY:  []any{},
+			expect: -1,
+		},
+		{
+			whenX:  []any{},
+			whenY:  []byte{},
+			expect: -1,
+		},
+		{
+			whenX:  []byte{},
+			whenY:  true,
+			expect: -1,
+		},
+		{
+			whenX:  true,
+			whenY:  now,
+			expect: -1,
+		},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expect, Compare(tc.whenX, tc.whenY), "%v %v", tc.whenX, tc.whenY)
+		assert.Equal(t, -tc.expect, Compare(tc.whenY, tc.whenX), "%v %v", tc.whenY, tc.whenX)
+	}
+}
+
+func TestCompare_Boundaries(t *testing.T) {
+	now := time.Now()
+
+	type testCase struct {
+		whenX  any
+		whenY  any
+		expect int
+	}
+
+	t.Run("Preserved", func(t *testing.T) {
+		for _, tc := range []testCase{
+			{whenX: nil, whenY: 1, expect: -1},
+			{whenX: 1, whenY: "a", expect: -1},
+			{whenX: "a", whenY: map[string]any{}, expect: -1},
+			{whenX: map[string]any{}, whenY: struct{}{}, expect: -1},
+			{whenX: struct{}{}, whenY: []any{}, expect: -1},
+			{whenX: []any{}, whenY: true, expect: -1},
+		} {
+			assert.Equal(t, tc.expect, Compare(tc.whenX, tc.whenY), "%v %v", tc.whenX, tc.whenY)
+			assert.Equal(t, -tc.expect, Compare(tc.whenY, tc.whenX), "%v %v", tc.whenY, tc.whenX)
+		}
+	})
+
+	t.Run("Changed", func(t *testing.T) {
+		for _, tc := range []testCase{
+			{whenX: 2, whenY: 1.5, expect: 1},
+			{whenX: uint(2), whenY: 1.5, expect: 1},
+			{whenX: []byte{0}, whenY: []any{1}, expect: 1},
+			{whenX: now, whenY: true, expect: 1},
+			{whenX: false, whenY: true, expect: -1},
+		} {
+			assert.Equal(t, tc.expect, Compare(tc.whenX, tc.whenY), "%v %v", tc.whenX, tc.whenY)
+			assert.Equal(t, -tc.expect, Compare(tc.whenY, tc.whenX), "%v %v", tc.whenY, tc.whenX)
+		}
+	})
+}
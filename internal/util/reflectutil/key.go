@@ -0,0 +1,94 @@
+package reflectutil
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"time"
+)
+
+type (
+	ratKey       string
+	bytesKey     string
+	compositeKey string
+	nanKey       struct{}
+)
+
+var (
+	minInt64 = big.NewInt(math.MinInt64)
+	maxInt64 = big.NewInt(math.MaxInt64)
+)
+
+// Key returns a hashable value such that Equal(x, y) implies Key(x) == Key(y).
+func Key(value any) any {
+	return key(reflect.ValueOf(value))
+}
+
+func key(v reflect.Value) any {
+	v = rawValue(v)
+
+	if v.IsValid() {
+		if codec, ok := lookup(v.Type()); ok {
+			return codecKey{typ: v.Type(), key: codec.Key(v.Interface())}
+		}
+	}
+
+	switch basicKind(v) {
+	case nullKind:
+		return nil
+	case pointerKind:
+		return key(v.Elem())
+	case boolKind:
+		return v.Bool()
+	case intKind:
+		return v.Int()
+	case uintKind:
+		if u := v.Uint(); u <= math.MaxInt64 {
+			return int64(u)
+		}
+		return ratKey(new(big.Rat).SetUint64(v.Uint()).RatString())
+	case floatKind:
+		f := v.Float()
+		if math.IsNaN(f) {
+			return nanKey{}
+		}
+		if math.IsInf(f, 0) {
+			return f
+		}
+		return ratToKey(new(big.Rat).SetFloat64(f))
+	case decimalKind:
+		r, _ := toRat(v)
+		return ratToKey(r)
+	case stringKind:
+		return v.String()
+	case bytesKind:
+		return bytesKey(toBytes(v))
+	case timeKind:
+		return v.Interface().(time.Time).UTC().Round(0)
+	case mapKind:
+		keys := make(map[any]any, v.Len())
+		for _, k := range v.MapKeys() {
+			keys[key(k)] = key(v.MapIndex(k))
+		}
+		return compositeKey(fmt.Sprintf("%#v", keys))
+	case iterableKind:
+		keys := make([]any, v.Len())
+		for i := range keys {
+			keys[i] = key(v.Index(i))
+		}
+		return compositeKey(fmt.Sprintf("%#v", keys))
+	}
+
+	if !v.Type().Comparable() {
+		return compositeKey(fmt.Sprintf("%#v", v.Interface()))
+	}
+	return v.Interface()
+}
+
+func ratToKey(r *big.Rat) any {
+	if r.IsInt() && r.Num().Cmp(minInt64) >= 0 && r.Num().Cmp(maxInt64) <= 0 {
+		return r.Num().Int64()
+	}
+	return ratKey(r.RatString())
+}
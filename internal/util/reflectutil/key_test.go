@@ -0,0 +1,65 @@
+package reflectutil
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestKey(t *testing.T) {
+	now := time.Now()
+
+	var testCases = []struct {
+		when   []any
+		expect bool
+	}{
+		{
+			when:   []any{1, int8(1), uint(1), float64(1), big.NewRat(1, 1)},
+			expect: true,
+		},
+		{
+			when:   []any{0.5, big.NewRat(1, 2)},
+			expect: true,
+		},
+		{
+			when:   []any{uint64(math.MaxUint64), new(big.Int).SetUint64(math.MaxUint64)},
+			expect: true,
+		},
+		{
+			when:   []any{now, now.In(time.FixedZone("KST", 9*60*60)), now.Round(0)},
+			expect: true,
+		},
+		{
+			when:   []any{[]byte{1, 2}, [2]byte{1, 2}},
+			expect: true,
+		},
+		{
+			when:   []any{map[string]any{"a": 1, "b": []any{1.0}}, map[string]any{"b": []any{1}, "a": uint8(1)}},
+			expect: true,
+		},
+		{
+			when:   []any{math.NaN(), math.NaN()},
+			expect: true,
+		},
+		{
+			when:   []any{"1", 1},
+			expect: false,
+		},
+		{
+			when:   []any{[]byte("a"), "a"},
+			expect: false,
+		},
+		{
+			when:   []any{0.5, 0.25},
+			expect: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		for _, v := range tc.when[1:] {
+			assert.Equal(t, tc.expect, Key(tc.when[0]) == Key(v), "%v %v", tc.when[0], v)
+		}
+	}
+}
@@ -2,7 +2,9 @@ package reflectutil
 
 import (
 	"github.com/siyul-park/memdb/internal/util"
+	"math/big"
 	"reflect"
+	"time"
 )
 
 type (
@@ -24,6 +26,9 @@ type (
 	Unsigned interface {
 		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
 	}
+	Rational interface {
+		Rat() *big.Rat
+	}
 )
 
 type basisKind int
@@ -41,16 +46,26 @@ const (
 	iterableKind
 	boolKind
 	pointerKind
+	bytesKind
+	timeKind
+	decimalKind
 )
 
 var (
-	anyType = reflect.ValueOf((*any)(nil)).Type().Elem()
+	anyType  = reflect.ValueOf((*any)(nil)).Type().Elem()
+	timeType = reflect.TypeOf(time.Time{})
 )
 
 func basicKind(v reflect.Value) basisKind {
 	if !v.IsValid() || util.IsNil(v.Interface()) {
 		return nullKind
 	}
+	if v.Type() == timeType {
+		return timeKind
+	}
+	if _, ok := toRat(v); ok {
+		return decimalKind
+	}
 
 	switch v.Kind() {
 	case reflect.Bool:
@@ -70,6 +85,9 @@ func basicKind(v reflect.Value) basisKind {
 	case reflect.Struct:
 		return structKind
 	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return bytesKind
+		}
 		return iterableKind
 	case reflect.Pointer:
 		return pointerKind
@@ -84,3 +102,32 @@ func rawValue(x reflect.Value) reflect.Value {
 
 	return reflect.ValueOf(x.Interface())
 }
+
+func toRat(v reflect.Value) (*big.Rat, bool) {
+	if !v.CanInterface() {
+		return nil, false
+	}
+	switch r := v.Interface().(type) {
+	case Rational:
+		if rat := r.Rat(); rat != nil {
+			return rat, true
+		}
+	case *big.Rat:
+		return r, true
+	case *big.Int:
+		return new(big.Rat).SetInt(r), true
+	case *big.Float:
+		if rat, _ := r.Rat(nil); rat != nil {
+			return rat, true
+		}
+	}
+	return nil, false
+}
+
+func toBytes(v reflect.Value) []byte {
+	b := make([]byte, v.Len())
+	for i := range b {
+		b[i] = byte(v.Index(i).Uint())
+	}
+	return b
+}
@@ -0,0 +1,48 @@
+package reflectutil
+
+import (
+	"github.com/pkg/errors"
+	"reflect"
+	"sync"
+)
+
+type (
+	Codec struct {
+		Compare func(x, y any) int
+		Key     func(value any) any
+	}
+
+	codecKey struct {
+		typ reflect.Type
+		key any
+	}
+)
+
+var (
+	ErrCodeInvalidCodec = "invalid_codec"
+
+	ErrInvalidCodec = errors.New(ErrCodeInvalidCodec)
+)
+
+var (
+	codecs sync.Map
+)
+
+func Register(typ reflect.Type, codec Codec) error {
+	if codec.Compare == nil || codec.Key == nil {
+		return errors.Wrap(ErrInvalidCodec, typ.String())
+	}
+	codecs.Store(typ, codec)
+	return nil
+}
+
+func Unregister(typ reflect.Type) {
+	codecs.Delete(typ)
+}
+
+func lookup(typ reflect.Type) (Codec, bool) {
+	if c, ok := codecs.Load(typ); ok {
+		return c.(Codec), true
+	}
+	return Codec{}, false
+}
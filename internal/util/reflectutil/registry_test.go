@@ -0,0 +1,42 @@
+package reflectutil
+
+import (
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type caseless string
+
+func TestRegister(t *testing.T) {
+	typ := reflect.TypeOf(caseless(""))
+
+	assert.False(t, Equal(caseless("A"), caseless("a")))
+	assert.NotEqual(t, Key(caseless("A")), Key(caseless("a")))
+
+	err := Register(typ, Codec{
+		Compare: func(x, y any) int {
+			return strings.Compare(strings.ToLower(string(x.(caseless))), strings.ToLower(string(y.(caseless))))
+		},
+	})
+	assert.ErrorIs(t, err, ErrInvalidCodec)
+	assert.False(t, Equal(caseless("A"), caseless("a")))
+
+	err = Register(typ, Codec{
+		Compare: func(x, y any) int {
+			return strings.Compare(strings.ToLower(string(x.(caseless))), strings.ToLower(string(y.(caseless))))
+		},
+		Key: func(value any) any {
+			return strings.ToLower(string(value.(caseless)))
+		},
+	})
+	assert.NoError(t, err)
+	defer Unregister(typ)
+
+	assert.True(t, Equal(caseless("A"), caseless("a")))
+	assert.Equal(t, -1, Compare(caseless("a"), caseless("B")))
+	assert.Equal(t, 0, Compare(caseless("a"), &[]caseless{"A"}[0]))
+	assert.Equal(t, Key(caseless("A")), Key(caseless("a")))
+	assert.NotEqual(t, Key(caseless("a")), Key("a"))
+}
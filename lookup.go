@@ -0,0 +1,177 @@
+package memdb
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/siyul-park/memdb/internal/util"
+)
+
+type (
+	Lookup struct {
+		From         string
+		LocalField   string
+		ForeignField string
+		As           string
+		Join         JoinType
+		Filter       *Filter
+	}
+
+	JoinType int
+)
+
+const (
+	JoinLeft JoinType = iota
+	JoinInner
+)
+
+func (opt *FindOptions) scan() *FindOptions {
+	if util.IsNil(opt) || !innerJoin(opt.Lookups) {
+		return opt
+	}
+	next := *opt
+	next.Limit = nil
+	next.Skip = nil
+	return &next
+}
+
+func (coll *Collection) join(ctx context.Context, documents []map[string]any, opt *FindOptions) ([]map[string]any, error) {
+	if util.IsNil(opt) || len(opt.Lookups) == 0 {
+		return documents, nil
+	}
+
+	docs := make([]map[string]any, len(documents))
+	for i, doc := range documents {
+		next := make(map[string]any, len(doc)+len(opt.Lookups))
+		for k, v := range doc {
+			next[k] = v
+		}
+		docs[i] = next
+	}
+
+	for _, lookup := range opt.Lookups {
+		var err error
+		if docs, err = coll.lookup(ctx, docs, lookup); err != nil {
+			return nil, err
+		}
+	}
+
+	if !innerJoin(opt.Lookups) {
+		return docs, nil
+	}
+
+	return paginate(docs, opt), nil
+}
+
+func (coll *Collection) lookup(ctx context.Context, documents []map[string]any, lookup Lookup) ([]map[string]any, error) {
+	var foreign *Collection
+	if coll.db != nil {
+		foreign, _ = coll.db.get(lookup.From)
+	}
+	if foreign == nil {
+		return nil, errors.Wrap(ErrCollectionNotFound, lookup.From)
+	}
+
+	var values []any
+	visits := map[any]struct{}{}
+	for _, doc := range documents {
+		for _, v := range lookupValues(doc, lookup.LocalField) {
+			if _, ok := visits[indexKey(v)]; !ok {
+				visits[indexKey(v)] = struct{}{}
+				values = append(values, v)
+			}
+		}
+	}
+
+	buckets := map[any][]map[string]any{}
+	if len(values) > 0 {
+		filter := Where(lookup.ForeignField).IN(values...)
+		if !util.IsNil(lookup.Filter) {
+			filter = filter.And(lookup.Filter)
+		}
+		matches, err := foreign.findMany(withQuery(ctx, nil), filter, &FindOptions{Sorts: foreign.pk.sorts()})
+		if err != nil {
+			return nil, err
+		}
+		foreign.touch(matches...)
+
+		if matches, err = foreign.decrypt(matches...); err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			for _, v := range lookupValues(match, lookup.ForeignField) {
+				buckets[indexKey(v)] = append(buckets[indexKey(v)], match)
+			}
+		}
+	}
+
+	docs := make([]map[string]any, 0, len(documents))
+	for _, doc := range documents {
+		joined := []any{}
+		ids := map[any]struct{}{}
+		for _, v := range lookupValues(doc, lookup.LocalField) {
+			for _, match := range buckets[indexKey(v)] {
+				id, _ := foreign.pk.id(match)
+				if _, ok := ids[id]; !ok {
+					ids[id] = struct{}{}
+					joined = append(joined, match)
+				}
+			}
+		}
+		if lookup.Join == JoinInner && len(joined) == 0 {
+			continue
+		}
+		doc[lookup.As] = joined
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+func lookupValues(document map[string]any, key string) []any {
+	var values []any
+	visits := map[any]struct{}{}
+	add := func(v any) {
+		if util.IsNil(v) {
+			return
+		}
+		if _, ok := visits[indexKey(v)]; !ok {
+			visits[indexKey(v)] = struct{}{}
+			values = append(values, v)
+		}
+	}
+
+	resolved, _ := resolve(document, key)
+	for _, v := range resolved {
+		if elements, ok := toArray(v); ok {
+			for _, e := range elements {
+				add(e)
+			}
+		} else {
+			add(v)
+		}
+	}
+	return values
+}
+
+func paginate(documents []map[string]any, opt *FindOptions) []map[string]any {
+	if util.IsNil(opt) {
+		return documents
+	}
+	skip := util.UnPtr(opt.Skip)
+	if skip >= len(documents) {
+		return nil
+	}
+	documents = documents[skip:]
+	if !util.IsNil(opt.Limit) && util.UnPtr(opt.Limit) >= 0 && len(documents) > util.UnPtr(opt.Limit) {
+		documents = documents[:util.UnPtr(opt.Limit)]
+	}
+	return documents
+}
+
+func innerJoin(lookups []Lookup) bool {
+	for _, lookup := range lookups {
+		if lookup.Join == JoinInner {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package memdb
+
+import (
+	"github.com/go-faker/faker/v4"
+	"github.com/siyul-park/memdb/internal/util"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCollection_Lookup(t *testing.T) {
+	db := New(faker.Word())
+
+	customers := db.Collection("customers")
+	orders := db.Collection("orders")
+
+	err := orders.Indexes().Create(IndexModel{Name: "customer", Keys: []string{"customer"}})
+	assert.NoError(t, err)
+
+	_, err = customers.InsertMany([]map[string]any{
+		{"id": 1, "name": "a", "tags": []any{"x"}},
+		{"id": 2, "name": "b", "tags": []any{"x", "y"}},
+		{"id": 3, "name": "c"},
+	})
+	assert.NoError(t, err)
+	_, err = orders.InsertMany([]map[string]any{
+		{"id": 10, "customer": 1, "total": 5},
+		{"id": 11, "customer": 1, "total": 7},
+		{"id": 12, "customer": 2, "total": 3},
+	})
+	assert.NoError(t, err)
+
+	t.Run("Left", func(t *testing.T) {
+		docs, err := customers.FindMany(nil, &FindOptions{
+			Sorts:   []Sort{{Key: "id", Order: OrderASC}},
+			Lookups: []Lookup{{From: "orders", LocalField: "id", ForeignField: "customer", As: "orders"}},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, docs, 3)
+		assert.Len(t, docs[0]["orders"], 2)
+		assert.Len(t, docs[1]["orders"], 1)
+		assert.Equal(t, []any{}, docs[2]["orders"])
+
+		doc, err := customers.FindOne(Where("id").EQ(3))
+		assert.NoError(t, err)
+		assert.NotContains(t, doc, "orders")
+	})
+
+	t.Run("Inner", func(t *testing.T) {
+		docs, err := customers.FindMany(nil, &FindOptions{
+			Sorts:   []Sort{{Key: "id", Order: OrderDESC}},
+			Skip:    util.Ptr(1),
+			Limit:   util.Ptr(1),
+			Lookups: []Lookup{{From: "orders", LocalField: "id", ForeignField: "customer", As: "orders", Join: JoinInner}},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []any{1}, ids(docs))
+
+		doc, err := customers.FindOne(Where("id").EQ(3), &FindOptions{
+			Lookups: []Lookup{{From: "orders", LocalField: "id", ForeignField: "customer", As: "orders", Join: JoinInner}},
+		})
+		assert.NoError(t, err)
+		assert.Nil(t, doc)
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		doc, err := customers.FindOne(Where("id").EQ(1), &FindOptions{
+			Lookups: []Lookup{{From: "orders", LocalField: "id", ForeignField: "customer", As: "orders", Filter: Where("total").GT(6)}},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, doc["orders"], 1)
+	})
+
+	t.Run("Array", func(t *testing.T) {
+		docs, err := orders.FindMany(Where("id").EQ(12), &FindOptions{
+			Lookups: []Lookup{{From: "customers", LocalField: "customer", ForeignField: "id", As: "customer"}},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, docs, 1)
+		assert.Equal(t, "b", docs[0]["customer"].([]any)[0].(map[string]any)["name"])
+
+		_, err = db.Collection("groups").InsertOne(map[string]any{"id": "x"})
+		assert.NoError(t, err)
+
+		docs, err = customers.FindMany(Where("id").EQ(2), &FindOptions{
+			Lookups: []Lookup{{From: "groups", LocalField: "tags", ForeignField: "id", As: "groups"}},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, docs[0]["groups"], 1)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := customers.FindMany(nil, &FindOptions{
+			Lookups: []Lookup{{From: faker.Word(), LocalField: "id", ForeignField: "customer", As: "orders"}},
+		})
+		assert.ErrorIs(t, err, ErrCollectionNotFound)
+	})
+
+	t.Run("Scoped", func(t *testing.T) {
+		db.SetRole(Role{
+			Name: "customer",
+			Grants: []Grant{
+				{Collection: "customers", Permissions: PermissionRead},
+			},
+		})
+		db.SetRole(Role{
+			Name: "order",
+			Grants: []Grant{
+				{Collection: "orders", Permissions: PermissionRead, Filter: Where("total").LT(6)},
+			},
+		})
+
+		opt := &FindOptions{
+			Lookups: []Lookup{{From: "orders", LocalField: "id", ForeignField: "customer", As: "orders"}},
+		}
+
+		_, err := db.As(Principal{Name: "a", Roles: []string{"customer"}}).Collection("customers").FindMany(nil, opt)
+		assert.ErrorIs(t, err, ErrForbidden)
+
+		doc, err := db.As(Principal{Name: "b", Roles: []string{"customer", "order"}}).Collection("customers").FindOne(Where("id").EQ(1), opt)
+		assert.NoError(t, err)
+		assert.Len(t, doc["orders"], 1)
+	})
+}
+
+func TestCollection_Lookup_Index(t *testing.T) {
+	m := NewOpenMetrics()
+	db := New(faker.Word(), &DatabaseOptions{Metrics: m})
+
+	customers := db.Collection("customers")
+	orders := db.Collection("orders")
+
+	err := orders.Indexes().Create(IndexModel{Name: "customer", Keys: []string{"customer"}})
+	assert.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		_, err := customers.InsertOne(map[string]any{"id": i})
+		assert.NoError(t, err)
+		_, err = orders.InsertOne(map[string]any{"id": 10 + i, "customer": i, "status": "paid"})
+		assert.NoError(t, err)
+	}
+
+	docs, err := customers.FindMany(nil, &FindOptions{
+		Lookups: []Lookup{
+			{From: "orders", LocalField: "id", ForeignField: "customer", As: "orders"},
+			{From: "orders", LocalField: "id", ForeignField: "customer", As: "paid", Filter: Where("status").EQ("paid")},
+			{From: "customers", LocalField: "id", ForeignField: "id", As: "self"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, docs, 5)
+	for _, doc := range docs {
+		assert.Len(t, doc["orders"], 1)
+		assert.Len(t, doc["paid"], 1)
+		assert.Len(t, doc["self"], 1)
+	}
+
+	assert.Equal(t, int64(2), m.scans[metricKey{collection: "orders", label: "index"}])
+	assert.Zero(t, m.scans[metricKey{collection: "orders", label: "full"}])
+	assert.Equal(t, int64(1), m.scans[metricKey{collection: "customers", label: "index"}])
+}
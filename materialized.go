@@ -0,0 +1,406 @@
+package memdb
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/siyul-park/memdb/internal/util"
+	"github.com/siyul-park/memdb/internal/util/reflectutil"
+	"math"
+	"math/big"
+	"sort"
+	"sync"
+)
+
+type (
+	Accumulator struct {
+		Op    AccumulatorOp
+		Field string
+	}
+
+	AccumulatorOp int
+
+	materializer struct {
+		source       *Collection
+		target       *Collection
+		filter       *Filter
+		match        func(map[string]any) bool
+		groupBy      []string
+		accumulators map[string]Accumulator
+		members      map[any]contribution
+		groups       map[any]*aggregate
+		watcher      int
+		err          error
+		lock         sync.Mutex
+	}
+
+	contribution struct {
+		group  any
+		keys   map[string]any
+		values map[string]any
+	}
+
+	aggregate struct {
+		keys   map[string]any
+		count  int
+		fields map[string]*aggregateField
+	}
+
+	aggregateField struct {
+		sum    *big.Rat
+		count  int
+		values map[any]*aggregateValue
+	}
+
+	aggregateValue struct {
+		value any
+		count int
+	}
+)
+
+const (
+	AccumulatorCount AccumulatorOp = iota
+	AccumulatorSum
+	AccumulatorAvg
+	AccumulatorMin
+	AccumulatorMax
+)
+
+const (
+	groupAll = "*"
+)
+
+var (
+	ErrCodeViewStale = "view_stale"
+
+	ErrViewStale = errors.New(ErrCodeViewStale)
+)
+
+func (db *Database) CreateMaterializedView(name string, source string, filter *Filter, groupBy []string, accumulators map[string]Accumulator) (*Collection, error) {
+	if name == source {
+		return nil, errors.Wrap(ErrCollectionExists, name)
+	}
+	src, ok := db.get(source)
+	if !ok {
+		return nil, errors.Wrap(ErrCollectionNotFound, source)
+	}
+
+	opt := &CollectionOptions{}
+	if len(groupBy) > 0 {
+		opt.PrimaryKey = groupBy
+	}
+	target, err := db.CreateCollection(name, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &materializer{
+		source:       src,
+		target:       target,
+		filter:       filter,
+		match:        parseFilter(filter),
+		groupBy:      groupBy,
+		accumulators: accumulators,
+	}
+	if err := m.start(); err != nil {
+		_ = db.DropCollection(name)
+		return nil, err
+	}
+
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	db.materialized[name] = m
+	return target, nil
+}
+
+func (m *materializer) start() error {
+	err := func() error {
+		m.lock.Lock()
+		defer m.lock.Unlock()
+
+		m.watcher = m.source.Watch(m.apply)
+		return m.load()
+	}()
+	if err != nil {
+		m.stop()
+	}
+	return err
+}
+
+func (m *materializer) load() error {
+	m.members = map[any]contribution{}
+	m.groups = map[any]*aggregate{}
+
+	ctx := withQuery(context.Background(), nil)
+	docs, err := m.source.findMany(ctx, m.filter)
+	if err != nil {
+		return err
+	}
+	if docs, err = m.source.decrypt(docs...); err != nil {
+		return err
+	}
+
+	var touched []any
+	for _, doc := range docs {
+		touched = append(touched, m.upsert(indexKey(m.source.pk.value(doc)), doc)...)
+	}
+	for _, group := range dedupe(touched) {
+		if err := m.flush(group); err != nil {
+			return err
+		}
+	}
+
+	stale, err := m.target.findMany(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, doc := range stale {
+		if _, ok := m.groups[indexKey(m.target.pk.value(doc))]; ok {
+			continue
+		}
+		if _, err := m.target.deleteOneContext(context.Background(), m.target.pk.filter(doc)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *materializer) apply(event Event, val any) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.err != nil {
+		return
+	}
+
+	var touched []any
+	if event == EventDelete {
+		touched = m.remove(indexKey(val))
+	} else if doc, ok := val.(map[string]any); ok {
+		touched = m.upsert(indexKey(m.source.pk.value(doc)), doc)
+	}
+	for _, group := range dedupe(touched) {
+		if err := m.flush(group); err != nil {
+			m.err = errors.Wrap(ErrViewStale, err.Error())
+			m.target.fail(m.err)
+			return
+		}
+	}
+}
+
+func (m *materializer) stop() {
+	m.source.Unwatch(m.watcher)
+}
+
+func (m *materializer) reads(coll *Collection) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.source == coll
+}
+
+func (m *materializer) rebind(replaced map[*Collection]*Collection) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	source, ok := replaced[m.source]
+	if !ok {
+		return false
+	}
+	target, ok := replaced[m.target]
+	if !ok {
+		return false
+	}
+	m.source = source
+	m.target = target
+	return m.load() == nil
+}
+
+func (m *materializer) upsert(id any, document map[string]any) []any {
+	touched := m.remove(id)
+	if !m.match(document) {
+		return touched
+	}
+
+	c, ok := m.contribution(document)
+	if !ok {
+		return touched
+	}
+	m.members[id] = c
+
+	agg, ok := m.groups[c.group]
+	if !ok {
+		agg = &aggregate{keys: c.keys, fields: map[string]*aggregateField{}}
+		m.groups[c.group] = agg
+	}
+	agg.count++
+	for name, v := range c.values {
+		field, ok := agg.fields[name]
+		if !ok {
+			field = &aggregateField{sum: new(big.Rat), values: map[any]*aggregateValue{}}
+			agg.fields[name] = field
+		}
+		field.add(v)
+	}
+
+	return append(touched, c.group)
+}
+
+func (m *materializer) remove(id any) []any {
+	c, ok := m.members[id]
+	if !ok {
+		return nil
+	}
+	delete(m.members, id)
+
+	agg := m.groups[c.group]
+	agg.count--
+	for name, v := range c.values {
+		agg.fields[name].remove(v)
+	}
+	return []any{c.group}
+}
+
+func (m *materializer) contribution(document map[string]any) (contribution, bool) {
+	keys := map[string]any{}
+	if len(m.groupBy) == 0 {
+		keys[keyID] = groupAll
+	}
+	for _, k := range m.groupBy {
+		v, ok := reflectutil.Get[any](document, k)
+		if !ok || util.IsNil(v) {
+			return contribution{}, false
+		}
+		keys[k] = v
+	}
+
+	values := map[string]any{}
+	for name, acc := range m.accumulators {
+		if acc.Op == AccumulatorCount {
+			continue
+		}
+		if v, ok := reflectutil.Get[any](document, acc.Field); ok && !util.IsNil(v) {
+			values[name] = v
+		}
+	}
+
+	return contribution{
+		group:  indexKey(m.target.pk.value(keys)),
+		keys:   keys,
+		values: values,
+	}, true
+}
+
+func (m *materializer) flush(group any) error {
+	agg, ok := m.groups[group]
+	if !ok {
+		return nil
+	}
+
+	ctx := context.Background()
+	if agg.count == 0 {
+		delete(m.groups, group)
+		_, err := m.target.deleteOneContext(ctx, m.target.pk.filter(agg.keys))
+		return err
+	}
+
+	doc := make(map[string]any, len(agg.keys)+len(m.accumulators))
+	for k, v := range agg.keys {
+		doc[k] = v
+	}
+	for name, acc := range m.accumulators {
+		field := agg.fields[name]
+		if field == nil {
+			field = &aggregateField{sum: new(big.Rat)}
+		}
+
+		switch acc.Op {
+		case AccumulatorCount:
+			doc[name] = agg.count
+		case AccumulatorSum:
+			doc[name] = field.total()
+		case AccumulatorAvg:
+			if field.count > 0 {
+				doc[name] = field.total() / float64(field.count)
+			} else {
+				doc[name] = nil
+			}
+		case AccumulatorMin:
+			doc[name] = field.extremum(-1)
+		case AccumulatorMax:
+			doc[name] = field.extremum(1)
+		}
+	}
+
+	_, err := m.target.updateOneContext(ctx, m.target.pk.filter(doc), doc, &UpdateOptions{Upsert: util.Ptr(true)})
+	return err
+}
+
+func (f *aggregateField) add(value any) {
+	if n, ok := toRat(value); ok {
+		f.sum.Add(f.sum, n)
+		f.count++
+	}
+
+	key := indexKey(value)
+	if v, ok := f.values[key]; ok {
+		v.count++
+	} else {
+		f.values[key] = &aggregateValue{value: value, count: 1}
+	}
+}
+
+func (f *aggregateField) remove(value any) {
+	if n, ok := toRat(value); ok {
+		f.sum.Sub(f.sum, n)
+		f.count--
+	}
+
+	key := indexKey(value)
+	if v, ok := f.values[key]; ok {
+		if v.count--; v.count == 0 {
+			delete(f.values, key)
+		}
+	}
+}
+
+func (f *aggregateField) extremum(sign int) any {
+	var result any
+	for _, v := range f.values {
+		if result == nil || reflectutil.Compare(v.value, result)*sign > 0 {
+			result = v.value
+		}
+	}
+	return result
+}
+
+func (f *aggregateField) total() float64 {
+	total, _ := f.sum.Float64()
+	return total
+}
+
+func toRat(value any) (*big.Rat, bool) {
+	if r, ok := value.(reflectutil.Rational); ok {
+		return r.Rat(), true
+	}
+	n, ok := toFloat(value)
+	if !ok || math.IsNaN(n) || math.IsInf(n, 0) {
+		return nil, false
+	}
+	return new(big.Rat).SetFloat64(n), true
+}
+
+func dedupe(keys []any) []any {
+	visits := map[any]struct{}{}
+	var unique []any
+	for _, k := range keys {
+		if _, ok := visits[k]; !ok {
+			visits[k] = struct{}{}
+			unique = append(unique, k)
+		}
+	}
+	sort.SliceStable(unique, func(i, j int) bool {
+		return reflectutil.Compare(unique[i], unique[j]) < 0
+	})
+	return unique
+}
@@ -0,0 +1,208 @@
+package memdb
+
+import (
+	"github.com/go-faker/faker/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDatabase_CreateMaterializedView(t *testing.T) {
+	db := New(faker.Word())
+
+	_, err := db.CreateMaterializedView("stats", "orders", nil, []string{"status"}, nil)
+	assert.ErrorIs(t, err, ErrCollectionNotFound)
+
+	orders := db.Collection("orders")
+	_, err = orders.InsertMany([]map[string]any{
+		{"id": 1, "status": "paid", "amount": 10},
+		{"id": 2, "status": "paid", "amount": 30},
+		{"id": 3, "status": "open", "amount": 5},
+		{"id": 4, "status": "canceled", "amount": 100},
+	})
+	assert.NoError(t, err)
+
+	stats, err := db.CreateMaterializedView("stats", "orders", Where("status").NE("canceled"), []string{"status"}, map[string]Accumulator{
+		"count": {Op: AccumulatorCount},
+		"total": {Op: AccumulatorSum, Field: "amount"},
+		"avg":   {Op: AccumulatorAvg, Field: "amount"},
+		"min":   {Op: AccumulatorMin, Field: "amount"},
+		"max":   {Op: AccumulatorMax, Field: "amount"},
+	})
+	assert.NoError(t, err)
+
+	_, err = db.CreateMaterializedView("stats", "orders", nil, nil, nil)
+	assert.ErrorIs(t, err, ErrCollectionExists)
+
+	docs, err := stats.FindMany(nil, &FindOptions{Sorts: []Sort{{Key: "status", Order: OrderASC}}})
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]any{
+		{"status": "open", "count": 1, "total": 5.0, "avg": 5.0, "min": 5, "max": 5},
+		{"status": "paid", "count": 2, "total": 40.0, "avg": 20.0, "min": 10, "max": 30},
+	}, docs)
+
+	_, err = orders.InsertOne(map[string]any{"id": 5, "status": "open", "amount": 15})
+	assert.NoError(t, err)
+
+	doc, err := stats.FindOne(Where("status").EQ("open"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"status": "open", "count": 2, "total": 20.0, "avg": 10.0, "min": 5, "max": 15}, doc)
+
+	_, err = orders.UpdateOne(Where("id").EQ(2), map[string]any{"id": 2, "status": "open", "amount": 30})
+	assert.NoError(t, err)
+
+	doc, err = stats.FindOne(Where("status").EQ("paid"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"status": "paid", "count": 1, "total": 10.0, "avg": 10.0, "min": 10, "max": 10}, doc)
+
+	doc, err = stats.FindOne(Where("status").EQ("open"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, doc["count"])
+	assert.Equal(t, 30, doc["max"])
+
+	_, err = orders.DeleteOne(Where("id").EQ(1))
+	assert.NoError(t, err)
+
+	doc, err = stats.FindOne(Where("status").EQ("paid"))
+	assert.NoError(t, err)
+	assert.Nil(t, doc)
+
+	_, err = orders.UpdateOne(Where("id").EQ(3), map[string]any{"id": 3, "status": "canceled", "amount": 5})
+	assert.NoError(t, err)
+
+	doc, err = stats.FindOne(Where("status").EQ("open"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"status": "open", "count": 2, "total": 45.0, "avg": 22.5, "min": 15, "max": 30}, doc)
+}
+
+func TestDatabase_CreateMaterializedView_All(t *testing.T) {
+	db := New(faker.Word())
+
+	orders := db.Collection("orders")
+	_, err := orders.InsertMany([]map[string]any{
+		{"id": 1, "amount": 0.1},
+		{"id": 2, "amount": 0.2},
+	})
+	assert.NoError(t, err)
+
+	stats, err := db.CreateMaterializedView("stats", "orders", nil, nil, map[string]Accumulator{
+		"count": {Op: AccumulatorCount},
+		"total": {Op: AccumulatorSum, Field: "amount"},
+	})
+	assert.NoError(t, err)
+
+	_, err = orders.InsertOne(map[string]any{"id": 3, "amount": 0.3})
+	assert.NoError(t, err)
+	_, err = orders.DeleteMany(Where("id").IN(2, 3))
+	assert.NoError(t, err)
+
+	docs, err := stats.FindMany(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]any{{"id": "*", "count": 1, "total": 0.1}}, docs)
+
+	_, err = orders.DeleteOne(Where("id").EQ(1))
+	assert.NoError(t, err)
+
+	docs, err = stats.FindMany(nil)
+	assert.NoError(t, err)
+	assert.Len(t, docs, 0)
+}
+
+func TestDatabase_CreateMaterializedView_Index(t *testing.T) {
+	db := New(faker.Word())
+
+	orders := db.Collection("orders")
+	stats, err := db.CreateMaterializedView("stats", "orders", nil, []string{"customer"}, map[string]Accumulator{
+		"count": {Op: AccumulatorCount},
+	})
+	assert.NoError(t, err)
+
+	err = stats.Indexes().Create(IndexModel{
+		Keys: []string{"count"},
+		Name: "count",
+	})
+	assert.NoError(t, err)
+
+	_, err = orders.InsertMany([]map[string]any{
+		{"id": 1, "customer": "a"},
+		{"id": 2, "customer": "a"},
+		{"id": 3, "customer": "b"},
+		{"id": 4},
+	})
+	assert.NoError(t, err)
+
+	docs, err := stats.FindMany(Where("count").GTE(2))
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]any{{"customer": "a", "count": 2}}, docs)
+
+	err = db.DropCollection("stats")
+	assert.NoError(t, err)
+
+	_, err = orders.InsertOne(map[string]any{"id": 5, "customer": "c"})
+	assert.NoError(t, err)
+
+	_, ok := db.get("stats")
+	assert.False(t, ok)
+}
+
+func TestDatabase_CreateMaterializedView_Rename(t *testing.T) {
+	db := New(faker.Word())
+
+	orders := db.Collection("orders")
+	_, err := db.CreateMaterializedView("stats", "orders", nil, []string{"customer"}, map[string]Accumulator{
+		"count": {Op: AccumulatorCount},
+	})
+	assert.NoError(t, err)
+
+	err = db.RenameCollection("orders", "purchases")
+	assert.NoError(t, err)
+	_, err = orders.InsertOne(map[string]any{"id": 1, "customer": "a"})
+	assert.NoError(t, err)
+
+	err = db.RenameCollection("stats", "summary")
+	assert.NoError(t, err)
+
+	summary := db.Collection("summary")
+	docs, err := summary.FindMany(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]any{{"customer": "a", "count": 1}}, docs)
+
+	err = db.DropCollection("purchases")
+	assert.NoError(t, err)
+	assert.Len(t, db.materialized, 0)
+
+	docs, err = summary.FindMany(nil)
+	assert.NoError(t, err)
+	assert.Len(t, docs, 1)
+
+	_, err = db.CreateMaterializedView("stats", "summary", nil, nil, map[string]Accumulator{
+		"count": {Op: AccumulatorCount},
+	})
+	assert.NoError(t, err)
+
+	err = db.RenameCollection("stats", "total")
+	assert.NoError(t, err)
+	err = db.DropCollection("total")
+	assert.NoError(t, err)
+	assert.Len(t, db.materialized, 0)
+}
+
+func TestDatabase_CreateMaterializedView_Stale(t *testing.T) {
+	db := New(faker.Word())
+
+	orders := db.Collection("orders")
+	stats, err := db.CreateMaterializedView("stats", "orders", nil, []string{"customer"}, map[string]Accumulator{
+		"count": {Op: AccumulatorCount},
+	})
+	assert.NoError(t, err)
+
+	err = stats.Indexes().Create(IndexModel{Keys: []string{"count"}, Name: "count", Unique: true})
+	assert.NoError(t, err)
+
+	_, err = orders.InsertOne(map[string]any{"id": 1, "customer": "a"})
+	assert.NoError(t, err)
+	_, err = orders.InsertOne(map[string]any{"id": 2, "customer": "b"})
+	assert.NoError(t, err)
+
+	_, err = stats.FindMany(nil)
+	assert.ErrorIs(t, err, ErrViewStale)
+}
@@ -0,0 +1,327 @@
+package memdb
+
+import (
+	"container/heap"
+	"github.com/siyul-park/memdb/internal/util"
+	"math/rand"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	EvictionPolicy int
+
+	MemoryStats struct {
+		Size      int
+		Limit     int
+		Evictions int
+	}
+
+	memory struct {
+		policy    EvictionPolicy
+		limit     int
+		ttlKey    string
+		entries   memoryHeap
+		elements  map[any]*memoryEntry
+		size      int
+		clock     uint64
+		evictions int64
+		lock      sync.Mutex
+	}
+
+	memoryEntry struct {
+		id     any
+		size   int
+		access uint64
+		hits   uint64
+		expire time.Time
+		index  int
+	}
+
+	memoryHeap struct {
+		policy  EvictionPolicy
+		entries []*memoryEntry
+	}
+
+	budget struct {
+		limit       int
+		collections func() []*Collection
+		evictions   int64
+	}
+)
+
+const (
+	EvictionLRU EvictionPolicy = iota
+	EvictionLFU
+	EvictionTTL
+	EvictionRandom
+)
+
+const (
+	keyExpireAt = "expireAt"
+)
+
+var _ heap.Interface = (*memoryHeap)(nil)
+
+func newMemory(limit int, policy EvictionPolicy, ttlKey string) *memory {
+	if ttlKey == "" {
+		ttlKey = keyExpireAt
+	}
+	return &memory{
+		policy:   policy,
+		limit:    limit,
+		ttlKey:   ttlKey,
+		entries:  memoryHeap{policy: policy},
+		elements: map[any]*memoryEntry{},
+	}
+}
+
+func (m *memory) push(id any, document map[string]any) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.clock++
+
+	size := sizeOf(document)
+	expire, _ := document[m.ttlKey].(time.Time)
+
+	if entry, ok := m.elements[id]; ok {
+		m.size += size - entry.size
+		entry.size = size
+		entry.expire = expire
+		entry.access = m.clock
+		entry.hits++
+		heap.Fix(&m.entries, entry.index)
+		return
+	}
+
+	entry := &memoryEntry{id: id, size: size, access: m.clock, hits: 1, expire: expire}
+	heap.Push(&m.entries, entry)
+	m.elements[id] = entry
+	m.size += size
+}
+
+func (m *memory) touch(ids ...any) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, id := range ids {
+		if entry, ok := m.elements[id]; ok {
+			m.clock++
+			entry.access = m.clock
+			entry.hits++
+			heap.Fix(&m.entries, entry.index)
+		}
+	}
+}
+
+func (m *memory) remove(id any) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if entry, ok := m.elements[id]; ok {
+		heap.Remove(&m.entries, entry.index)
+		delete(m.elements, id)
+		m.size -= entry.size
+	}
+}
+
+func (m *memory) overflow() []any {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var ids []any
+	for m.limit > 0 && m.size > m.limit && len(m.entries.entries) > 1 {
+		ids = append(ids, m.pop())
+	}
+	return ids
+}
+
+func (m *memory) victim() (any, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if len(m.entries.entries) == 0 {
+		return nil, false
+	}
+	return m.pop(), true
+}
+
+func (m *memory) pop() any {
+	i := 0
+	if m.policy == EvictionRandom {
+		i = rand.Intn(len(m.entries.entries))
+	}
+	entry := heap.Remove(&m.entries, i).(*memoryEntry)
+	delete(m.elements, entry.id)
+	m.size -= entry.size
+	return entry.id
+}
+
+func (m *memory) usage() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.size
+}
+
+func (m *memory) stats() MemoryStats {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return MemoryStats{
+		Size:      m.size,
+		Limit:     m.limit,
+		Evictions: int(atomic.LoadInt64(&m.evictions)),
+	}
+}
+
+func (m *memory) reset() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.entries.entries = nil
+	m.elements = map[any]*memoryEntry{}
+	m.size = 0
+}
+
+func (h *memoryHeap) Len() int {
+	return len(h.entries)
+}
+
+func (h *memoryHeap) Less(i, j int) bool {
+	x, y := h.entries[i], h.entries[j]
+	switch h.policy {
+	case EvictionLFU:
+		if x.hits != y.hits {
+			return x.hits < y.hits
+		}
+	case EvictionTTL:
+		if x.expire.IsZero() != y.expire.IsZero() {
+			return !x.expire.IsZero()
+		}
+		if !x.expire.Equal(y.expire) {
+			return x.expire.Before(y.expire)
+		}
+	}
+	return x.access < y.access
+}
+
+func (h *memoryHeap) Swap(i, j int) {
+	h.entries[i], h.entries[j] = h.entries[j], h.entries[i]
+	h.entries[i].index = i
+	h.entries[j].index = j
+}
+
+func (h *memoryHeap) Push(x any) {
+	entry := x.(*memoryEntry)
+	entry.index = len(h.entries)
+	h.entries = append(h.entries, entry)
+}
+
+func (h *memoryHeap) Pop() any {
+	n := len(h.entries)
+	entry := h.entries[n-1]
+	h.entries[n-1] = nil
+	h.entries = h.entries[:n-1]
+	entry.index = -1
+	return entry
+}
+
+func (b *budget) evict() {
+	if b.limit <= 0 {
+		return
+	}
+
+	for {
+		colls := b.collections()
+
+		total := 0
+		var largest *Collection
+		for _, coll := range colls {
+			if coll.memory == nil {
+				continue
+			}
+			size := coll.memory.usage()
+			total += size
+			if largest == nil || size > largest.memory.usage() {
+				largest = coll
+			}
+		}
+		if total <= b.limit || largest == nil {
+			return
+		}
+
+		id, ok := largest.memory.victim()
+		if !ok {
+			return
+		}
+		if largest.evict(id) > 0 {
+			atomic.AddInt64(&b.evictions, 1)
+		}
+	}
+}
+
+func (b *budget) stats() MemoryStats {
+	size := 0
+	for _, coll := range b.collections() {
+		if coll.memory != nil {
+			size += coll.memory.usage()
+		}
+	}
+	return MemoryStats{
+		Size:      size,
+		Limit:     b.limit,
+		Evictions: int(atomic.LoadInt64(&b.evictions)),
+	}
+}
+
+func sizeOf(value any) int {
+	if util.IsNil(value) {
+		return 0
+	}
+
+	switch v := value.(type) {
+	case string:
+		return len(v)
+	case []byte:
+		return len(v)
+	case bool:
+		return 1
+	case map[string]any:
+		size := 0
+		for k, e := range v {
+			size += len(k) + sizeOf(e)
+		}
+		return size
+	case []any:
+		size := 0
+		for _, e := range v {
+			size += sizeOf(e)
+		}
+		return size
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Map:
+		size := 0
+		for _, k := range rv.MapKeys() {
+			size += sizeOf(k.Interface()) + sizeOf(rv.MapIndex(k).Interface())
+		}
+		return size
+	case reflect.Slice, reflect.Array:
+		size := 0
+		for i := 0; i < rv.Len(); i++ {
+			size += sizeOf(rv.Index(i).Interface())
+		}
+		return size
+	case reflect.Pointer, reflect.Interface:
+		return sizeOf(rv.Elem().Interface())
+	case reflect.String:
+		return rv.Len()
+	}
+	return int(rv.Type().Size())
+}
@@ -0,0 +1,231 @@
+package memdb
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	Metrics interface {
+		ObserveOperation(collection string, operation string, duration time.Duration, err error)
+		ObserveScan(collection string, indexed bool)
+		ObserveListenerCount(collection string, count int)
+		ObserveLockWait(collection string, duration time.Duration)
+	}
+
+	OpenMetrics struct {
+		buckets    []float64
+		operations map[metricKey]*histogram
+		results    map[metricKey]int64
+		scans      map[metricKey]int64
+		listeners  map[string]int
+		lockWaits  map[string]*histogram
+		lock       sync.Mutex
+	}
+
+	OpenMetricsOptions struct {
+		Buckets []float64
+	}
+
+	metricKey struct {
+		collection string
+		label      string
+	}
+
+	histogram struct {
+		counts []int64
+		sum    float64
+		count  int64
+	}
+
+	countWriter struct {
+		w   io.Writer
+		n   int64
+		err error
+	}
+)
+
+var (
+	defaultBuckets = []float64{0.00001, 0.00005, 0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1}
+)
+
+var _ Metrics = (*OpenMetrics)(nil)
+var _ http.Handler = (*OpenMetrics)(nil)
+
+func NewOpenMetrics(opts ...*OpenMetricsOptions) *OpenMetrics {
+	buckets := defaultBuckets
+	for _, opt := range opts {
+		if opt != nil && len(opt.Buckets) > 0 {
+			buckets = opt.Buckets
+		}
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	return &OpenMetrics{
+		buckets:    buckets,
+		operations: map[metricKey]*histogram{},
+		results:    map[metricKey]int64{},
+		scans:      map[metricKey]int64{},
+		listeners:  map[string]int{},
+		lockWaits:  map[string]*histogram{},
+	}
+}
+
+func (m *OpenMetrics) ObserveOperation(collection string, operation string, duration time.Duration, err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	key := metricKey{collection: collection, label: operation}
+	h, ok := m.operations[key]
+	if !ok {
+		h = newHistogram(len(m.buckets))
+		m.operations[key] = h
+	}
+	h.observe(m.buckets, duration.Seconds())
+
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	m.results[metricKey{collection: collection, label: operation + "\x00" + status}]++
+}
+
+func (m *OpenMetrics) ObserveScan(collection string, indexed bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	path := "full"
+	if indexed {
+		path = "index"
+	}
+	m.scans[metricKey{collection: collection, label: path}]++
+}
+
+func (m *OpenMetrics) ObserveListenerCount(collection string, count int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.listeners[collection] = count
+}
+
+func (m *OpenMetrics) ObserveLockWait(collection string, duration time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	h, ok := m.lockWaits[collection]
+	if !ok {
+		h = newHistogram(len(m.buckets))
+		m.lockWaits[collection] = h
+	}
+	h.observe(m.buckets, duration.Seconds())
+}
+
+func (m *OpenMetrics) WriteTo(w io.Writer) (int64, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	cw := &countWriter{w: bufio.NewWriter(w)}
+
+	fmt.Fprintln(cw, "# TYPE memdb_operation_duration_seconds histogram")
+	fmt.Fprintln(cw, "# UNIT memdb_operation_duration_seconds seconds")
+	fmt.Fprintln(cw, "# HELP memdb_operation_duration_seconds Latency of collection operations.")
+	for _, key := range sortedKeys(m.operations) {
+		labels := fmt.Sprintf("collection=%s,operation=%s", quoteLabel(key.collection), quoteLabel(key.label))
+		m.operations[key].write(cw, "memdb_operation_duration_seconds", labels, m.buckets)
+	}
+
+	fmt.Fprintln(cw, "# TYPE memdb_operations counter")
+	fmt.Fprintln(cw, "# HELP memdb_operations Number of collection operations by result.")
+	for _, key := range sortedKeys(m.results) {
+		operation, status, _ := strings.Cut(key.label, "\x00")
+		fmt.Fprintf(cw, "memdb_operations_total{collection=%s,operation=%s,status=%s} %d\n", quoteLabel(key.collection), quoteLabel(operation), quoteLabel(status), m.results[key])
+	}
+
+	fmt.Fprintln(cw, "# TYPE memdb_scans counter")
+	fmt.Fprintln(cw, "# HELP memdb_scans Number of shard scans by access path.")
+	for _, key := range sortedKeys(m.scans) {
+		fmt.Fprintf(cw, "memdb_scans_total{collection=%s,path=%s} %d\n", quoteLabel(key.collection), quoteLabel(key.label), m.scans[key])
+	}
+
+	fmt.Fprintln(cw, "# TYPE memdb_listener_count gauge")
+	fmt.Fprintln(cw, "# HELP memdb_listener_count Number of listeners registered on a collection.")
+	for _, collection := range sortedKeys(m.listeners) {
+		fmt.Fprintf(cw, "memdb_listener_count{collection=%s} %d\n", quoteLabel(collection), m.listeners[collection])
+	}
+
+	fmt.Fprintln(cw, "# TYPE memdb_lock_wait_seconds histogram")
+	fmt.Fprintln(cw, "# UNIT memdb_lock_wait_seconds seconds")
+	fmt.Fprintln(cw, "# HELP memdb_lock_wait_seconds Time spent waiting for shard locks.")
+	for _, collection := range sortedKeys(m.lockWaits) {
+		m.lockWaits[collection].write(cw, "memdb_lock_wait_seconds", "collection="+quoteLabel(collection), m.buckets)
+	}
+
+	fmt.Fprintln(cw, "# EOF")
+
+	if cw.err != nil {
+		return cw.n, cw.err
+	}
+	return cw.n, cw.w.(*bufio.Writer).Flush()
+}
+
+func (m *OpenMetrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+	_, _ = m.WriteTo(w)
+}
+
+func newHistogram(size int) *histogram {
+	return &histogram{counts: make([]int64, size)}
+}
+
+func (h *histogram) observe(buckets []float64, value float64) {
+	for i, le := range buckets {
+		if value <= le {
+			h.counts[i]++
+		}
+	}
+	h.sum += value
+	h.count++
+}
+
+func (h *histogram) write(w io.Writer, name string, labels string, buckets []float64) {
+	for i, le := range buckets {
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, strconv.FormatFloat(le, 'g', -1, 64), h.counts[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+	fmt.Fprintf(w, "%s_sum{%s} %s\n", name, labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+	fmt.Fprintf(w, "%s_count{%s} %d\n", name, labels, h.count)
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	w.err = err
+	return n, err
+}
+
+func sortedKeys[K comparable, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+	return keys
+}
+
+func quoteLabel(value string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(value) + `"`
+}
@@ -0,0 +1,75 @@
+package memdb
+
+import (
+	"bytes"
+	"github.com/go-faker/faker/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpenMetrics_ObserveOperation(t *testing.T) {
+	m := NewOpenMetrics(&OpenMetricsOptions{Buckets: []float64{0.1, 1}})
+
+	m.ObserveOperation("users", "insert_one", 50*time.Millisecond, nil)
+	m.ObserveOperation("users", "insert_one", 500*time.Millisecond, ErrPKDuplicated)
+
+	var buf bytes.Buffer
+	n, err := m.WriteTo(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+
+	out := buf.String()
+	assert.Contains(t, out, `memdb_operation_duration_seconds_bucket{collection="users",operation="insert_one",le="0.1"} 1`)
+	assert.Contains(t, out, `memdb_operation_duration_seconds_bucket{collection="users",operation="insert_one",le="1"} 2`)
+	assert.Contains(t, out, `memdb_operation_duration_seconds_bucket{collection="users",operation="insert_one",le="+Inf"} 2`)
+	assert.Contains(t, out, `memdb_operation_duration_seconds_count{collection="users",operation="insert_one"} 2`)
+	assert.Contains(t, out, `memdb_operations_total{collection="users",operation="insert_one",status="ok"} 1`)
+	assert.Contains(t, out, `memdb_operations_total{collection="users",operation="insert_one",status="error"} 1`)
+	assert.True(t, strings.HasSuffix(out, "# EOF\n"))
+}
+
+func TestOpenMetrics_ObserveScan(t *testing.T) {
+	m := NewOpenMetrics()
+
+	db := New(faker.Word(), &DatabaseOptions{Metrics: m})
+	coll := db.Collection("users")
+
+	_, err := coll.InsertOne(map[string]any{"id": 1, "name": "a"})
+	assert.NoError(t, err)
+
+	_, err = coll.FindOne(Where("id").EQ(1))
+	assert.NoError(t, err)
+	_, err = coll.FindOne(Where("name").EQ("a"))
+	assert.NoError(t, err)
+
+	coll.Watch(func(_ Event, _ any) {})
+	_, err = coll.InsertOne(map[string]any{"id": 2})
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	_, err = m.WriteTo(&buf)
+	assert.NoError(t, err)
+
+	out := buf.String()
+	assert.Contains(t, out, `memdb_scans_total{collection="users",path="index"} 1`)
+	assert.Contains(t, out, `memdb_scans_total{collection="users",path="full"} 1`)
+	assert.Contains(t, out, `memdb_listener_count{collection="users"} 1`)
+	assert.Contains(t, out, `memdb_lock_wait_seconds_count{collection="users"}`)
+	assert.Contains(t, out, `memdb_operations_total{collection="users",operation="find_one",status="ok"} 2`)
+}
+
+func TestOpenMetrics_ServeHTTP(t *testing.T) {
+	m := NewOpenMetrics()
+	m.ObserveScan("a\"b", true)
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/openmetrics-text")
+	assert.Contains(t, w.Body.String(), `memdb_scans_total{collection="a\"b",path="index"} 1`)
+}
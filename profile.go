@@ -0,0 +1,232 @@
+package memdb
+
+import (
+	"context"
+	"github.com/siyul-park/memdb/internal/util"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	ProfileOptions struct {
+		Threshold  *time.Duration
+		SampleRate *float64
+		MaxEntries *int
+	}
+
+	profiler struct {
+		db         *Database
+		enabled    bool
+		threshold  time.Duration
+		sampleRate float64
+		maxEntries int
+		seq        int64
+		lock       sync.RWMutex
+	}
+
+	query struct {
+		operation OperationType
+		filter    *Filter
+		options   any
+		start     time.Time
+		scanned   int64
+		returned  int64
+		indexed   int64
+		full      int64
+	}
+
+	queryKey struct{}
+)
+
+const (
+	ProfileCollection = "system.profile"
+)
+
+const (
+	defaultProfileEntries = 1000
+)
+
+func newProfiler(db *Database) *profiler {
+	return &profiler{db: db}
+}
+
+func (p *profiler) configure(opt *ProfileOptions) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if util.IsNil(opt) {
+		p.enabled = false
+		return
+	}
+
+	p.maxEntries = defaultProfileEntries
+	if !util.IsNil(opt.MaxEntries) {
+		p.maxEntries = util.UnPtr(opt.MaxEntries)
+	}
+	if p.db.HasCollection(ProfileCollection) {
+		if coll := p.db.Collection(ProfileCollection); coll.capped == nil || coll.capped.maxDocuments != p.maxEntries {
+			_ = p.db.DropCollection(ProfileCollection)
+		}
+	}
+
+	p.enabled = true
+	p.threshold = util.UnPtr(opt.Threshold)
+	p.sampleRate = util.UnPtr(opt.SampleRate)
+}
+
+func (p *profiler) active() bool {
+	if p == nil {
+		return false
+	}
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.enabled
+}
+
+func (p *profiler) record(coll *Collection, q *query, duration time.Duration, err error) {
+	p.lock.RLock()
+	enabled, threshold, sampleRate, maxEntries := p.enabled, p.threshold, p.sampleRate, p.maxEntries
+	p.lock.RUnlock()
+
+	if !enabled {
+		return
+	}
+	if duration < threshold && (sampleRate <= 0 || rand.Float64() >= sampleRate) {
+		return
+	}
+
+	target := p.db.Collection(ProfileCollection, &CollectionOptions{MaxDocuments: util.Ptr(maxEntries)})
+	if target == coll {
+		return
+	}
+
+	entry := map[string]any{
+		"id":         atomic.AddInt64(&p.seq, 1),
+		"ts":         q.start,
+		"collection": coll.Name(),
+		"operation":  string(q.operation),
+		"scanned":    atomic.LoadInt64(&q.scanned),
+		"returned":   atomic.LoadInt64(&q.returned),
+		"duration":   duration,
+	}
+	if !util.IsNil(q.filter) {
+		if s, err := q.filter.String(); err == nil {
+			entry["filter"] = s
+		}
+	}
+	if options := renderOptions(q.options); len(options) > 0 {
+		entry["options"] = options
+	}
+	if plan := q.plan(); plan != "" {
+		entry["plan"] = plan
+	}
+	if err != nil {
+		entry["error"] = err.Error()
+	}
+
+	_, _ = target.InsertOne(entry)
+}
+
+func (q *query) plan() string {
+	indexed := atomic.LoadInt64(&q.indexed)
+	full := atomic.LoadInt64(&q.full)
+	switch {
+	case indexed > 0 && full > 0:
+		return "mixed"
+	case indexed > 0:
+		return "index"
+	case full > 0:
+		return "full"
+	}
+	return ""
+}
+
+func (q *query) observe(stat scanStat) {
+	if q == nil {
+		return
+	}
+	if stat.indexed {
+		atomic.AddInt64(&q.indexed, 1)
+	} else {
+		atomic.AddInt64(&q.full, 1)
+	}
+	atomic.AddInt64(&q.scanned, int64(stat.scanned))
+}
+
+func (q *query) result(returned int) {
+	if q != nil {
+		atomic.AddInt64(&q.returned, int64(returned))
+	}
+}
+
+func withQuery(ctx context.Context, q *query) context.Context {
+	return context.WithValue(ctx, queryKey{}, q)
+}
+
+func queryFrom(ctx context.Context) *query {
+	q, _ := ctx.Value(queryKey{}).(*query)
+	return q
+}
+
+func renderOptions(options any) map[string]any {
+	rendered := map[string]any{}
+	switch opt := options.(type) {
+	case *FindOptions:
+		if util.IsNil(opt) {
+			break
+		}
+		if !util.IsNil(opt.Limit) {
+			rendered["limit"] = util.UnPtr(opt.Limit)
+		}
+		if !util.IsNil(opt.Skip) {
+			rendered["skip"] = util.UnPtr(opt.Skip)
+		}
+		if len(opt.Sorts) > 0 {
+			var sorts []any
+			for _, s := range opt.Sorts {
+				order := "asc"
+				if s.Order == OrderDESC {
+					order = "desc"
+				}
+				sorts = append(sorts, map[string]any{"key": s.Key, "order": order})
+			}
+			rendered["sorts"] = sorts
+		}
+		if len(opt.Lookups) > 0 {
+			var lookups []any
+			for _, l := range opt.Lookups {
+				join := "left"
+				if l.Join == JoinInner {
+					join = "inner"
+				}
+				lookups = append(lookups, map[string]any{"from": l.From, "localField": l.LocalField, "foreignField": l.ForeignField, "as": l.As, "join": join})
+			}
+			rendered["lookups"] = lookups
+		}
+		if !util.IsNil(opt.Collation) {
+			rendered["collation"] = map[string]any{
+				"caseInsensitive":   opt.Collation.CaseInsensitive,
+				"accentInsensitive": opt.Collation.AccentInsensitive,
+				"numericOrdering":   opt.Collation.NumericOrdering,
+			}
+		}
+	case *UpdateOptions:
+		if util.IsNil(opt) {
+			break
+		}
+		if !util.IsNil(opt.Upsert) {
+			rendered["upsert"] = util.UnPtr(opt.Upsert)
+		}
+	case *VectorQuery:
+		if util.IsNil(opt) {
+			break
+		}
+		rendered["field"] = opt.Field
+		rendered["k"] = opt.K
+	}
+	return rendered
+}
@@ -0,0 +1,78 @@
+package memdb
+
+import (
+	"github.com/go-faker/faker/v4"
+	"github.com/siyul-park/memdb/internal/util"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestDatabase_SetProfile(t *testing.T) {
+	db := New(faker.Word(), &DatabaseOptions{
+		Profile: &ProfileOptions{MaxEntries: util.Ptr(3)},
+	})
+
+	coll := db.Collection("users")
+	err := coll.Indexes().Create(IndexModel{Keys: []string{"name"}, Name: "name"})
+	assert.NoError(t, err)
+
+	_, err = coll.InsertMany([]map[string]any{
+		{"id": 1, "name": "a", "age": 10},
+		{"id": 2, "name": "b", "age": 20},
+	})
+	assert.NoError(t, err)
+
+	_, err = coll.FindMany(Where("name").EQ("a"))
+	assert.NoError(t, err)
+
+	_, err = coll.FindMany(Where("age").GT(5), &FindOptions{Limit: util.Ptr(1), Sorts: []Sort{{Key: "age", Order: OrderDESC}}})
+	assert.NoError(t, err)
+
+	entries, err := db.Collection(ProfileCollection).FindMany(nil)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3)
+
+	assert.Equal(t, "insert_many", entries[0]["operation"])
+	assert.Equal(t, "users", entries[0]["collection"])
+
+	assert.Equal(t, "find_many", entries[1]["operation"])
+	assert.Equal(t, `name = "a"`, entries[1]["filter"])
+	assert.Equal(t, "index", entries[1]["plan"])
+	assert.Equal(t, int64(1), entries[1]["scanned"])
+	assert.Equal(t, int64(1), entries[1]["returned"])
+
+	assert.Equal(t, "full", entries[2]["plan"])
+	assert.Equal(t, int64(2), entries[2]["scanned"])
+	assert.Equal(t, int64(1), entries[2]["returned"])
+	assert.Equal(t, map[string]any{
+		"limit": 1,
+		"sorts": []any{map[string]any{"key": "age", "order": "desc"}},
+	}, entries[2]["options"])
+
+	t.Run("Threshold", func(t *testing.T) {
+		db.SetProfile(&ProfileOptions{Threshold: util.Ptr(time.Hour), MaxEntries: util.Ptr(3)})
+
+		count, err := db.Collection(ProfileCollection).DeleteMany(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, count)
+
+		_, err = coll.FindMany(nil)
+		assert.NoError(t, err)
+
+		entries, err := db.Collection(ProfileCollection).FindMany(nil)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 0)
+	})
+
+	t.Run("Disable", func(t *testing.T) {
+		db.SetProfile()
+
+		_, err = coll.FindMany(nil)
+		assert.NoError(t, err)
+
+		entries, err := db.Collection(ProfileCollection).FindMany(nil)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 0)
+	})
+}
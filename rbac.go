@@ -0,0 +1,415 @@
+package memdb
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/siyul-park/memdb/internal/util"
+	"sync"
+)
+
+type (
+	Permission int
+
+	Role struct {
+		Name   string
+		Grants []Grant
+	}
+
+	Grant struct {
+		Collection  string
+		Permissions Permission
+		Filter      *Filter
+	}
+
+	Principal struct {
+		Name  string
+		Roles []string
+	}
+
+	Session struct {
+		db        *Database
+		principal Principal
+	}
+
+	ScopedCollection struct {
+		session *Session
+		name    string
+	}
+
+	roles struct {
+		data map[string]Role
+		lock sync.RWMutex
+	}
+
+	checkKey struct{}
+)
+
+const (
+	PermissionRead Permission = 1 << iota
+	PermissionInsert
+	PermissionUpdate
+	PermissionDelete
+	PermissionIndexAdmin
+
+	PermissionAll = PermissionRead | PermissionInsert | PermissionUpdate | PermissionDelete | PermissionIndexAdmin
+)
+
+const (
+	AnyCollection = "*"
+)
+
+var (
+	ErrCodeForbidden = "forbidden"
+
+	ErrForbidden = errors.New(ErrCodeForbidden)
+)
+
+func (db *Database) SetRole(role Role) {
+	db.roles.lock.Lock()
+	defer db.roles.lock.Unlock()
+
+	db.roles.data[role.Name] = role
+}
+
+func (db *Database) DropRole(name string) {
+	db.roles.lock.Lock()
+	defer db.roles.lock.Unlock()
+
+	delete(db.roles.data, name)
+}
+
+func (db *Database) As(principal Principal) *Session {
+	return &Session{
+		db:        db,
+		principal: principal,
+	}
+}
+
+func (s *Session) Principal() Principal {
+	return s.principal
+}
+
+func (s *Session) ListCollections() []string {
+	var names []string
+	for _, name := range s.db.ListCollections() {
+		if s.permissions(name) != 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (s *Session) Collection(name string) *ScopedCollection {
+	return &ScopedCollection{
+		session: s,
+		name:    name,
+	}
+}
+
+func (s *Session) permissions(collection string) Permission {
+	var permissions Permission
+	for _, grant := range s.grants(collection) {
+		permissions |= grant.Permissions
+	}
+	return permissions
+}
+
+func (s *Session) authorize(collection string, permission Permission) (*Filter, error) {
+	var filters []*Filter
+	allowed := false
+	for _, grant := range s.grants(collection) {
+		if grant.Permissions&permission != permission {
+			continue
+		}
+		if util.IsNil(grant.Filter) {
+			return nil, nil
+		}
+		allowed = true
+		filters = append(filters, grant.Filter)
+	}
+	if !allowed {
+		return nil, errors.Wrapf(ErrForbidden, "%s on %s", s.principal.Name, collection)
+	}
+	if len(filters) == 1 {
+		return filters[0], nil
+	}
+	return filters[0].Or(filters[1:]...), nil
+}
+
+func (s *Session) grants(collection string) []Grant {
+	s.db.roles.lock.RLock()
+	defer s.db.roles.lock.RUnlock()
+
+	var grants []Grant
+	for _, name := range s.principal.Roles {
+		role, ok := s.db.roles.data[name]
+		if !ok {
+			continue
+		}
+		for _, grant := range role.Grants {
+			if grant.Collection == collection || grant.Collection == AnyCollection {
+				grants = append(grants, grant)
+			}
+		}
+	}
+	return grants
+}
+
+func (coll *ScopedCollection) Name() string {
+	return coll.name
+}
+
+func (coll *ScopedCollection) Indexes() ([]IndexModel, error) {
+	if _, err := coll.session.authorize(coll.name, PermissionRead); err != nil {
+		return nil, err
+	}
+	local, err := coll.local(false)
+	if err != nil {
+		return nil, err
+	}
+	return local.Indexes().List(), nil
+}
+
+func (coll *ScopedCollection) CreateIndex(index IndexModel) error {
+	return coll.CreateIndexContext(context.Background(), index)
+}
+
+func (coll *ScopedCollection) CreateIndexContext(ctx context.Context, index IndexModel) error {
+	if _, err := coll.session.authorize(coll.name, PermissionIndexAdmin); err != nil {
+		return err
+	}
+	local, err := coll.local(false)
+	if err != nil {
+		return err
+	}
+	return local.Indexes().CreateContext(ctx, index)
+}
+
+func (coll *ScopedCollection) DropIndex(name string) error {
+	return coll.DropIndexContext(context.Background(), name)
+}
+
+func (coll *ScopedCollection) DropIndexContext(ctx context.Context, name string) error {
+	if _, err := coll.session.authorize(coll.name, PermissionIndexAdmin); err != nil {
+		return err
+	}
+	local, err := coll.local(false)
+	if err != nil {
+		return err
+	}
+	return local.Indexes().DropContext(ctx, name)
+}
+
+func (coll *ScopedCollection) InsertOne(document map[string]any) (any, error) {
+	return coll.InsertOneContext(context.Background(), document)
+}
+
+func (coll *ScopedCollection) InsertOneContext(ctx context.Context, document map[string]any) (any, error) {
+	filter, err := coll.session.authorize(coll.name, PermissionInsert)
+	if err != nil {
+		return nil, err
+	}
+	local, err := coll.local(true)
+	if err != nil {
+		return nil, err
+	}
+	return local.InsertOneContext(withCheck(ctx, filter), document)
+}
+
+func (coll *ScopedCollection) InsertMany(documents []map[string]any) ([]any, error) {
+	return coll.InsertManyContext(context.Background(), documents)
+}
+
+func (coll *ScopedCollection) InsertManyContext(ctx context.Context, documents []map[string]any) ([]any, error) {
+	filter, err := coll.session.authorize(coll.name, PermissionInsert)
+	if err != nil {
+		return nil, err
+	}
+	local, err := coll.local(true)
+	if err != nil {
+		return nil, err
+	}
+	return local.InsertManyContext(withCheck(ctx, filter), documents)
+}
+
+func (coll *ScopedCollection) UpdateOne(filter *Filter, update map[string]any, opts ...*UpdateOptions) (bool, error) {
+	return coll.UpdateOneContext(context.Background(), filter, update, opts...)
+}
+
+func (coll *ScopedCollection) UpdateOneContext(ctx context.Context, filter *Filter, update map[string]any, opts ...*UpdateOptions) (bool, error) {
+	scope, err := coll.session.authorize(coll.name, PermissionUpdate)
+	if err != nil {
+		return false, err
+	}
+	upsert := false
+	if opt := mergeUpdateOptions(opts); !util.IsNil(opt) && util.UnPtr(opt.Upsert) {
+		if _, err := coll.session.authorize(coll.name, PermissionInsert); err != nil {
+			return false, err
+		}
+		upsert = true
+	}
+	local, err := coll.local(upsert)
+	if err != nil {
+		return false, err
+	}
+	return local.UpdateOneContext(withCheck(ctx, scope), scoped(filter, scope), update, opts...)
+}
+
+func (coll *ScopedCollection) UpdateMany(filter *Filter, update map[string]any, opts ...*UpdateOptions) (int, error) {
+	return coll.UpdateManyContext(context.Background(), filter, update, opts...)
+}
+
+func (coll *ScopedCollection) UpdateManyContext(ctx context.Context, filter *Filter, update map[string]any, opts ...*UpdateOptions) (int, error) {
+	scope, err := coll.session.authorize(coll.name, PermissionUpdate)
+	if err != nil {
+		return 0, err
+	}
+	upsert := false
+	if opt := mergeUpdateOptions(opts); !util.IsNil(opt) && util.UnPtr(opt.Upsert) {
+		if _, err := coll.session.authorize(coll.name, PermissionInsert); err != nil {
+			return 0, err
+		}
+		upsert = true
+	}
+	local, err := coll.local(upsert)
+	if err != nil {
+		return 0, err
+	}
+	return local.UpdateManyContext(withCheck(ctx, scope), scoped(filter, scope), update, opts...)
+}
+
+func (coll *ScopedCollection) DeleteOne(filter *Filter) (bool, error) {
+	return coll.DeleteOneContext(context.Background(), filter)
+}
+
+func (coll *ScopedCollection) DeleteOneContext(ctx context.Context, filter *Filter) (bool, error) {
+	scope, err := coll.session.authorize(coll.name, PermissionDelete)
+	if err != nil {
+		return false, err
+	}
+	local, err := coll.local(false)
+	if err != nil {
+		return false, err
+	}
+	return local.DeleteOneContext(ctx, scoped(filter, scope))
+}
+
+func (coll *ScopedCollection) DeleteMany(filter *Filter) (int, error) {
+	return coll.DeleteManyContext(context.Background(), filter)
+}
+
+func (coll *ScopedCollection) DeleteManyContext(ctx context.Context, filter *Filter) (int, error) {
+	scope, err := coll.session.authorize(coll.name, PermissionDelete)
+	if err != nil {
+		return 0, err
+	}
+	local, err := coll.local(false)
+	if err != nil {
+		return 0, err
+	}
+	return local.DeleteManyContext(ctx, scoped(filter, scope))
+}
+
+func (coll *ScopedCollection) FindOne(filter *Filter, opts ...*FindOptions) (map[string]any, error) {
+	return coll.FindOneContext(context.Background(), filter, opts...)
+}
+
+func (coll *ScopedCollection) FindOneContext(ctx context.Context, filter *Filter, opts ...*FindOptions) (map[string]any, error) {
+	scope, err := coll.session.authorize(coll.name, PermissionRead)
+	if err != nil {
+		return nil, err
+	}
+	if opts, err = coll.lookups(opts); err != nil {
+		return nil, err
+	}
+	local, err := coll.local(false)
+	if err != nil {
+		return nil, err
+	}
+	return local.FindOneContext(ctx, scoped(filter, scope), opts...)
+}
+
+func (coll *ScopedCollection) FindMany(filter *Filter, opts ...*FindOptions) ([]map[string]any, error) {
+	return coll.FindManyContext(context.Background(), filter, opts...)
+}
+
+func (coll *ScopedCollection) FindManyContext(ctx context.Context, filter *Filter, opts ...*FindOptions) ([]map[string]any, error) {
+	scope, err := coll.session.authorize(coll.name, PermissionRead)
+	if err != nil {
+		return nil, err
+	}
+	if opts, err = coll.lookups(opts); err != nil {
+		return nil, err
+	}
+	local, err := coll.local(false)
+	if err != nil {
+		return nil, err
+	}
+	return local.FindManyContext(ctx, scoped(filter, scope), opts...)
+}
+
+func (coll *ScopedCollection) NearestNeighbors(field string, vector []float64, k int, prefilter *Filter) ([]map[string]any, error) {
+	return coll.NearestNeighborsContext(context.Background(), field, vector, k, prefilter)
+}
+
+func (coll *ScopedCollection) NearestNeighborsContext(ctx context.Context, field string, vector []float64, k int, prefilter *Filter) ([]map[string]any, error) {
+	scope, err := coll.session.authorize(coll.name, PermissionRead)
+	if err != nil {
+		return nil, err
+	}
+	local, err := coll.local(false)
+	if err != nil {
+		return nil, err
+	}
+	return local.NearestNeighborsContext(ctx, field, vector, k, scoped(prefilter, scope))
+}
+
+func (coll *ScopedCollection) lookups(opts []*FindOptions) ([]*FindOptions, error) {
+	opt := mergeFindOptions(opts)
+	if util.IsNil(opt) || len(opt.Lookups) == 0 {
+		return opts, nil
+	}
+
+	lookups := make([]Lookup, len(opt.Lookups))
+	for i, lookup := range opt.Lookups {
+		scope, err := coll.session.authorize(lookup.From, PermissionRead)
+		if err != nil {
+			return nil, err
+		}
+		lookup.Filter = scoped(lookup.Filter, scope)
+		lookups[i] = lookup
+	}
+	return append(opts, &FindOptions{Lookups: lookups}), nil
+}
+
+func (coll *ScopedCollection) local(create bool) (*Collection, error) {
+	if create {
+		return coll.session.db.Collection(coll.name), nil
+	}
+	if local, ok := coll.session.db.get(coll.name); ok {
+		return local, nil
+	}
+	return nil, errors.Wrap(ErrCollectionNotFound, coll.name)
+}
+
+func scoped(filter *Filter, scope *Filter) *Filter {
+	if util.IsNil(scope) {
+		return filter
+	}
+	if util.IsNil(filter) {
+		return scope
+	}
+	return filter.And(scope)
+}
+
+func withCheck(ctx context.Context, filter *Filter) context.Context {
+	if util.IsNil(filter) {
+		return ctx
+	}
+	return context.WithValue(ctx, checkKey{}, filter)
+}
+
+func checkFrom(ctx context.Context) *Filter {
+	filter, _ := ctx.Value(checkKey{}).(*Filter)
+	return filter
+}
@@ -0,0 +1,115 @@
+package memdb
+
+import (
+	"github.com/go-faker/faker/v4"
+	"github.com/siyul-park/memdb/internal/util"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDatabase_As(t *testing.T) {
+	db := New(faker.Word())
+
+	db.SetRole(Role{
+		Name: "reader",
+		Grants: []Grant{
+			{Collection: "users", Permissions: PermissionRead},
+		},
+	})
+	db.SetRole(Role{
+		Name: "tenant",
+		Grants: []Grant{
+			{Collection: AnyCollection, Permissions: PermissionAll &^ PermissionIndexAdmin, Filter: Where("tenant").EQ("a")},
+		},
+	})
+
+	_, err := db.Collection("users").InsertMany([]map[string]any{
+		{"id": 1, "tenant": "a"},
+		{"id": 2, "tenant": "b"},
+	})
+	assert.NoError(t, err)
+
+	t.Run("Read", func(t *testing.T) {
+		coll := db.As(Principal{Name: "plugin", Roles: []string{"reader"}}).Collection("users")
+
+		docs, err := coll.FindMany(nil)
+		assert.NoError(t, err)
+		assert.Len(t, docs, 2)
+
+		_, err = coll.InsertOne(map[string]any{"id": 3})
+		assert.ErrorIs(t, err, ErrForbidden)
+
+		_, err = coll.DeleteMany(nil)
+		assert.ErrorIs(t, err, ErrForbidden)
+
+		err = coll.CreateIndex(IndexModel{Keys: []string{"tenant"}, Name: "tenant"})
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("RowLevel", func(t *testing.T) {
+		coll := db.As(Principal{Name: "plugin", Roles: []string{"tenant"}}).Collection("users")
+
+		docs, err := coll.FindMany(nil)
+		assert.NoError(t, err)
+		assert.Len(t, docs, 1)
+		assert.Equal(t, 1, docs[0]["id"])
+
+		doc, err := coll.FindOne(Where("id").EQ(2))
+		assert.NoError(t, err)
+		assert.Nil(t, doc)
+
+		docs, err = coll.FindMany(Where("tenant").EQ("a"))
+		assert.NoError(t, err)
+		assert.Equal(t, []map[string]any{{"id": 1, "tenant": "a"}}, docs)
+
+		docs, err = coll.FindMany(Where("tenant").EQ("b"))
+		assert.NoError(t, err)
+		assert.Len(t, docs, 0)
+
+		_, err = coll.InsertOne(map[string]any{"id": 3, "tenant": "b"})
+		assert.ErrorIs(t, err, ErrForbidden)
+
+		_, err = coll.InsertOne(map[string]any{"id": 3, "tenant": "a"})
+		assert.NoError(t, err)
+
+		_, err = coll.UpdateOne(Where("id").EQ(3), map[string]any{"tenant": "b"})
+		assert.ErrorIs(t, err, ErrForbidden)
+
+		ok, err := coll.UpdateOne(Where("id").EQ(2), map[string]any{"tenant": "a"}, &UpdateOptions{Upsert: util.Ptr(false)})
+		assert.NoError(t, err)
+		assert.False(t, ok)
+
+		count, err := coll.DeleteMany(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+
+		docs, err = db.Collection("users").FindMany(nil)
+		assert.NoError(t, err)
+		assert.Len(t, docs, 1)
+		assert.Equal(t, "b", docs[0]["tenant"])
+	})
+
+	t.Run("ListCollections", func(t *testing.T) {
+		db.Collection("secrets")
+
+		s := db.As(Principal{Name: "plugin", Roles: []string{"reader"}})
+		assert.Equal(t, []string{"users"}, s.ListCollections())
+
+		_, err := s.Collection("secrets").FindMany(nil)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		coll := db.As(Principal{Name: "plugin", Roles: []string{"tenant"}}).Collection("orders")
+
+		_, err := coll.FindMany(nil)
+		assert.ErrorIs(t, err, ErrCollectionNotFound)
+		_, err = coll.DeleteMany(nil)
+		assert.ErrorIs(t, err, ErrCollectionNotFound)
+		assert.False(t, db.HasCollection("orders"))
+
+		_, err = coll.InsertOne(map[string]any{"id": 1, "tenant": "a"})
+		assert.NoError(t, err)
+		assert.True(t, db.HasCollection("orders"))
+	})
+}
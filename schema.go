@@ -0,0 +1,411 @@
+package memdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/siyul-park/memdb/internal/util"
+	"github.com/siyul-park/memdb/internal/util/reflectutil"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+type (
+	Schema struct {
+		Type                 SchemaType         `json:"type,omitempty"`
+		Required             []string           `json:"required,omitempty"`
+		Properties           map[string]*Schema `json:"properties,omitempty"`
+		AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+		Items                *Schema            `json:"items,omitempty"`
+		Enum                 []any              `json:"enum,omitempty"`
+		Const                any                `json:"const,omitempty"`
+		Minimum              *float64           `json:"minimum,omitempty"`
+		Maximum              *float64           `json:"maximum,omitempty"`
+		ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+		ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
+		MinLength            *int               `json:"minLength,omitempty"`
+		MaxLength            *int               `json:"maxLength,omitempty"`
+		Pattern              string             `json:"pattern,omitempty"`
+		MinItems             *int               `json:"minItems,omitempty"`
+		MaxItems             *int               `json:"maxItems,omitempty"`
+		UniqueItems          bool               `json:"uniqueItems,omitempty"`
+		MinProperties        *int               `json:"minProperties,omitempty"`
+		MaxProperties        *int               `json:"maxProperties,omitempty"`
+	}
+
+	SchemaType []string
+
+	ValidationLevel int
+
+	ValidationError struct {
+		Errors []SchemaError
+	}
+
+	SchemaError struct {
+		Path    string
+		Keyword string
+		Message string
+	}
+)
+
+const (
+	ValidationStrict ValidationLevel = iota
+	ValidationModerate
+	ValidationOff
+)
+
+var (
+	ErrCodeValidation    = "validation"
+	ErrCodeInvalidSchema = "invalid_schema"
+
+	ErrValidation    = errors.New(ErrCodeValidation)
+	ErrInvalidSchema = errors.New(ErrCodeInvalidSchema)
+)
+
+var (
+	patterns = sync.Map{}
+)
+
+func ParseSchema(data []byte) (*Schema, error) {
+	var schema Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, errors.Wrap(ErrInvalidSchema, err.Error())
+	}
+	if err := schema.Compile(); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+func (t *SchemaType) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = SchemaType{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*t = multiple
+	return nil
+}
+
+func (t SchemaType) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+func (s *Schema) Compile() error {
+	if util.IsNil(s) {
+		return nil
+	}
+
+	for _, typ := range s.Type {
+		switch typ {
+		case "null", "boolean", "object", "array", "number", "integer", "string":
+		default:
+			return errors.Wrap(ErrInvalidSchema, fmt.Sprintf("unknown type %q", typ))
+		}
+	}
+	if s.Pattern != "" {
+		if _, err := compilePattern(s.Pattern); err != nil {
+			return errors.Wrap(ErrInvalidSchema, err.Error())
+		}
+	}
+	for _, sub := range s.Properties {
+		if err := sub.Compile(); err != nil {
+			return err
+		}
+	}
+	return s.Items.Compile()
+}
+
+func (s *Schema) Validate(value any) error {
+	var errs []SchemaError
+	s.validate(value, "", &errs)
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+func (s *Schema) validate(value any, path string, errs *[]SchemaError) {
+	if util.IsNil(s) {
+		return
+	}
+
+	report := func(keyword string, format string, args ...any) {
+		*errs = append(*errs, SchemaError{
+			Path:    path,
+			Keyword: keyword,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	if len(s.Type) > 0 {
+		matched := false
+		for _, typ := range s.Type {
+			if isSchemaType(value, typ) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			report("type", "expected %s, got %s", strings.Join(s.Type, " or "), schemaTypeOf(value))
+			return
+		}
+	}
+
+	if len(s.Enum) > 0 {
+		matched := false
+		for _, e := range s.Enum {
+			if schemaEqual(value, e) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			report("enum", "value is not one of the allowed values")
+		}
+	}
+	if s.Const != nil && !schemaEqual(value, s.Const) {
+		report("const", "value does not equal the constant")
+	}
+
+	if n, ok := toFloat(value); ok {
+		if s.Minimum != nil && n < *s.Minimum {
+			report("minimum", "must be >= %v", *s.Minimum)
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			report("maximum", "must be <= %v", *s.Maximum)
+		}
+		if s.ExclusiveMinimum != nil && n <= *s.ExclusiveMinimum {
+			report("exclusiveMinimum", "must be > %v", *s.ExclusiveMinimum)
+		}
+		if s.ExclusiveMaximum != nil && n >= *s.ExclusiveMaximum {
+			report("exclusiveMaximum", "must be < %v", *s.ExclusiveMaximum)
+		}
+	}
+
+	if str, ok := value.(string); ok {
+		length := utf8.RuneCountInString(str)
+		if s.MinLength != nil && length < *s.MinLength {
+			report("minLength", "length must be >= %d", *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			report("maxLength", "length must be <= %d", *s.MaxLength)
+		}
+		if s.Pattern != "" {
+			if re, err := compilePattern(s.Pattern); err != nil {
+				report("pattern", "invalid pattern %q", s.Pattern)
+			} else if !re.MatchString(str) {
+				report("pattern", "must match %q", s.Pattern)
+			}
+		}
+	}
+
+	if obj, ok := toObject(value); ok {
+		if s.MinProperties != nil && len(obj) < *s.MinProperties {
+			report("minProperties", "must have >= %d properties", *s.MinProperties)
+		}
+		if s.MaxProperties != nil && len(obj) > *s.MaxProperties {
+			report("maxProperties", "must have <= %d properties", *s.MaxProperties)
+		}
+		for _, key := range s.Required {
+			if _, ok := obj[key]; !ok {
+				*errs = append(*errs, SchemaError{
+					Path:    joinPath(path, key),
+					Keyword: "required",
+					Message: "is required",
+				})
+			}
+		}
+
+		var keys []string
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			if sub, ok := s.Properties[k]; ok {
+				sub.validate(obj[k], joinPath(path, k), errs)
+			} else if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				*errs = append(*errs, SchemaError{
+					Path:    joinPath(path, k),
+					Keyword: "additionalProperties",
+					Message: "is not allowed",
+				})
+			}
+		}
+	}
+
+	if arr, ok := toArray(value); ok {
+		if s.MinItems != nil && len(arr) < *s.MinItems {
+			report("minItems", "must have >= %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(arr) > *s.MaxItems {
+			report("maxItems", "must have <= %d items", *s.MaxItems)
+		}
+		if s.UniqueItems {
+			for i := 0; i < len(arr); i++ {
+				for j := i + 1; j < len(arr); j++ {
+					if schemaEqual(arr[i], arr[j]) {
+						report("uniqueItems", "items at %d and %d are equal", i, j)
+					}
+				}
+			}
+		}
+		if s.Items != nil {
+			for i, e := range arr {
+				s.Items.validate(e, path+"["+strconv.Itoa(i)+"]", errs)
+			}
+		}
+	}
+}
+
+func (e *ValidationError) Error() string {
+	var msgs []string
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return ErrCodeValidation + ": " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
+
+func (e SchemaError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(pattern, re)
+	return re, nil
+}
+
+func isSchemaType(value any, typ string) bool {
+	switch typ {
+	case "null":
+		return util.IsNil(value)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := toFloat(value)
+		return ok
+	case "integer":
+		n, ok := toFloat(value)
+		return ok && n == math.Trunc(n)
+	case "object":
+		_, ok := toObject(value)
+		return ok
+	case "array":
+		_, ok := toArray(value)
+		return ok
+	}
+	return false
+}
+
+func schemaTypeOf(value any) string {
+	for _, typ := range []string{"null", "boolean", "string", "integer", "number", "object", "array"} {
+		if isSchemaType(value, typ) {
+			return typ
+		}
+	}
+	return reflect.TypeOf(value).String()
+}
+
+func schemaEqual(x, y any) bool {
+	if a, ok := toFloat(x); ok {
+		if b, ok := toFloat(y); ok {
+			return a == b
+		}
+	}
+	return reflectutil.Equal(x, y)
+}
+
+func toFloat(value any) (float64, bool) {
+	if util.IsNil(value) {
+		return 0, false
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+func toObject(value any) (map[string]any, bool) {
+	if m, ok := value.(map[string]any); ok {
+		return m, true
+	}
+	if util.IsNil(value) {
+		return nil, false
+	}
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+	m := make(map[string]any, v.Len())
+	for _, k := range v.MapKeys() {
+		m[k.String()] = v.MapIndex(k).Interface()
+	}
+	return m, true
+}
+
+func toArray(value any) ([]any, bool) {
+	if s, ok := value.([]any); ok {
+		return s, true
+	}
+	if util.IsNil(value) {
+		return nil, false
+	}
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, false
+	}
+	if v.Type().Elem().Kind() == reflect.Uint8 {
+		return nil, false
+	}
+	s := make([]any, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		s[i] = v.Index(i).Interface()
+	}
+	return s, true
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,122 @@
+package memdb
+
+import (
+	"github.com/go-faker/faker/v4"
+	"github.com/siyul-park/memdb/internal/util"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseSchema(t *testing.T) {
+	schema, err := ParseSchema([]byte(`{
+		"type": "object",
+		"required": ["id", "name"],
+		"properties": {
+			"id": {"type": "string"},
+			"name": {"type": "string", "minLength": 1, "pattern": "^[A-Z]"},
+			"age": {"type": ["integer", "null"], "minimum": 0},
+			"tags": {"type": "array", "items": {"enum": ["a", "b"]}, "uniqueItems": true}
+		}
+	}`))
+	assert.NoError(t, err)
+	assert.Equal(t, SchemaType{"object"}, schema.Type)
+	assert.Equal(t, SchemaType{"integer", "null"}, schema.Properties["age"].Type)
+
+	_, err = ParseSchema([]byte(`{"pattern": "("}`))
+	assert.ErrorIs(t, err, ErrInvalidSchema)
+
+	_, err = ParseSchema([]byte(`{"type": "unknown"}`))
+	assert.ErrorIs(t, err, ErrInvalidSchema)
+}
+
+func TestSchema_Validate(t *testing.T) {
+	schema := &Schema{
+		Type:     SchemaType{"object"},
+		Required: []string{"id", "name"},
+		Properties: map[string]*Schema{
+			"id":   {Type: SchemaType{"string"}},
+			"name": {Type: SchemaType{"string"}, MinLength: util.Ptr(1), Pattern: "^[A-Z]"},
+			"age":  {Type: SchemaType{"integer", "null"}, Minimum: util.Ptr(0.0), ExclusiveMaximum: util.Ptr(200.0)},
+			"tags": {
+				Type:        SchemaType{"array"},
+				Items:       &Schema{Enum: []any{"a", "b"}},
+				UniqueItems: true,
+				MaxItems:    util.Ptr(2),
+			},
+			"address": {
+				Type:                 SchemaType{"object"},
+				Required:             []string{"city"},
+				AdditionalProperties: util.Ptr(false),
+				Properties: map[string]*Schema{
+					"city": {Type: SchemaType{"string"}},
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		when   map[string]any
+		expect []SchemaError
+	}{
+		{
+			when: map[string]any{
+				"id":      faker.UUIDHyphenated(),
+				"name":    "Alice",
+				"age":     20,
+				"tags":    []string{"a", "b"},
+				"address": map[string]any{"city": "Seoul"},
+			},
+			expect: nil,
+		},
+		{
+			when: map[string]any{
+				"id":  faker.UUIDHyphenated(),
+				"age": nil,
+			},
+			expect: []SchemaError{
+				{Path: "name", Keyword: "required", Message: "is required"},
+			},
+		},
+		{
+			when: map[string]any{
+				"id":   faker.UUIDHyphenated(),
+				"name": "alice",
+				"age":  1.5,
+			},
+			expect: []SchemaError{
+				{Path: "age", Keyword: "type", Message: "expected integer or null, got number"},
+				{Path: "name", Keyword: "pattern", Message: `must match "^[A-Z]"`},
+			},
+		},
+		{
+			when: map[string]any{
+				"id":      faker.UUIDHyphenated(),
+				"name":    "Alice",
+				"age":     200,
+				"tags":    []any{"a", "c", "a"},
+				"address": map[string]any{"zip": "0"},
+			},
+			expect: []SchemaError{
+				{Path: "address.city", Keyword: "required", Message: "is required"},
+				{Path: "address.zip", Keyword: "additionalProperties", Message: "is not allowed"},
+				{Path: "age", Keyword: "exclusiveMaximum", Message: "must be < 200"},
+				{Path: "tags", Keyword: "maxItems", Message: "must have <= 2 items"},
+				{Path: "tags", Keyword: "uniqueItems", Message: "items at 0 and 2 are equal"},
+				{Path: "tags[1]", Keyword: "enum", Message: "value is not one of the allowed values"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		err := schema.Validate(tc.when)
+		if tc.expect == nil {
+			assert.NoError(t, err)
+		} else {
+			assert.ErrorIs(t, err, ErrValidation)
+
+			var verr *ValidationError
+			assert.ErrorAs(t, err, &verr)
+			assert.Equal(t, tc.expect, verr.Errors)
+		}
+	}
+}
@@ -4,18 +4,28 @@ import "github.com/siyul-park/memdb/internal/util/reflectutil"
 
 type (
 	Sort struct {
-		Key   string
-		Order Order
+		Key       string
+		Order     Order
+		Collation *Collation
+	}
+
+	virtualField interface {
+		key() string
+		value(document map[string]any) any
 	}
 )
 
 func parseSorts(sorts []Sort) func(i, j map[string]any) bool {
+	return parseSortsWith(sorts, reflectutil.Get[any])
+}
+
+func parseSortsWith(sorts []Sort, get func(value any, key string) (any, bool)) func(i, j map[string]any) bool {
 	return func(i, j map[string]any) bool {
 		for _, s := range sorts {
-			x, _ := reflectutil.Get[any](i, s.Key)
-			y, _ := reflectutil.Get[any](j, s.Key)
+			x, _ := get(i, s.Key)
+			y, _ := get(j, s.Key)
 
-			e := reflectutil.Compare(x, y)
+			e := s.Collation.compare(x, y)
 			if e == 0 {
 				continue
 			}
@@ -28,3 +38,47 @@ func parseSorts(sorts []Sort) func(i, j map[string]any) bool {
 		return false
 	}
 }
+
+func collateSorts(sorts []Sort, collation *Collation) []Sort {
+	next := make([]Sort, len(sorts))
+	for i, s := range sorts {
+		if s.Collation == nil {
+			s.Collation = collation
+		}
+		next[i] = s
+	}
+	return next
+}
+
+func parseVirtualSorts(sorts []Sort, fields []virtualField) func(i, j map[string]any) bool {
+	if len(fields) == 0 {
+		return parseSorts(sorts)
+	}
+	return parseSortsWith(sorts, func(value any, key string) (any, bool) {
+		for _, field := range fields {
+			if field.key() == key {
+				return field.value(value.(map[string]any)), true
+			}
+		}
+		return reflectutil.Get[any](value, key)
+	})
+}
+
+func annotate(documents []map[string]any, fields []virtualField) []map[string]any {
+	if len(fields) == 0 {
+		return documents
+	}
+
+	docs := make([]map[string]any, len(documents))
+	for i, doc := range documents {
+		next := make(map[string]any, len(doc)+len(fields))
+		for k, v := range doc {
+			next[k] = v
+		}
+		for _, field := range fields {
+			next[field.key()] = field.value(doc)
+		}
+		docs[i] = next
+	}
+	return docs
+}
@@ -0,0 +1,267 @@
+package memdb
+
+import (
+	"fmt"
+	"github.com/siyul-park/memdb/internal/pool"
+	"github.com/siyul-park/memdb/internal/text"
+	"github.com/siyul-park/memdb/internal/util"
+	"github.com/siyul-park/memdb/internal/util/reflectutil"
+	"math"
+	"strings"
+	"sync"
+)
+
+type (
+	textScorer struct {
+		path   string
+		query  text.Query
+		pk     primaryKey
+		fields []*sync.Map
+		count  int
+		length int
+		df     sync.Map
+		scores sync.Map
+	}
+
+	textLengths struct{}
+)
+
+const (
+	KeyTextScore = "$score"
+)
+
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+func (iv *IndexView) insertText(data *sync.Map, model IndexModel, id any, document map[string]any) {
+	for _, k := range model.Keys {
+		v, ok := reflectutil.Get[string](document, k)
+		if !ok {
+			continue
+		}
+		tokens := text.Analyze(v)
+
+		field := loadOrStoreMap(data, k)
+		loadOrStoreMap(field, textLengths{}).Store(id, len(tokens))
+
+		positions := map[string][]int{}
+		for _, token := range tokens {
+			positions[token.Term] = append(positions[token.Term], token.Position)
+		}
+		for term, p := range positions {
+			loadOrStoreMap(field, term).Store(id, p)
+		}
+	}
+}
+
+func (iv *IndexView) deleteText(data *sync.Map, model IndexModel, id any, document map[string]any) {
+	for _, k := range model.Keys {
+		v, ok := reflectutil.Get[string](document, k)
+		if !ok {
+			continue
+		}
+		sub, ok := data.Load(k)
+		if !ok {
+			continue
+		}
+		field := sub.(*sync.Map)
+
+		terms := []any{textLengths{}}
+		for _, token := range text.Analyze(v) {
+			terms = append(terms, token.Term)
+		}
+		for _, term := range terms {
+			if sub, ok := field.Load(term); ok {
+				postings := sub.(*sync.Map)
+				postings.Delete(id)
+				if isEmptyMap(postings) {
+					field.Delete(term)
+					pool.PutMap(postings)
+				}
+			}
+		}
+		if isEmptyMap(field) {
+			data.Delete(k)
+			pool.PutMap(field)
+		}
+	}
+}
+
+func (iv *IndexView) findText(filter *Filter) ([]any, bool) {
+	if util.IsNil(filter) {
+		return nil, false
+	}
+
+	switch filter.OP {
+	case AND:
+		children, _ := filter.Value.([]*Filter)
+		for _, child := range children {
+			if ids, ok := iv.findText(child); ok {
+				return ids, true
+			}
+		}
+		return nil, false
+	case TEXT:
+		field, ok := iv.field(IndexText, filter.Key)
+		if !ok {
+			return nil, false
+		} else if field == nil {
+			return nil, true
+		}
+
+		query := text.Parse(fmt.Sprint(filter.Value))
+
+		var terms []string
+		terms = append(terms, query.Terms...)
+		for _, phrase := range query.Phrases {
+			terms = append(terms, phrase[0].Term)
+		}
+		if len(query.Prefixes) > 0 {
+			field.Range(func(key, _ any) bool {
+				if term, ok := key.(string); ok {
+					for _, prefix := range query.Prefixes {
+						if strings.HasPrefix(term, prefix) {
+							terms = append(terms, term)
+							break
+						}
+					}
+				}
+				return true
+			})
+		}
+
+		visits := map[any]struct{}{}
+		var ids []any
+		for _, term := range terms {
+			if sub, ok := field.Load(term); ok {
+				sub.(*sync.Map).Range(func(id, _ any) bool {
+					if _, ok := visits[id]; !ok {
+						visits[id] = struct{}{}
+						ids = append(ids, id)
+					}
+					return true
+				})
+			}
+		}
+		return ids, true
+	}
+	return nil, false
+}
+
+func (coll *Collection) scorer(filter *Filter) *textScorer {
+	f := findFilter(filter, TEXT)
+	if f == nil {
+		return nil
+	}
+
+	s := &textScorer{
+		path:  f.Key,
+		query: text.Parse(fmt.Sprint(f.Value)),
+		pk:    coll.pk,
+	}
+	for _, sh := range coll.shards {
+		func() {
+			sh.indexView.lock.RLock()
+			defer sh.indexView.lock.RUnlock()
+
+			if field, _ := sh.indexView.field(IndexText, f.Key); field != nil {
+				s.fields = append(s.fields, field)
+			}
+		}()
+	}
+	if len(s.fields) == 0 {
+		return nil
+	}
+
+	for _, field := range s.fields {
+		if sub, ok := field.Load(textLengths{}); ok {
+			sub.(*sync.Map).Range(func(_, value any) bool {
+				s.count++
+				s.length += value.(int)
+				return true
+			})
+		}
+	}
+	return s
+}
+
+func (s *textScorer) score(document map[string]any) float64 {
+	id, _ := s.pk.id(document)
+	if v, ok := s.scores.Load(id); ok {
+		return v.(float64)
+	}
+
+	v, _ := reflectutil.Get[string](document, s.path)
+	tokens := text.Analyze(v)
+
+	tf := map[string]int{}
+	for _, token := range tokens {
+		tf[token.Term]++
+	}
+
+	terms := map[string]struct{}{}
+	for _, term := range s.query.Terms {
+		terms[term] = struct{}{}
+	}
+	for _, phrase := range s.query.Phrases {
+		for _, token := range phrase {
+			terms[token.Term] = struct{}{}
+		}
+	}
+	for term := range tf {
+		for _, prefix := range s.query.Prefixes {
+			if strings.HasPrefix(term, prefix) {
+				terms[term] = struct{}{}
+			}
+		}
+	}
+
+	avgdl := float64(s.length) / math.Max(float64(s.count), 1)
+	dl := float64(len(tokens))
+
+	score := 0.0
+	for term := range terms {
+		f := float64(tf[term])
+		if f == 0 {
+			continue
+		}
+		df := float64(s.frequency(term))
+		idf := math.Log(1 + (float64(s.count)-df+0.5)/(df+0.5))
+		norm := 1 - bm25B
+		if avgdl > 0 {
+			norm += bm25B * dl / avgdl
+		}
+		score += idf * f * (bm25K1 + 1) / (f + bm25K1*norm)
+	}
+
+	s.scores.Store(id, score)
+	return score
+}
+
+func (s *textScorer) frequency(term string) int {
+	if v, ok := s.df.Load(term); ok {
+		return v.(int)
+	}
+
+	n := 0
+	for _, field := range s.fields {
+		if sub, ok := field.Load(term); ok {
+			sub.(*sync.Map).Range(func(_, _ any) bool {
+				n++
+				return true
+			})
+		}
+	}
+	s.df.Store(term, n)
+	return n
+}
+
+func (s *textScorer) key() string {
+	return KeyTextScore
+}
+
+func (s *textScorer) value(document map[string]any) any {
+	return s.score(document)
+}
@@ -0,0 +1,93 @@
+package memdb
+
+import (
+	"github.com/go-faker/faker/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCollection_FindMany_Text(t *testing.T) {
+	for _, shards := range []int{1, 4} {
+		coll := newCollection(faker.Name(), &CollectionOptions{Shards: &shards})
+
+		err := coll.Indexes().Create(IndexModel{Keys: []string{"name"}, Name: "name_text", Kind: IndexText})
+		assert.NoError(t, err)
+
+		_, err = coll.InsertMany([]map[string]any{
+			{"id": 1, "name": "Wireless Mouse"},
+			{"id": 2, "name": "Wired Mouse with wireless receiver and mouse pad"},
+			{"id": 3, "name": "Mechanical Keyboard"},
+			{"id": 4, "name": "Wireless Keyboard and Mouse Combo"},
+			{"id": 5, "name": "USB-C Charging Cable"},
+		})
+		assert.NoError(t, err)
+
+		docs, err := coll.FindMany(Where("name").Text("keyboards"))
+		assert.NoError(t, err)
+		assert.Len(t, docs, 2)
+
+		docs, err = coll.FindMany(Where("name").Text(`"wireless mouse"`))
+		assert.NoError(t, err)
+		assert.Len(t, docs, 1)
+		assert.Equal(t, 1, docs[0]["id"])
+
+		docs, err = coll.FindMany(Where("name").Text("charg*"))
+		assert.NoError(t, err)
+		assert.Len(t, docs, 1)
+		assert.Equal(t, 5, docs[0]["id"])
+
+		docs, err = coll.FindMany(Where("name").Text("mouse"), &FindOptions{
+			Sorts: []Sort{{Key: KeyTextScore, Order: OrderDESC}},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, docs, 3)
+		assert.Equal(t, 1, docs[0]["id"])
+		for i := 1; i < len(docs); i++ {
+			assert.GreaterOrEqual(t, docs[i-1][KeyTextScore], docs[i][KeyTextScore])
+		}
+
+		docs, err = coll.FindMany(Where("name").Text("mouse").And(Where("id").EQ(4)))
+		assert.NoError(t, err)
+		assert.Len(t, docs, 1)
+
+		_, err = coll.DeleteOne(Where("id").EQ(1))
+		assert.NoError(t, err)
+
+		docs, err = coll.FindMany(Where("name").Text(`"wireless mouse"`))
+		assert.NoError(t, err)
+		assert.Len(t, docs, 0)
+	}
+}
+
+func TestCollection_FindMany_TextWithoutIndex(t *testing.T) {
+	coll := newCollection(faker.Name())
+
+	_, err := coll.InsertMany([]map[string]any{
+		{"id": 1, "name": "Running Shoes"},
+		{"id": 2, "name": "Rain Jacket"},
+	})
+	assert.NoError(t, err)
+
+	docs, err := coll.FindMany(Where("name").Text("run"))
+	assert.NoError(t, err)
+	assert.Len(t, docs, 1)
+	assert.Equal(t, 1, docs[0]["id"])
+	assert.Nil(t, docs[0][KeyTextScore])
+}
+
+func TestIndexView_Stats_Text(t *testing.T) {
+	coll := newCollection(faker.Name())
+
+	err := coll.Indexes().Create(IndexModel{Keys: []string{"name"}, Name: "name_text", Kind: IndexText})
+	assert.NoError(t, err)
+
+	_, err = coll.InsertMany([]map[string]any{
+		{"id": 1, "name": "red apple"},
+		{"id": 2, "name": "green apple"},
+	})
+	assert.NoError(t, err)
+
+	stats := coll.Indexes().Stats()
+	assert.Len(t, stats, 2)
+	assert.Equal(t, IndexStats{Name: "name_text", Entries: 4, Keys: 3, Depth: 3}, stats[1])
+}
@@ -0,0 +1,349 @@
+package memdb
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/siyul-park/memdb/internal/hnsw"
+	"github.com/siyul-park/memdb/internal/util"
+	"github.com/siyul-park/memdb/internal/util/reflectutil"
+	"math"
+	"sync"
+)
+
+type (
+	VectorMetric int
+
+	VectorOptions struct {
+		Metric         VectorMetric
+		Dimensions     int
+		M              int
+		EfConstruction int
+		EfSearch       int
+	}
+
+	VectorQuery struct {
+		Field  string
+		Vector []float64
+		K      int
+	}
+
+	vectorLocator struct {
+		path      string
+		target    []float64
+		metric    VectorMetric
+		pk        primaryKey
+		distances sync.Map
+	}
+)
+
+const (
+	VectorCosine VectorMetric = iota
+	VectorDot
+	VectorL2
+)
+
+const (
+	KeyVectorDistance = "$vectorDistance"
+)
+
+const (
+	defaultEfSearch       = 64
+	vectorBruteForceLimit = 1024
+)
+
+var (
+	ErrCodeVectorDimension = "vector_dimension"
+
+	ErrVectorDimension = errors.New(ErrCodeVectorDimension)
+)
+
+func (coll *Collection) NearestNeighbors(field string, vector []float64, k int, prefilter *Filter) ([]map[string]any, error) {
+	return coll.NearestNeighborsContext(context.Background(), field, vector, k, prefilter)
+}
+
+func (coll *Collection) NearestNeighborsContext(ctx context.Context, field string, vector []float64, k int, prefilter *Filter) ([]map[string]any, error) {
+	r, err := coll.intercept(ctx, &Operation{
+		Type:    OperationNearestNeighbors,
+		Filter:  prefilter,
+		Options: &VectorQuery{Field: field, Vector: vector, K: k},
+	}, func(ctx context.Context, op *Operation) (any, error) {
+		query, _ := op.Options.(*VectorQuery)
+		return coll.nearestNeighborsContext(ctx, op.Filter, query)
+	})
+	docs, _ := r.([]map[string]any)
+	return docs, err
+}
+
+func (coll *Collection) nearestNeighborsContext(ctx context.Context, prefilter *Filter, query *VectorQuery) (docs []map[string]any, err error) {
+	ctx, q := coll.begin(ctx, OperationNearestNeighbors, prefilter, query)
+	defer coll.end(q, &err)
+
+	if util.IsNil(query) || query.K <= 0 {
+		return nil, nil
+	}
+
+	model, indexed := coll.vectorModel(query.Field)
+	metric := VectorCosine
+	if indexed {
+		metric = model.Vector.Metric
+	}
+	locator := &vectorLocator{
+		path:   query.Field,
+		target: normalizeVector(query.Vector, metric),
+		metric: metric,
+		pk:     coll.pk,
+	}
+
+	var allowed map[any]struct{}
+	if !indexed || !util.IsNil(prefilter) {
+		candidates, err := coll.findMany(withQuery(ctx, nil), prefilter)
+		if err != nil {
+			return nil, err
+		}
+		if !indexed || len(candidates) <= vectorBruteForceLimit {
+			for _, doc := range candidates {
+				if locator.value(doc) != nil {
+					docs = append(docs, doc)
+				}
+			}
+		} else {
+			allowed = make(map[any]struct{}, len(candidates))
+			for _, doc := range candidates {
+				id, _ := coll.pk.id(doc)
+				allowed[id] = struct{}{}
+			}
+		}
+	}
+	if indexed && (util.IsNil(prefilter) || allowed != nil) {
+		if docs, err = coll.searchVector(ctx, model, locator, query.K, allowed); err != nil {
+			return nil, err
+		}
+	}
+
+	fields := []virtualField{locator}
+	if err := sortContext(ctx, docs, parseVirtualSorts([]Sort{{Key: KeyVectorDistance, Order: OrderASC}}, fields)); err != nil {
+		return nil, err
+	}
+	if len(docs) > query.K {
+		docs = docs[:query.K]
+	}
+	coll.touch(docs...)
+	queryFrom(ctx).result(len(docs))
+
+	if docs, err = coll.decrypt(docs...); err != nil {
+		return nil, err
+	}
+	return annotate(docs, fields), nil
+}
+
+func (coll *Collection) searchVector(ctx context.Context, model IndexModel, locator *vectorLocator, k int, allowed map[any]struct{}) ([]map[string]any, error) {
+	ef := model.Vector.EfSearch
+	if ef <= 0 {
+		ef = defaultEfSearch
+	}
+
+	var filter func(id any) bool
+	if allowed != nil {
+		filter = func(id any) bool {
+			_, ok := allowed[id]
+			return ok
+		}
+	}
+
+	var docs []map[string]any
+	for _, s := range coll.shards {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		func() {
+			s.lock.RLock()
+			defer s.lock.RUnlock()
+
+			graph := s.indexView.graph(locator.path)
+			if graph == nil {
+				return
+			}
+			for _, r := range graph.Search(locator.target, k, ef, filter) {
+				if doc, ok := s.data.Load(r.ID); ok {
+					locator.distances.Store(r.ID, r.Distance)
+					docs = append(docs, doc.(map[string]any))
+				}
+			}
+		}()
+	}
+	return docs, nil
+}
+
+func (coll *Collection) vectorModel(field string) (IndexModel, bool) {
+	for _, model := range coll.indexView.List() {
+		if model.Kind != IndexVector {
+			continue
+		}
+		for _, k := range model.Keys {
+			if k == field {
+				if model.Vector == nil {
+					model.Vector = &VectorOptions{}
+				}
+				return model, true
+			}
+		}
+	}
+	return IndexModel{}, false
+}
+
+func (iv *IndexView) insertVector(data *sync.Map, model IndexModel, id any, document map[string]any) error {
+	opt := model.Vector
+	if opt == nil {
+		opt = &VectorOptions{}
+	}
+
+	for _, k := range model.Keys {
+		v, ok := reflectutil.Get[any](document, k)
+		if !ok {
+			continue
+		}
+		vector, ok := toVector(v)
+		if !ok {
+			continue
+		}
+
+		g, ok := data.Load(k)
+		if !ok {
+			g, _ = data.LoadOrStore(k, hnsw.New(vectorDistance(opt.Metric), opt.M, opt.EfConstruction))
+		}
+		graph := g.(*hnsw.Graph)
+
+		dimensions := opt.Dimensions
+		if dimensions == 0 {
+			dimensions = graph.Dimensions()
+		}
+		if dimensions > 0 && len(vector) != dimensions {
+			return errors.Wrapf(ErrVectorDimension, "%s has %d dimensions, expected %d", k, len(vector), dimensions)
+		}
+
+		graph.Insert(id, normalizeVector(vector, opt.Metric))
+	}
+	return nil
+}
+
+func (iv *IndexView) deleteVector(data *sync.Map, model IndexModel, id any) {
+	for _, k := range model.Keys {
+		if g, ok := data.Load(k); ok {
+			g.(*hnsw.Graph).Delete(id)
+		}
+	}
+}
+
+func (iv *IndexView) graph(key string) *hnsw.Graph {
+	iv.lock.RLock()
+	defer iv.lock.RUnlock()
+
+	for i, model := range iv.models {
+		if model.Kind != IndexVector {
+			continue
+		}
+		for _, k := range model.Keys {
+			if k != key {
+				continue
+			}
+			if g, ok := iv.data[i].Load(key); ok {
+				return g.(*hnsw.Graph)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+func (l *vectorLocator) key() string {
+	return KeyVectorDistance
+}
+
+func (l *vectorLocator) value(document map[string]any) any {
+	id, _ := l.pk.id(document)
+	if v, ok := l.distances.Load(id); ok {
+		return v
+	}
+
+	var distance any
+	if v, ok := reflectutil.Get[any](document, l.path); ok {
+		if vector, ok := toVector(v); ok && len(vector) == len(l.target) {
+			distance = vectorDistance(l.metric)(l.target, normalizeVector(vector, l.metric))
+		}
+	}
+	l.distances.Store(id, distance)
+	return distance
+}
+
+func walkGraphs(data *sync.Map, visit func(key []any, entries int)) {
+	data.Range(func(field, value any) bool {
+		visit([]any{field}, value.(*hnsw.Graph).Len())
+		return true
+	})
+}
+
+func vectorDistance(metric VectorMetric) func(x, y []float64) float64 {
+	switch metric {
+	case VectorDot:
+		return func(x, y []float64) float64 {
+			return -dot(x, y)
+		}
+	case VectorL2:
+		return func(x, y []float64) float64 {
+			sum := 0.0
+			for i := range x {
+				d := x[i] - y[i]
+				sum += d * d
+			}
+			return math.Sqrt(sum)
+		}
+	}
+	return func(x, y []float64) float64 {
+		return 1 - dot(x, y)
+	}
+}
+
+func normalizeVector(vector []float64, metric VectorMetric) []float64 {
+	if metric != VectorCosine {
+		return vector
+	}
+
+	norm := math.Sqrt(dot(vector, vector))
+	normalized := make([]float64, len(vector))
+	for i, v := range vector {
+		if norm > 0 {
+			normalized[i] = v / norm
+		}
+	}
+	return normalized
+}
+
+func toVector(value any) ([]float64, bool) {
+	if v, ok := value.([]float64); ok {
+		return v, len(v) > 0
+	}
+	elements, ok := toArray(value)
+	if !ok || len(elements) == 0 {
+		return nil, false
+	}
+	vector := make([]float64, len(elements))
+	for i, e := range elements {
+		if f, ok := toFloat(e); ok {
+			vector[i] = f
+		} else {
+			return nil, false
+		}
+	}
+	return vector, true
+}
+
+func dot(x, y []float64) float64 {
+	sum := 0.0
+	for i := range x {
+		if i < len(y) {
+			sum += x[i] * y[i]
+		}
+	}
+	return sum
+}
@@ -0,0 +1,110 @@
+package memdb
+
+import (
+	"github.com/go-faker/faker/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCollection_NearestNeighbors(t *testing.T) {
+	testCases := []struct {
+		name   string
+		index  *IndexModel
+		shards int
+		query  []float64
+		expect []any
+	}{
+		{
+			name:   "brute force",
+			shards: 1,
+			query:  []float64{1, 0.1},
+			expect: []any{1, 2},
+		},
+		{
+			name:   "cosine",
+			index:  &IndexModel{Keys: []string{"embedding"}, Name: "embedding_vector", Kind: IndexVector},
+			shards: 1,
+			query:  []float64{1, 0.1},
+			expect: []any{1, 2},
+		},
+		{
+			name:   "l2",
+			index:  &IndexModel{Keys: []string{"embedding"}, Name: "embedding_vector", Kind: IndexVector, Vector: &VectorOptions{Metric: VectorL2}},
+			shards: 4,
+			query:  []float64{0, 0.9},
+			expect: []any{3, 4},
+		},
+		{
+			name:   "dot",
+			index:  &IndexModel{Keys: []string{"embedding"}, Name: "embedding_vector", Kind: IndexVector, Vector: &VectorOptions{Metric: VectorDot}},
+			shards: 1,
+			query:  []float64{0, 1},
+			expect: []any{5, 3},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			coll := newCollection(faker.Name(), &CollectionOptions{Shards: &tc.shards})
+
+			if tc.index != nil {
+				err := coll.Indexes().Create(*tc.index)
+				assert.NoError(t, err)
+			}
+
+			_, err := coll.InsertMany([]map[string]any{
+				{"id": 1, "embedding": []float64{1, 0}, "category": "a"},
+				{"id": 2, "embedding": []float64{0.9, 0.3}, "category": "b"},
+				{"id": 3, "embedding": []any{0, 1}, "category": "a"},
+				{"id": 4, "embedding": []float64{0.1, 0.8}, "category": "b"},
+				{"id": 5, "embedding": []float64{3, 3}, "category": "b"},
+				{"id": 6, "name": faker.Name()},
+			})
+			assert.NoError(t, err)
+
+			docs, err := coll.NearestNeighbors("embedding", tc.query, 2, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expect, ids(docs))
+			assert.NotNil(t, docs[0][KeyVectorDistance])
+			assert.LessOrEqual(t, docs[0][KeyVectorDistance], docs[1][KeyVectorDistance])
+		})
+	}
+}
+
+func TestCollection_NearestNeighbors_Prefilter(t *testing.T) {
+	coll := newCollection(faker.Name())
+
+	err := coll.Indexes().Create(IndexModel{Keys: []string{"embedding"}, Name: "embedding_vector", Kind: IndexVector, Vector: &VectorOptions{Metric: VectorL2, Dimensions: 2}})
+	assert.NoError(t, err)
+
+	_, err = coll.InsertMany([]map[string]any{
+		{"id": 1, "embedding": []float64{1, 0}, "category": "a"},
+		{"id": 2, "embedding": []float64{0.9, 0.3}, "category": "b"},
+		{"id": 3, "embedding": []float64{0, 1}, "category": "a"},
+	})
+	assert.NoError(t, err)
+
+	docs, err := coll.NearestNeighbors("embedding", []float64{1, 0}, 1, Where("category").EQ("b"))
+	assert.NoError(t, err)
+	assert.Equal(t, []any{2}, ids(docs))
+
+	_, err = coll.InsertOne(map[string]any{"id": 4, "embedding": []float64{1, 0, 0}})
+	assert.ErrorIs(t, err, ErrVectorDimension)
+
+	_, err = coll.UpdateOne(Where("id").EQ(1), map[string]any{"embedding": []float64{0, 1}})
+	assert.NoError(t, err)
+
+	docs, err = coll.NearestNeighbors("embedding", []float64{1, 0}, 1, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{2}, ids(docs))
+
+	_, err = coll.DeleteOne(Where("id").EQ(2))
+	assert.NoError(t, err)
+
+	docs, err = coll.NearestNeighbors("embedding", []float64{1, 0}, 3, nil)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []any{1, 3}, ids(docs))
+
+	stats := coll.Indexes().Stats()
+	assert.Equal(t, IndexStats{Name: "embedding_vector", Entries: 2, Keys: 1, Depth: 2}, stats[1])
+}
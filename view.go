@@ -0,0 +1,447 @@
+package memdb
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/siyul-park/memdb/internal/util"
+	"github.com/siyul-park/memdb/internal/util/reflectutil"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type (
+	View struct {
+		db         *Database
+		name       string
+		source     string
+		filter     *Filter
+		projection Projection
+		sorts      []Sort
+		watchers   map[int]*Collection
+		lock       sync.Mutex
+	}
+
+	Projection map[string]bool
+)
+
+var (
+	ErrCodeViewNotFound = "view_notfound"
+	ErrCodeViewReadOnly = "view_readonly"
+
+	ErrViewNotFound = errors.New(ErrCodeViewNotFound)
+	ErrViewReadOnly = errors.New(ErrCodeViewReadOnly)
+)
+
+func (db *Database) CreateView(name string, source string, filter *Filter, projection Projection, sorts []Sort) (*View, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if _, ok := db.collections[name]; ok {
+		return nil, ErrCollectionExists
+	}
+	if _, ok := db.views[name]; ok {
+		return nil, ErrCollectionExists
+	}
+	if _, ok := db.collections[source]; !ok {
+		return nil, errors.Wrap(ErrCollectionNotFound, source)
+	}
+
+	v := &View{
+		db:         db,
+		name:       name,
+		source:     source,
+		filter:     filter,
+		projection: projection,
+		sorts:      sorts,
+		watchers:   map[int]*Collection{},
+	}
+	db.views[name] = v
+	return v, nil
+}
+
+func (db *Database) View(name string) (*View, bool) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	v, ok := db.views[name]
+	return v, ok
+}
+
+func (db *Database) ListViews() []string {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	names := make([]string, 0, len(db.views))
+	for name := range db.views {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func (db *Database) DropView(name string) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if _, ok := db.views[name]; !ok {
+		return ErrViewNotFound
+	}
+	delete(db.views, name)
+	return nil
+}
+
+func (v *View) Name() string {
+	return v.name
+}
+
+func (v *View) Source() string {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	return v.source
+}
+
+func (v *View) Watch(listener func(event Event, val any)) int {
+	source, ok := v.db.get(v.Source())
+	if !ok {
+		return 0
+	}
+
+	members := map[any]struct{}{}
+	docs, _ := source.findMany(withQuery(context.Background(), nil), v.filter)
+	for _, doc := range docs {
+		members[indexKey(source.pk.value(doc))] = struct{}{}
+	}
+
+	match := parseFilter(v.filter)
+	var lock sync.Mutex
+
+	id := source.Watch(func(event Event, val any) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if event == EventDelete {
+			if _, ok := members[indexKey(val)]; ok {
+				delete(members, indexKey(val))
+				listener(event, val)
+			}
+			return
+		}
+
+		doc, ok := val.(map[string]any)
+		if !ok {
+			return
+		}
+		key := indexKey(source.pk.value(doc))
+		if match(doc) {
+			members[key] = struct{}{}
+			listener(event, v.projection.project(doc, source.pk))
+		} else if _, ok := members[key]; ok {
+			delete(members, key)
+			listener(EventDelete, source.pk.value(doc))
+		}
+	})
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	v.watchers[id] = source
+	return id
+}
+
+func (v *View) Unwatch(listenerID int) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if source, ok := v.watchers[listenerID]; ok {
+		source.Unwatch(listenerID)
+		delete(v.watchers, listenerID)
+	}
+}
+
+func (v *View) rename(source string) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	v.source = source
+}
+
+func (v *View) rebind(replaced map[*Collection]*Collection) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	for id, source := range v.watchers {
+		if next, ok := replaced[source]; ok {
+			v.watchers[id] = next
+		}
+	}
+}
+
+func (v *View) InsertOne(document map[string]any) (any, error) {
+	return v.InsertOneContext(context.Background(), document)
+}
+
+func (v *View) InsertOneContext(_ context.Context, _ map[string]any) (any, error) {
+	return nil, errors.Wrap(ErrViewReadOnly, v.name)
+}
+
+func (v *View) InsertMany(documents []map[string]any) ([]any, error) {
+	return v.InsertManyContext(context.Background(), documents)
+}
+
+func (v *View) InsertManyContext(_ context.Context, _ []map[string]any) ([]any, error) {
+	return nil, errors.Wrap(ErrViewReadOnly, v.name)
+}
+
+func (v *View) UpdateOne(filter *Filter, update map[string]any, opts ...*UpdateOptions) (bool, error) {
+	return v.UpdateOneContext(context.Background(), filter, update, opts...)
+}
+
+func (v *View) UpdateOneContext(_ context.Context, _ *Filter, _ map[string]any, _ ...*UpdateOptions) (bool, error) {
+	return false, errors.Wrap(ErrViewReadOnly, v.name)
+}
+
+func (v *View) UpdateMany(filter *Filter, update map[string]any, opts ...*UpdateOptions) (int, error) {
+	return v.UpdateManyContext(context.Background(), filter, update, opts...)
+}
+
+func (v *View) UpdateManyContext(_ context.Context, _ *Filter, _ map[string]any, _ ...*UpdateOptions) (int, error) {
+	return 0, errors.Wrap(ErrViewReadOnly, v.name)
+}
+
+func (v *View) DeleteOne(filter *Filter) (bool, error) {
+	return v.DeleteOneContext(context.Background(), filter)
+}
+
+func (v *View) DeleteOneContext(_ context.Context, _ *Filter) (bool, error) {
+	return false, errors.Wrap(ErrViewReadOnly, v.name)
+}
+
+func (v *View) DeleteMany(filter *Filter) (int, error) {
+	return v.DeleteManyContext(context.Background(), filter)
+}
+
+func (v *View) DeleteManyContext(_ context.Context, _ *Filter) (int, error) {
+	return 0, errors.Wrap(ErrViewReadOnly, v.name)
+}
+
+func (v *View) FindOne(filter *Filter, opts ...*FindOptions) (map[string]any, error) {
+	return v.FindOneContext(context.Background(), filter, opts...)
+}
+
+func (v *View) FindOneContext(ctx context.Context, filter *Filter, opts ...*FindOptions) (map[string]any, error) {
+	docs, err := v.FindManyContext(ctx, filter, append(opts, util.Ptr(FindOptions{Limit: util.Ptr(1)}))...)
+	if err != nil || len(docs) == 0 {
+		return nil, err
+	}
+	return docs[0], nil
+}
+
+func (v *View) FindMany(filter *Filter, opts ...*FindOptions) ([]map[string]any, error) {
+	return v.FindManyContext(context.Background(), filter, opts...)
+}
+
+func (v *View) FindManyContext(ctx context.Context, filter *Filter, opts ...*FindOptions) ([]map[string]any, error) {
+	name := v.Source()
+	source, ok := v.db.get(name)
+	if !ok {
+		return nil, errors.Wrap(ErrCollectionNotFound, name)
+	}
+
+	opt := mergeFindOptions(opts)
+	if util.IsNil(opt) {
+		opt = &FindOptions{}
+	}
+	sorts := opt.Sorts
+	if sorts == nil {
+		sorts = v.sorts
+	}
+
+	keys := filterKeys(filter)
+	for _, s := range opt.Sorts {
+		keys = append(keys, s.Key)
+	}
+	pushdown := v.projection.visible(source.pk, keys...)
+
+	scan := &FindOptions{Sorts: sorts, Collation: opt.Collation}
+	if pushdown && !innerJoin(opt.Lookups) {
+		scan.Skip = opt.Skip
+		scan.Limit = opt.Limit
+	}
+	if !pushdown {
+		scan.Sorts = v.sorts
+	}
+
+	var docs []map[string]any
+	var err error
+	if pushdown {
+		docs, err = source.FindManyContext(ctx, scoped(filter, v.filter), scan)
+	} else {
+		docs, err = source.FindManyContext(ctx, v.filter, scan)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	projected := make([]map[string]any, 0, len(docs))
+	for _, doc := range docs {
+		projected = append(projected, v.projection.project(doc, source.pk))
+	}
+	docs = projected
+
+	if !pushdown {
+		match := parseFilterWith(filter, opt.Collation)
+		matched := docs[:0]
+		for _, doc := range docs {
+			if match(doc) {
+				matched = append(matched, doc)
+			}
+		}
+		docs = matched
+
+		if len(opt.Sorts) > 0 {
+			if err := sortContext(ctx, docs, parseSorts(collateSorts(opt.Sorts, opt.Collation))); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if docs, err = source.join(ctx, docs, &FindOptions{Lookups: opt.Lookups}); err != nil {
+		return nil, err
+	}
+	if !pushdown || innerJoin(opt.Lookups) {
+		docs = paginate(docs, opt)
+	}
+	return docs, nil
+}
+
+func (p Projection) project(document map[string]any, pk primaryKey) map[string]any {
+	if len(p) == 0 {
+		return document
+	}
+
+	if p.inclusive() {
+		next := map[string]any{}
+		for _, k := range pk {
+			if include, ok := p[k]; !ok || include {
+				copyPath(next, document, k)
+			}
+		}
+		for k, include := range p {
+			if include {
+				copyPath(next, document, k)
+			}
+		}
+		return next
+	}
+
+	next := make(map[string]any, len(document))
+	for k, v := range document {
+		next[k] = v
+	}
+	for k := range p {
+		deletePath(next, strings.Split(k, "."))
+	}
+	return next
+}
+
+func (p Projection) visible(pk primaryKey, keys ...string) bool {
+	if len(p) == 0 {
+		return true
+	}
+
+	for _, key := range keys {
+		if strings.HasPrefix(key, "$") {
+			continue
+		}
+		if p.inclusive() {
+			ok := false
+			for k, include := range p {
+				if include && (k == key || strings.HasPrefix(key, k+".")) {
+					ok = true
+				}
+			}
+			for _, k := range pk {
+				if include, exists := p[k]; (!exists || include) && (k == key || strings.HasPrefix(key, k+".")) {
+					ok = true
+				}
+			}
+			if !ok {
+				return false
+			}
+		} else {
+			for k := range p {
+				if k == key || strings.HasPrefix(key, k+".") || strings.HasPrefix(k, key+".") {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+func (p Projection) inclusive() bool {
+	for _, include := range p {
+		if include {
+			return true
+		}
+	}
+	return false
+}
+
+func copyPath(dst map[string]any, src map[string]any, key string) {
+	v, ok := reflectutil.Get[any](src, key)
+	if !ok {
+		return
+	}
+
+	path := strings.Split(key, ".")
+	for _, k := range path[:len(path)-1] {
+		sub := map[string]any{}
+		if prev, ok := dst[k].(map[string]any); ok {
+			for k, v := range prev {
+				sub[k] = v
+			}
+		}
+		dst[k] = sub
+		dst = sub
+	}
+	dst[path[len(path)-1]] = v
+}
+
+func deletePath(document map[string]any, path []string) {
+	if len(path) == 1 {
+		delete(document, path[0])
+		return
+	}
+
+	sub, ok := document[path[0]].(map[string]any)
+	if !ok {
+		return
+	}
+	next := make(map[string]any, len(sub))
+	for k, v := range sub {
+		next[k] = v
+	}
+	deletePath(next, path[1:])
+	document[path[0]] = next
+}
+
+func filterKeys(filter *Filter) []string {
+	if util.IsNil(filter) {
+		return nil
+	}
+
+	switch filter.OP {
+	case AND, OR:
+		var keys []string
+		children, _ := filter.Value.([]*Filter)
+		for _, child := range children {
+			keys = append(keys, filterKeys(child)...)
+		}
+		return keys
+	}
+	return []string{filter.Key}
+}
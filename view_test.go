@@ -0,0 +1,213 @@
+package memdb
+
+import (
+	"github.com/go-faker/faker/v4"
+	"github.com/siyul-park/memdb/internal/util"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDatabase_CreateView(t *testing.T) {
+	db := New(faker.Word())
+
+	_, err := db.CreateView("active", "users", nil, nil, nil)
+	assert.ErrorIs(t, err, ErrCollectionNotFound)
+
+	db.Collection("users")
+
+	v, err := db.CreateView("active", "users", Where("active").EQ(true), nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "active", v.Name())
+	assert.Equal(t, "users", v.Source())
+
+	_, err = db.CreateView("active", "users", nil, nil, nil)
+	assert.ErrorIs(t, err, ErrCollectionExists)
+	_, err = db.CreateCollection("active")
+	assert.ErrorIs(t, err, ErrCollectionExists)
+
+	_, err = db.Collection("active").InsertOne(map[string]any{"id": 1})
+	assert.ErrorIs(t, err, ErrCollectionExists)
+	assert.False(t, db.HasCollection("active"))
+
+	assert.Equal(t, []string{"active"}, db.ListViews())
+
+	found, ok := db.View("active")
+	assert.True(t, ok)
+	assert.Equal(t, v, found)
+
+	err = db.DropView("active")
+	assert.NoError(t, err)
+	err = db.DropView("active")
+	assert.ErrorIs(t, err, ErrViewNotFound)
+}
+
+func TestView_FindMany(t *testing.T) {
+	db := New(faker.Word())
+
+	_, err := db.Collection("users").InsertMany([]map[string]any{
+		{"id": 1, "name": "a", "age": 30, "active": true, "secret": "x", "profile": map[string]any{"city": "s", "phone": "1"}},
+		{"id": 2, "name": "b", "age": 20, "active": true, "secret": "y", "profile": map[string]any{"city": "b", "phone": "2"}},
+		{"id": 3, "name": "c", "age": 40, "active": false, "secret": "z"},
+	})
+	assert.NoError(t, err)
+
+	v, err := db.CreateView("active", "users", Where("active").EQ(true), Projection{"name": true, "age": true, "profile.city": true}, []Sort{{Key: "age", Order: OrderASC}})
+	assert.NoError(t, err)
+
+	docs, err := v.FindMany(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]any{
+		{"id": 2, "name": "b", "age": 20, "profile": map[string]any{"city": "b"}},
+		{"id": 1, "name": "a", "age": 30, "profile": map[string]any{"city": "s"}},
+	}, docs)
+
+	docs, err = v.FindMany(Where("age").GT(25))
+	assert.NoError(t, err)
+	assert.Equal(t, []any{1}, ids(docs))
+
+	docs, err = v.FindMany(Where("secret").EQ("x"))
+	assert.NoError(t, err)
+	assert.Len(t, docs, 0)
+
+	docs, err = v.FindMany(Where("secret").IsNull(), &FindOptions{
+		Sorts: []Sort{{Key: "name", Order: OrderDESC}},
+		Limit: util.Ptr(1),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []any{2}, ids(docs))
+
+	doc, err := v.FindOne(Where("name").EQ("c"))
+	assert.NoError(t, err)
+	assert.Nil(t, doc)
+
+	doc, err = v.FindOne(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, doc["id"])
+
+	exclude, err := db.CreateView("public", "users", nil, Projection{"secret": false, "profile.phone": false}, nil)
+	assert.NoError(t, err)
+
+	doc, err = exclude.FindOne(Where("id").EQ(1))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"id": 1, "name": "a", "age": 30, "active": true, "profile": map[string]any{"city": "s"}}, doc)
+
+	doc, err = db.Collection("users").FindOne(Where("id").EQ(1))
+	assert.NoError(t, err)
+	assert.Equal(t, "1", doc["profile"].(map[string]any)["phone"])
+}
+
+func TestView_FindMany_Predicate(t *testing.T) {
+	db := New(faker.Word())
+
+	users := db.Collection("users")
+	err := users.Indexes().Create(IndexModel{Keys: []string{"tenant"}, Name: "tenant"})
+	assert.NoError(t, err)
+
+	_, err = users.InsertMany([]map[string]any{
+		{"id": 1, "tenant": "a"},
+		{"id": 2, "tenant": "b"},
+	})
+	assert.NoError(t, err)
+
+	v, err := db.CreateView("tenant", "users", Where("tenant").EQ("a"), nil, nil)
+	assert.NoError(t, err)
+
+	docs, err := v.FindMany(Where("tenant").EQ("a"))
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]any{{"id": 1, "tenant": "a"}}, docs)
+
+	docs, err = v.FindMany(Where("tenant").EQ("b"))
+	assert.NoError(t, err)
+	assert.Len(t, docs, 0)
+
+	doc, err := v.FindOne(Where("tenant").EQ("a").And(Where("id").EQ(1)))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"id": 1, "tenant": "a"}, doc)
+}
+
+func TestView_Watch(t *testing.T) {
+	db := New(faker.Word())
+	users := db.Collection("users")
+
+	_, err := users.InsertOne(map[string]any{"id": 1, "active": true, "secret": "x"})
+	assert.NoError(t, err)
+
+	v, err := db.CreateView("active", "users", Where("active").EQ(true), Projection{"secret": false}, nil)
+	assert.NoError(t, err)
+
+	var events []Event
+	var values []any
+	id := v.Watch(func(event Event, val any) {
+		events = append(events, event)
+		values = append(values, val)
+	})
+
+	_, err = users.InsertOne(map[string]any{"id": 2, "active": false})
+	assert.NoError(t, err)
+	_, err = users.InsertOne(map[string]any{"id": 3, "active": true, "secret": "y"})
+	assert.NoError(t, err)
+	_, err = users.UpdateOne(Where("id").EQ(1), map[string]any{"active": false})
+	assert.NoError(t, err)
+	_, err = users.DeleteOne(Where("id").EQ(2))
+	assert.NoError(t, err)
+	_, err = users.DeleteOne(Where("id").EQ(3))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Event{EventInsert, EventDelete, EventDelete}, events)
+	assert.Equal(t, []any{map[string]any{"id": 3, "active": true}, 1, 3}, values)
+
+	v.Unwatch(id)
+
+	_, err = users.InsertOne(map[string]any{"id": 4, "active": true})
+	assert.NoError(t, err)
+	assert.Len(t, events, 3)
+}
+
+func TestView_ReadOnly(t *testing.T) {
+	db := New(faker.Word())
+	db.Collection("users")
+
+	v, err := db.CreateView("all", "users", nil, nil, nil)
+	assert.NoError(t, err)
+
+	_, err = v.InsertOne(map[string]any{"id": 1})
+	assert.ErrorIs(t, err, ErrViewReadOnly)
+	_, err = v.InsertMany([]map[string]any{{"id": 1}})
+	assert.ErrorIs(t, err, ErrViewReadOnly)
+	_, err = v.UpdateOne(nil, map[string]any{"id": 1})
+	assert.ErrorIs(t, err, ErrViewReadOnly)
+	_, err = v.UpdateMany(nil, map[string]any{"id": 1})
+	assert.ErrorIs(t, err, ErrViewReadOnly)
+	_, err = v.DeleteOne(nil)
+	assert.ErrorIs(t, err, ErrViewReadOnly)
+	_, err = v.DeleteMany(nil)
+	assert.ErrorIs(t, err, ErrViewReadOnly)
+
+	docs, err := db.Collection("users").FindMany(nil)
+	assert.NoError(t, err)
+	assert.Len(t, docs, 0)
+}
+
+func TestView_Source(t *testing.T) {
+	db := New(faker.Word())
+
+	_, err := db.Collection("users").InsertOne(map[string]any{"id": 1, "active": true})
+	assert.NoError(t, err)
+
+	v, err := db.CreateView("active", "users", Where("active").EQ(true), nil, nil)
+	assert.NoError(t, err)
+
+	err = db.RenameCollection("users", "members")
+	assert.NoError(t, err)
+	assert.Equal(t, "members", v.Source())
+
+	docs, err := v.FindMany(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{1}, ids(docs))
+
+	err = db.DropCollection("members")
+	assert.NoError(t, err)
+
+	_, ok := db.View("active")
+	assert.False(t, ok)
+}